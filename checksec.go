@@ -0,0 +1,86 @@
+package main
+
+import (
+	"cycle1/machoHeader"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+//checksec [-json] <file>...
+//Exits with 1 if any file breaks the hardening policy or cannot be read. Universal binaries are checked one
+//slice at a time.
+func checksec(args []string){
+	asJSON := false
+	if 0 != len(args) && "-json" == args[0]{
+		asJSON = true
+		args = args[1:]
+	}
+	if 0 == len(args){
+		usage()
+	}
+
+	var reports []machoHeader.SecurityReport
+	failed := false
+	for _, fileName := range args{
+		//a file which cannot be checked does not pass the gate
+		images, ok := loadImages("checksec", fileName)
+		failed = failed || !ok
+		for _, image := range images{
+			report := image.header.Checksec()
+			report.File = image.label
+			reports = append(reports, report)
+			failed = failed || 0 != len(report.Violations)
+		}
+	}
+
+	if asJSON{
+		out, err := json.MarshalIndent(reports, "", "  ")
+		if nil != err{
+			fmt.Fprintln(os.Stderr, "checksec:", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(out))
+	} else {
+		for _, report := range reports{
+			printSecurityReport(report)
+		}
+	}
+
+	if failed{
+		os.Exit(1)
+	}
+}
+
+func printSecurityReport(report machoHeader.SecurityReport){
+	fmt.Println(report.File)
+	fmt.Printf("\tPIE:%s%s\n", strings.Repeat(" ", 25-4), yesNo(report.PIE))
+	fmt.Printf("\tNo heap execution:%s%s\n", strings.Repeat(" ", 25-18), yesNo(report.NoHeapExecution))
+	fmt.Printf("\tStack execution:%s%s\n", strings.Repeat(" ", 25-16), yesNo(report.AllowStackExecution))
+	fmt.Printf("\tStack canary:%s%s\n", strings.Repeat(" ", 25-13), yesNo(report.StackCanary))
+	fmt.Printf("\tARC:%s%s\n", strings.Repeat(" ", 25-4), yesNo(report.ARC))
+	fmt.Printf("\tSigned:%s%s\n", strings.Repeat(" ", 25-7), yesNo(report.Signed))
+	if report.Signed{
+		fmt.Printf("\tSigning flags:%s%s\n", strings.Repeat(" ", 25-14), strings.Join(report.CodeSigningFlags, " "))
+	}
+	fmt.Printf("\tHardened runtime:%s%s\n", strings.Repeat(" ", 25-17), yesNo(report.HardenedRuntime))
+	fmt.Printf("\tLibrary validation:%s%s\n", strings.Repeat(" ", 25-19), yesNo(report.LibraryValidation))
+	fmt.Printf("\tRestricted:%s%s\n", strings.Repeat(" ", 25-11), yesNo(report.Restricted))
+	fmt.Printf("\tEncrypted:%s%s\n", strings.Repeat(" ", 25-10), yesNo(report.Encrypted))
+	fmt.Printf("\tRWX segments:%s%s\n", strings.Repeat(" ", 25-13), strings.Join(report.RWXSegments, " "))
+	fmt.Printf("\tSuspiciousness:%s%d/100\n", strings.Repeat(" ", 25-15), report.Suspiciousness)
+	for _, anomaly := range report.Anomalies{
+		fmt.Println("\tANOMALY:", anomaly)
+	}
+	for _, violation := range report.Violations{
+		fmt.Println("\tFAIL:", violation)
+	}
+}
+
+func yesNo(value bool)string{
+	if value{
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,63 @@
+package demangle
+
+import (
+	"errors"
+	"strings"
+)
+
+//Demangles C++ (Itanium ABI) and Swift symbol names without calling out to c++filt or swift-demangle.
+//Names are accepted as they appear in a Mach-O symbol table, with the extra leading underscore the
+//compiler adds ("__ZN3foo3barEv", "_$s4main3fooyyF"), or without it.
+
+var ErrNotMangled = errors.New("not a mangled name")
+var ErrInvalid = errors.New("invalid or unsupported mangling")
+
+/*
+	//////////////////////////////////////// PUBLIC METHODS ////////////////////////////////////////
+*/
+
+//Returns the demangled form of name, or name itself if it is not mangled or uses something the
+//demanglers do not understand. This is what the listings use.
+func Demangle(name string)string{
+	demangled, err := Symbol(name)
+	if nil != err{
+		return name
+	}
+	return demangled
+}
+
+//Demangles a C++ or Swift symbol, picking the scheme from its prefix.
+func Symbol(name string)(string, error){
+	switch{
+	case IsItanium(name):
+		return Itanium(name)
+	case IsSwift(name):
+		return Swift(name)
+	}
+	return "", ErrNotMangled
+}
+
+//True for names with the Itanium C++ ABI prefix _Z, including block invocations "___Z..._block_invoke".
+func IsItanium(name string)bool{
+	name = strings.TrimPrefix(name, "_")
+	return strings.HasPrefix(name, "_Z") || strings.HasPrefix(name, "__Z")
+}
+
+//True for Swift 5 ($s), Swift 4.2 ($S), Swift 4.0 (_T0) and pre Swift 4 (_T) names.
+func IsSwift(name string)bool{
+	name = trimSwiftUnderscore(name)
+	return strings.HasPrefix(name, "$s") || strings.HasPrefix(name, "$S") || strings.HasPrefix(name, "$e") ||
+		strings.HasPrefix(name, "_T")
+}
+
+/*
+	//////////////////////////////////////// PRIVATE METHODS ////////////////////////////////////////
+*/
+
+//Drops the underscore Mach-O puts in front of every C symbol, "_$s..." and "__T..."
+func trimSwiftUnderscore(name string)string{
+	if strings.HasPrefix(name, "_$") || strings.HasPrefix(name, "__T"){
+		return name[1:]
+	}
+	return name
+}
@@ -0,0 +1,187 @@
+package demangle
+
+import (
+	"errors"
+	"testing"
+)
+
+//Expected output follows llvm-cxxfilt, the demangler Apple's toolchain ships, rather than GNU c++filt.
+//The two differ in "decltype(nullptr)" against "std::nullptr_t", "float __vector(4)" against
+//"float vector[4]", "> >" against ">>" and in whether "Ss" is spelled out, and llvm-cxxfilt is what a
+//Mach-O user compares the listings with.
+var itaniumCases = []struct{
+	mangled string
+	want string
+}{
+	{"__Z1fv", "f()"},
+	{"__Z3addii", "add(int, int)"},
+	{"__ZN3foo3barEv", "foo::bar()"},
+	{"__ZN5Outer5Inner6methodEPKcRi", "Outer::Inner::method(char const*, int&)"},
+	{"__ZNK3Foo3getEv", "Foo::get() const"},
+	{"__Z1fPKPVi", "f(int volatile* const*)"},
+	{"__Z1fOi", "f(int&&)"},
+	{"__Z1fA_i", "f(int [])"},
+	{"__Z1fRA10_i", "f(int (&) [10])"},
+	{"__Z1fPFivE", "f(int (*)())"},
+	{"__Z1fM3FooFivE", "f(int (Foo::*)())"},
+	{"__Z1fDn", "f(std::nullptr_t)"},
+	{"__Z1fDv4_f", "f(float vector[4])"},
+
+	//constructors and destructors
+	{"__ZN3FooC1Ev", "Foo::Foo()"},
+	{"__ZN3FooC2ERKS_", "Foo::Foo(Foo const&)"},
+	{"__ZN3FooD0Ev", "Foo::~Foo()"},
+	{"__ZN3FooD1Ev", "Foo::~Foo()"},
+	{"__ZNSt9exceptionD2Ev", "std::exception::~exception()"},
+
+	//substitutions
+	{"__ZN2ns1fENS_1AE", "ns::f(ns::A)"},
+	{"__Z1fSs", "f(std::string)"},
+	{"__ZNSs4sizeEv", "std::string::size()"},
+	{"__ZNSt3__112basic_stringIcNS_11char_traitsIcEENS_9allocatorIcEEED1Ev",
+		"std::__1::basic_string<char, std::__1::char_traits<char>, std::__1::allocator<char>>::~basic_string()"},
+	{"__ZNSt3__16vectorIiNS_9allocatorIiEEE9push_backERKi",
+		"std::__1::vector<int, std::__1::allocator<int>>::push_back(int const&)"},
+
+	//templates
+	{"__Z5printIiEvT_", "void print<int>(int)"},
+	{"__ZN9Container3mapIFivEEEvT_", "void Container::map<int ()>(int ())"},
+	{"__ZN3foo3bazIJiiEEEvDpT_", "void foo::baz<int, int>(int, int)"},
+	{"__ZNKSt3__14hashIiEclEi", "std::__1::hash<int>::operator()(int) const"},
+
+	//operators, local names and special names
+	{"__ZN3FooaSERKS_", "Foo::operator=(Foo const&)"},
+	{"__ZN3FooplERKS_", "Foo::operator+(Foo const&)"},
+	{"__ZN3FooixEm", "Foo::operator[](unsigned long)"},
+	{"__ZN3FoocvbEv", "Foo::operator bool()"},
+	{"__ZZ4mainE1x", "main::x"},
+	{"__ZZN3Foo3barEvE5local", "Foo::bar()::local"},
+	{"__ZL6helperv", "helper()"},
+	{"__ZTV3Foo", "vtable for Foo"},
+	{"__ZTI3Foo", "typeinfo for Foo"},
+	{"__ZTS3Foo", "typeinfo name for Foo"},
+	{"__ZThn8_N3Foo3barEv", "non-virtual thunk to Foo::bar()"},
+
+	//blocks
+	{"___Z3foov_block_invoke", "invocation function for block in foo()"},
+	{"___Z3foov_block_invoke_2", "invocation function for block in foo()"},
+	{"___ZN3Foo3barEv_block_invoke", "invocation function for block in Foo::bar()"},
+}
+
+var swiftCases = []struct{
+	mangled string
+	want string
+}{
+	//Swift 5
+	{"_$s4main3fooyyF", "main.foo() -> ()"},
+	{"$s4main3fooyyF", "main.foo() -> ()"},
+	{"_$s4main3FooC3baryySiF", "main.Foo.bar(Swift.Int) -> ()"},
+	{"_$s4main3addyS2i_SitF", "main.add(Swift.Int, Swift.Int) -> Swift.Int"},
+	{"_$s4main3fooyySSF", "main.foo(Swift.String) -> ()"},
+	{"_$s4main3FooCACycfc", "main.Foo.init() -> main.Foo"},
+	{"_$s4main3FooCfd", "main.Foo.deinit"},
+	{"_$s4main3FooCfD", "main.Foo.__deallocating_deinit"},
+	{"_$s4main3FooC1xSivg", "main.Foo.x.getter : Swift.Int"},
+	{"_$s4main3FooC1xSivs", "main.Foo.x.setter : Swift.Int"},
+	{"_$s4main3FooC1xSivM", "main.Foo.x.modify : Swift.Int"},
+	{"_$s4main3FooC1xSivpMV", "property descriptor for main.Foo.x : Swift.Int"},
+	{"_$s4main3fooyyFyycfU_", "closure #1 in main.foo() -> ()"},
+	{"_$s4main3BarPAAE3bazyyF", "(extension in main):main.Bar.baz() -> ()"},
+	{"_$s4main3FooCMa", "type metadata accessor for main.Foo"},
+	{"_$s4main3FooVMn", "nominal type descriptor for main.Foo"},
+	{"_$s4main3FooVN", "type metadata for main.Foo"},
+	{"_$sSiN", "type metadata for Swift.Int"},
+	{"_$sSo8NSObjectCN", "type metadata for __C.NSObject"},
+	{"_$s4main3FooVAA3BarAAMc", "protocol conformance descriptor for main.Foo : main.Bar in main"},
+	{"_$s4main3FooVAA3BarAAWP", "protocol witness table for main.Foo : main.Bar in main"},
+
+	//Swift 4.2 and 4.0
+	{"_$S4main3fooyyF", "main.foo() -> ()"},
+	{"__T04main3fooyyF", "main.foo() -> ()"},
+	{"__T04main3FooCACycfc", "main.Foo.init() -> main.Foo"},
+
+	//before Swift 4, and Objective-C runtime names of Swift classes
+	{"__TF4main3fooFT_T_", "main.foo() -> ()"},
+	{"__TFC4main3Foo3barfSiT_", "main.Foo.bar(Swift.Int) -> ()"},
+	{"__TFC4main3FoocfT_S0_", "main.Foo.init() -> main.Foo"},
+	{"__TFC4main3FooD", "main.Foo.__deallocating_deinit"},
+	{"__TToFC4main3Foo3barfT_T_", "@objc main.Foo.bar() -> ()"},
+	{"__TMaC4main3Foo", "type metadata accessor for main.Foo"},
+	{"__TtC4main3Foo", "main.Foo"},
+	{"__TtSi", "Swift.Int"},
+}
+
+func TestItanium(t *testing.T){
+	for _, c := range itaniumCases{
+		got, err := Symbol(c.mangled)
+		if nil != err || got != c.want{
+			t.Errorf("Symbol(%q) = %q, %v, want %q", c.mangled, got, err, c.want)
+		}
+	}
+}
+
+func TestSwift(t *testing.T){
+	for _, c := range swiftCases{
+		got, err := Symbol(c.mangled)
+		if nil != err || got != c.want{
+			t.Errorf("Symbol(%q) = %q, %v, want %q", c.mangled, got, err, c.want)
+		}
+	}
+}
+
+func TestSwiftType(t *testing.T){
+	cases := []struct{
+		mangled string
+		want string
+	}{
+		{"SaySSG", "[Swift.String]"},
+		{"SDySSSiG", "[Swift.String : Swift.Int]"},
+		{"SiSg", "Swift.Int?"},
+		{"Si_SStSg", "(Swift.Int, Swift.String)?"},
+	}
+	for _, c := range cases{
+		got, err := SwiftType(c.mangled, nil)
+		if nil != err || got != c.want{
+			t.Errorf("SwiftType(%q) = %q, %v, want %q", c.mangled, got, err, c.want)
+		}
+	}
+}
+
+//Plain C names come back unchanged, including "_T", which is how a C global named T appears.
+func TestNotMangled(t *testing.T){
+	for _, name := range []string{"_main", "_T", "__T", "_printf", "start", ""}{
+		if got := Demangle(name); got != name{
+			t.Errorf("Demangle(%q) = %q", name, got)
+		}
+	}
+	if _, err := Symbol("_main"); !errors.Is(err, ErrNotMangled){
+		t.Errorf("Symbol(\"_main\") error = %v, want ErrNotMangled", err)
+	}
+}
+
+//Every prefix of every name in the corpus is what a truncated or corrupt string table produces. None of
+//them may panic, and the ones that fail must come back unchanged from Demangle.
+func TestTruncated(t *testing.T){
+	var names []string
+	for _, c := range itaniumCases{
+		names = append(names, c.mangled)
+	}
+	for _, c := range swiftCases{
+		names = append(names, c.mangled)
+	}
+	for _, name := range names{
+		for i := 0; i < len(name); i++{
+			prefix := name[:i]
+			func(){
+				defer func(){
+					if r := recover(); nil != r{
+						t.Errorf("Demangle(%q) panicked: %v", prefix, r)
+					}
+				}()
+				if _, err := Symbol(prefix); nil != err && Demangle(prefix) != prefix{
+					t.Errorf("Demangle(%q) changed a name Symbol rejected", prefix)
+				}
+			}()
+		}
+	}
+}
@@ -0,0 +1,1188 @@
+package demangle
+
+import (
+	"fmt"
+	"strings"
+)
+
+//Itanium C++ ABI name mangling, see https://itanium-cxx-abi.github.io/cxx-abi/abi.html#mangling
+//The output follows llvm-cxxfilt: "foo::bar(char const*, int) const".
+
+var cxxBuiltinTypes = map[byte]string{
+	'v': "void",
+	'w': "wchar_t",
+	'b': "bool",
+	'c': "char",
+	'a': "signed char",
+	'h': "unsigned char",
+	's': "short",
+	't': "unsigned short",
+	'i': "int",
+	'j': "unsigned int",
+	'l': "long",
+	'm': "unsigned long",
+	'x': "long long",
+	'y': "unsigned long long",
+	'n': "__int128",
+	'o': "unsigned __int128",
+	'f': "float",
+	'd': "double",
+	'e': "long double",
+	'g': "__float128",
+	'z': "...",
+}
+
+//Builtin types with a D prefix
+var cxxExtendedTypes = map[byte]string{
+	'a': "auto",
+	'c': "decltype(auto)",
+	'd': "decimal64",
+	'e': "decimal128",
+	'f': "decimal32",
+	'h': "half",
+	'i': "char32_t",
+	'n': "std::nullptr_t",
+	's': "char16_t",
+	'u': "char8_t",
+}
+
+//Abbreviations for std:: types, Sa, Sb, Ss...
+var cxxStandardSubstitutions = map[byte]string{
+	'a': "std::allocator",
+	'b': "std::basic_string",
+	's': "std::string",
+	'i': "std::istream",
+	'o': "std::ostream",
+	'd': "std::iostream",
+}
+
+//Name used for constructors and destructors of the abbreviated std:: types
+var cxxStandardBaseNames = map[string]string{
+	"std::allocator":		"allocator",
+	"std::basic_string":	"basic_string",
+	"std::string":			"basic_string",
+	"std::istream":			"basic_istream",
+	"std::ostream":			"basic_ostream",
+	"std::iostream":		"basic_iostream",
+}
+
+var cxxOperators = map[string]string{
+	"nw": "operator new", "na": "operator new[]", "dl": "operator delete", "da": "operator delete[]",
+	"aw": "operator co_await", "ps": "operator+", "ng": "operator-", "ad": "operator&", "de": "operator*",
+	"co": "operator~", "pl": "operator+", "mi": "operator-", "ml": "operator*", "dv": "operator/",
+	"rm": "operator%", "an": "operator&", "or": "operator|", "eo": "operator^", "aS": "operator=",
+	"pL": "operator+=", "mI": "operator-=", "mL": "operator*=", "dV": "operator/=", "rM": "operator%=",
+	"aN": "operator&=", "oR": "operator|=", "eO": "operator^=", "ls": "operator<<", "rs": "operator>>",
+	"lS": "operator<<=", "rS": "operator>>=", "eq": "operator==", "ne": "operator!=", "lt": "operator<",
+	"gt": "operator>", "le": "operator<=", "ge": "operator>=", "ss": "operator<=>", "nt": "operator!",
+	"aa": "operator&&", "oo": "operator||", "pp": "operator++", "mm": "operator--", "cm": "operator,",
+	"pm": "operator->*", "pt": "operator->", "cl": "operator()", "ix": "operator[]", "qu": "operator?",
+}
+
+//Suffixes llvm-cxxfilt uses for integer literals in template arguments
+var cxxLiteralSuffixes = map[byte]string{
+	'i': "", 'j': "u", 'l': "l", 'm': "ul", 'x': "ll", 'y': "ull",
+}
+
+type cxxKind int
+
+const (
+	cxxName cxxKind = iota		//text
+	cxxNested					//children[0]::children[1]
+	cxxTemplate					//children[0]<children[1:]>
+	cxxQualified				//children[0] followed by the qualifiers in text
+	cxxPointer
+	cxxReference
+	cxxRValueReference
+	cxxFunctionType				//children[0] is the return type, the rest the parameters, text the qualifiers
+	cxxArray					//children[0] is the element type, text the dimension
+	cxxMemberPointer			//children[0] is the class, children[1] the member type
+	cxxEncoding					//children[0] is the name, children[1] the return type or nil, the rest the parameters
+	cxxSpecial					//text followed by children[0], "vtable for ..."
+	cxxPackExpansion
+	cxxArgumentPack
+	cxxLocal					//children[0]::children[1], an entity inside a function
+	cxxAbiTag
+	cxxConversion				//operator children[0]
+	cxxPostfix					//children[0] followed by text, " complex"
+)
+
+type cxxNode struct{
+	kind cxxKind
+	text string
+	children []*cxxNode
+}
+
+//Extra results from parsing the name of an encoding
+type cxxNameState struct{
+	qualifiers string				//cv and ref qualifiers of a member function
+	endsWithTemplateArgs bool		//template functions mangle their return type
+	ctorDtorConversion bool			//but constructors, destructors and conversion operators do not
+}
+
+type itaniumParser struct{
+	text string
+	pos int
+	substitutions []*cxxNode
+	templateArgs []*cxxNode			//of the entity being demangled, what T_ refers to
+	failed bool
+}
+
+/*
+	//////////////////////////////////////// PUBLIC METHODS ////////////////////////////////////////
+*/
+
+//Demangles an Itanium C++ ABI name such as "__ZN3foo3barEPKc" into "foo::bar(char const*)". Special names
+//(vtables, typeinfo, thunks, guard variables) and block invocations are included.
+func Itanium(name string)(string, error){
+	mangled := name
+	if strings.HasPrefix(mangled, "__Z") || strings.HasPrefix(mangled, "___Z"){
+		mangled = mangled[1:]
+	}
+
+	//clang names the blocks in a function "___Z<function>_block_invoke[_N]"
+	if strings.HasPrefix(mangled, "__Z"){
+		index := strings.Index(mangled, "_block_invoke")
+		if -1 == index{
+			return "", ErrInvalid
+		}
+		function, err := Itanium(mangled[1:index])
+		if nil != err{
+			return "", err
+		}
+		return "invocation function for block in " + function, nil
+	}
+	if !strings.HasPrefix(mangled, "_Z"){
+		return "", ErrNotMangled
+	}
+
+	p := itaniumParser{text: mangled[2:]}
+	node := p.encoding()
+	suffix := ""
+	if !p.failed && p.pos < len(p.text) && '.' == p.text[p.pos]{
+		//clone suffixes such as ".cold.1" or ".constprop.0"
+		suffix = " (" + p.text[p.pos:] + ")"
+		p.pos = len(p.text)
+	}
+	if p.failed || nil == node || p.pos != len(p.text){
+		return "", fmt.Errorf("%w: %s", ErrInvalid, name)
+	}
+	return node.String() + suffix, nil
+}
+
+func (n *cxxNode) String()string{
+	var out []byte
+	out = n.printLeft(out)
+	return string(n.printRight(out))
+}
+
+/*
+	//////////////////////////////////////// PRIVATE METHODS ////////////////////////////////////////
+*/
+
+func (p *itaniumParser) fail()*cxxNode{
+	p.failed = true
+	return nil
+}
+
+func (p *itaniumParser) peek()byte{
+	if p.pos < len(p.text){
+		return p.text[p.pos]
+	}
+	return 0
+}
+
+func (p *itaniumParser) peekAt(offset int)byte{
+	if p.pos + offset < len(p.text){
+		return p.text[p.pos + offset]
+	}
+	return 0
+}
+
+func (p *itaniumParser) consume(prefix string)bool{
+	if strings.HasPrefix(p.text[p.pos:], prefix){
+		p.pos += len(prefix)
+		return true
+	}
+	return false
+}
+
+func (p *itaniumParser) atEnd()bool{
+	return p.failed || p.pos >= len(p.text)
+}
+
+//Reads a decimal number, with an n prefix for negative ones. Returns the digits as written.
+func (p *itaniumParser) number()string{
+	start := p.pos
+	p.consume("n")
+	digits := p.pos
+	for p.pos < len(p.text) && isDigit(p.text[p.pos]){
+		p.pos++
+	}
+	if digits == p.pos{
+		p.fail()
+		return ""
+	}
+	if 'n' == p.text[start]{
+		return "-" + p.text[digits:p.pos]
+	}
+	return p.text[start:p.pos]
+}
+
+func (p *itaniumParser) length()int{
+	n := 0
+	start := p.pos
+	for p.pos < len(p.text) && isDigit(p.text[p.pos]){
+		n = n*10 + int(p.text[p.pos] - '0')
+		if n > len(p.text){
+			break
+		}
+		p.pos++
+	}
+	if start == p.pos{
+		p.fail()
+	}
+	return n
+}
+
+func (p *itaniumParser) expect(c byte){
+	if p.peek() != c{
+		p.fail()
+		return
+	}
+	p.pos++
+}
+
+func (p *itaniumParser) addSubstitution(n *cxxNode){
+	if nil != n{
+		p.substitutions = append(p.substitutions, n)
+	}
+}
+
+//<encoding> ::= <name> <bare-function-type> | <name> | <special-name>
+func (p *itaniumParser) encoding()*cxxNode{
+	if 'T' == p.peek() || 'G' == p.peek(){
+		return p.specialName()
+	}
+
+	state := cxxNameState{}
+	name := p.name(&state)
+	if p.atEnd() || 'E' == p.peek() || '.' == p.peek(){
+		return name
+	}
+
+	var returnType *cxxNode
+	if state.endsWithTemplateArgs && !state.ctorDtorConversion{
+		returnType = p.typ()
+	}
+	children := []*cxxNode{name, returnType}
+	children = append(children, p.parameters()...)
+	if p.failed{
+		return nil
+	}
+	return &cxxNode{kind: cxxEncoding, text: state.qualifiers, children: children}
+}
+
+//Parameter types up to the end of the encoding, a lone v means there are none.
+func (p *itaniumParser) parameters()[]*cxxNode{
+	var parameters []*cxxNode
+	if 'v' == p.peek() && (p.pos + 1 == len(p.text) || 'E' == p.peekAt(1) || '.' == p.peekAt(1)){
+		p.pos++
+		return nil
+	}
+	for !p.atEnd() && 'E' != p.peek() && '.' != p.peek(){
+		parameters = append(parameters, p.typ())
+	}
+	return parameters
+}
+
+func (p *itaniumParser) specialName()*cxxNode{
+	special := func(text string, child *cxxNode)*cxxNode{
+		if nil == child{
+			return p.fail()
+		}
+		return &cxxNode{kind: cxxSpecial, text: text, children: []*cxxNode{child}}
+	}
+
+	switch{
+	case p.consume("TV"):
+		return special("vtable for ", p.typ())
+	case p.consume("TT"):
+		return special("VTT for ", p.typ())
+	case p.consume("TI"):
+		return special("typeinfo for ", p.typ())
+	case p.consume("TS"):
+		return special("typeinfo name for ", p.typ())
+	case p.consume("Th"):
+		p.number()
+		p.expect('_')
+		return special("non-virtual thunk to ", p.encoding())
+	case p.consume("Tv"):
+		p.callOffset('v')
+		return special("virtual thunk to ", p.encoding())
+	case p.consume("Tc"):
+		p.callOffset(p.peek())
+		p.callOffset(p.peek())
+		return special("covariant return thunk to ", p.encoding())
+	case p.consume("TC"):
+		derived := p.typ()
+		p.number()
+		p.expect('_')
+		base := p.typ()
+		if nil == derived || nil == base{
+			return p.fail()
+		}
+		return &cxxNode{kind: cxxName, text: "construction vtable for " + base.String() + "-in-" + derived.String()}
+	case p.consume("TW"):
+		return special("thread-local wrapper routine for ", p.name(nil))
+	case p.consume("TH"):
+		return special("thread-local initialization routine for ", p.name(nil))
+	case p.consume("GV"):
+		return special("guard variable for ", p.name(nil))
+	case p.consume("GR"):
+		node := special("reference temporary for ", p.name(nil))
+		if !p.consume("_"){
+			for isDigit(p.peek()) || isUpper(p.peek()){
+				p.pos++
+			}
+			p.consume("_")
+		}
+		return node
+	case p.consume("GTt"):
+		return special("transaction clone for ", p.encoding())
+	}
+	return p.fail()
+}
+
+//<call-offset> ::= h <nv-offset> _ | v <v-offset> _ <virtual offset> _
+func (p *itaniumParser) callOffset(kind byte){
+	switch{
+	case 'h' == kind && p.consume("h"):
+		p.number()
+		p.expect('_')
+	case 'v' == kind && p.consume("v"):
+		p.number()
+		p.expect('_')
+		p.number()
+		p.expect('_')
+	default:
+		p.fail()
+	}
+}
+
+//<name> ::= <nested-name> | <local-name> | <unscoped-template-name> <template-args> | <unscoped-name>
+func (p *itaniumParser) name(state *cxxNameState)*cxxNode{
+	switch{
+	case p.consume("N"):
+		return p.nestedName(state)
+	case p.consume("Z"):
+		return p.localName(state)
+	}
+
+	var name *cxxNode
+	substituted := false
+	switch{
+	case p.consume("St"):
+		name = p.nested(&cxxNode{kind: cxxName, text: "std"}, p.unqualifiedName(state))
+	case 'S' == p.peek():
+		//only a substituted template can stand here
+		name = p.substitution()
+		substituted = true
+		if 'I' != p.peek(){
+			return p.fail()
+		}
+	default:
+		name = p.unqualifiedName(state)
+	}
+
+	if 'I' == p.peek(){
+		if !substituted{
+			p.addSubstitution(name)
+		}
+		arguments := p.templateArguments(nil != state)
+		if nil != state{
+			state.endsWithTemplateArgs = true
+		}
+		name = p.template(name, arguments)
+	}
+	return name
+}
+
+//<nested-name> ::= N [<CV-qualifiers>] [<ref-qualifier>] <prefix> <unqualified-name> E
+func (p *itaniumParser) nestedName(state *cxxNameState)*cxxNode{
+	qualifiers := p.cvQualifiers()
+	switch{
+	case p.consume("O"):
+		qualifiers += " &&"
+	case p.consume("R"):
+		qualifiers += " &"
+	}
+	if nil != state{
+		state.qualifiers = qualifiers
+	}
+
+	var soFar *cxxNode
+	push := func(component *cxxNode){
+		if nil == component{
+			p.fail()
+			return
+		}
+		soFar = p.nested(soFar, component)
+		if nil != state{
+			state.endsWithTemplateArgs = false
+		}
+	}
+
+	if p.consume("St"){
+		soFar = &cxxNode{kind: cxxName, text: "std"}
+	}
+	for !p.atEnd() && !p.consume("E"){
+		p.consume("L")
+		switch{
+		case p.consume("M"):
+			//lambdas in data member initializers
+			if nil == soFar{
+				return p.fail()
+			}
+			continue
+		case 'T' == p.peek():
+			push(p.templateParameter())
+			p.addSubstitution(soFar)
+		case 'I' == p.peek():
+			if nil == soFar{
+				return p.fail()
+			}
+			soFar = p.template(soFar, p.templateArguments(nil != state))
+			if nil != state{
+				state.endsWithTemplateArgs = true
+			}
+			p.addSubstitution(soFar)
+		case 'D' == p.peek() && ('t' == p.peekAt(1) || 'T' == p.peekAt(1)):
+			//decltype
+			return p.fail()
+		case 'S' == p.peek() && 't' != p.peekAt(1):
+			substitution := p.substitution()
+			push(substitution)
+			if soFar != substitution{
+				p.addSubstitution(soFar)
+			}
+		case 'C' == p.peek() || ('D' == p.peek() && 'C' != p.peekAt(1)):
+			if nil == soFar{
+				return p.fail()
+			}
+			push(p.ctorDtorName(soFar, state))
+			soFar = p.abiTags(soFar)
+			p.addSubstitution(soFar)
+		default:
+			push(p.unqualifiedName(state))
+			p.addSubstitution(soFar)
+		}
+	}
+	if p.failed || nil == soFar || 0 == len(p.substitutions){
+		return p.fail()
+	}
+	//the complete name is not a prefix of anything
+	p.substitutions = p.substitutions[:len(p.substitutions)-1]
+	return soFar
+}
+
+//<local-name> ::= Z <encoding> E <entity name> [<discriminator>] | Z <encoding> E s [<discriminator>]
+func (p *itaniumParser) localName(state *cxxNameState)*cxxNode{
+	function := p.encoding()
+	p.expect('E')
+	if p.failed{
+		return nil
+	}
+
+	var entity *cxxNode
+	switch{
+	case p.consume("s"):
+		entity = &cxxNode{kind: cxxName, text: "string literal"}
+	case p.consume("d"):
+		//default argument
+		if '_' != p.peek(){
+			p.number()
+		}
+		p.expect('_')
+		entity = p.name(state)
+	default:
+		entity = p.name(state)
+	}
+	p.discriminator()
+	if p.failed{
+		return nil
+	}
+	return &cxxNode{kind: cxxLocal, children: []*cxxNode{function, entity}}
+}
+
+func (p *itaniumParser) discriminator(){
+	if !p.consume("_"){
+		return
+	}
+	if p.consume("_"){
+		p.number()
+		p.expect('_')
+		return
+	}
+	if !isDigit(p.peek()){
+		p.fail()
+		return
+	}
+	p.pos++
+}
+
+//<unqualified-name> ::= <operator-name> | <source-name> | <unnamed-type-name>, followed by any ABI tags
+func (p *itaniumParser) unqualifiedName(state *cxxNameState)*cxxNode{
+	p.consume("L")
+	var name *cxxNode
+	switch c := p.peek(); {
+	case isDigit(c):
+		name = p.sourceName()
+	case p.consume("Ut"):
+		number := ""
+		if '_' != p.peek(){
+			number = p.number()
+		}
+		p.expect('_')
+		name = &cxxNode{kind: cxxName, text: "'unnamed" + number + "'"}
+	case p.consume("Ul"):
+		var parameters []string
+		if p.consume("vE"){
+			p.pos--
+		}
+		for !p.atEnd() && !p.consume("E"){
+			if parameter := p.typ(); nil != parameter{
+				parameters = append(parameters, parameter.String())
+			}
+		}
+		number := ""
+		if '_' != p.peek(){
+			number = p.number()
+		}
+		p.expect('_')
+		name = &cxxNode{kind: cxxName, text: "'lambda" + number + "'(" + strings.Join(parameters, ", ") + ")"}
+	case isLower(c):
+		name = p.operatorName(state)
+	default:
+		return p.fail()
+	}
+	return p.abiTags(name)
+}
+
+//B <source-name>, as in std::__cxx11::basic_string[abi:cxx11]
+func (p *itaniumParser) abiTags(name *cxxNode)*cxxNode{
+	for nil != name && p.consume("B"){
+		tag := p.sourceName()
+		if nil == tag{
+			return nil
+		}
+		name = &cxxNode{kind: cxxAbiTag, text: tag.text, children: []*cxxNode{name}}
+	}
+	return name
+}
+
+//<source-name> ::= <positive length number> <identifier>
+func (p *itaniumParser) sourceName()*cxxNode{
+	length := p.length()
+	if p.failed || 0 == length || p.pos + length > len(p.text){
+		return p.fail()
+	}
+	text := p.text[p.pos : p.pos+length]
+	p.pos += length
+	if strings.HasPrefix(text, "_GLOBAL__N"){
+		text = "(anonymous namespace)"
+	}
+	return &cxxNode{kind: cxxName, text: text}
+}
+
+func (p *itaniumParser) operatorName(state *cxxNameState)*cxxNode{
+	switch{
+	case p.consume("cv"):
+		if nil != state{
+			state.ctorDtorConversion = true
+		}
+		target := p.typ()
+		if nil == target{
+			return nil
+		}
+		return &cxxNode{kind: cxxConversion, children: []*cxxNode{target}}
+	case p.consume("li"):
+		suffix := p.sourceName()
+		if nil == suffix{
+			return nil
+		}
+		return &cxxNode{kind: cxxName, text: `operator"" ` + suffix.text}
+	case 'v' == p.peek() && isDigit(p.peekAt(1)):
+		p.pos += 2
+		vendor := p.sourceName()
+		if nil == vendor{
+			return nil
+		}
+		return &cxxNode{kind: cxxName, text: "operator " + vendor.text}
+	}
+	if p.pos + 2 > len(p.text){
+		return p.fail()
+	}
+	operator, ok := cxxOperators[p.text[p.pos : p.pos+2]]
+	if !ok{
+		return p.fail()
+	}
+	p.pos += 2
+	return &cxxNode{kind: cxxName, text: operator}
+}
+
+//<ctor-dtor-name> ::= C1 | C2 | C3 | CI1 <type> | CI2 <type> | D0 | D1 | D2
+func (p *itaniumParser) ctorDtorName(soFar *cxxNode, state *cxxNameState)*cxxNode{
+	if nil != state{
+		state.ctorDtorConversion = true
+	}
+	name := cxxBaseName(soFar)
+	if p.consume("C"){
+		inheriting := p.consume("I")
+		if c := p.peek(); c < '1' || c > '5'{
+			return p.fail()
+		}
+		p.pos++
+		if inheriting{
+			p.typ()
+		}
+		return &cxxNode{kind: cxxName, text: name}
+	}
+	p.expect('D')
+	if c := p.peek(); c < '0' || c > '5'{
+		return p.fail()
+	}
+	p.pos++
+	return &cxxNode{kind: cxxName, text: "~" + name}
+}
+
+//<substitution> ::= S_ | S <seq-id> _ | St | Sa | Sb | Ss | Si | So | Sd
+func (p *itaniumParser) substitution()*cxxNode{
+	p.expect('S')
+	if p.failed{
+		return nil
+	}
+	if standard, ok := cxxStandardSubstitutions[p.peek()]; ok{
+		p.pos++
+		return &cxxNode{kind: cxxName, text: standard}
+	}
+
+	index := 0
+	if !p.consume("_"){
+		//base 36 with upper case letters
+		id := 0
+		start := p.pos
+		for isDigit(p.peek()) || isUpper(p.peek()){
+			c := p.peek()
+			if isDigit(c){
+				id = id*36 + int(c - '0')
+			} else {
+				id = id*36 + int(c - 'A') + 10
+			}
+			if id > len(p.text){
+				return p.fail()
+			}
+			p.pos++
+		}
+		if start == p.pos{
+			return p.fail()
+		}
+		p.expect('_')
+		index = id + 1
+	}
+	if p.failed || index >= len(p.substitutions){
+		return p.fail()
+	}
+	return p.substitutions[index]
+}
+
+//<template-param> ::= T_ | T <number> _
+func (p *itaniumParser) templateParameter()*cxxNode{
+	p.expect('T')
+	index := 0
+	if !p.consume("_"){
+		index = p.length() + 1
+		p.expect('_')
+	}
+	if p.failed{
+		return nil
+	}
+	if index < len(p.templateArgs){
+		return p.templateArgs[index]
+	}
+	//forward reference, from a conversion operator to a template type
+	return &cxxNode{kind: cxxName, text: fmt.Sprintf("T%d", index)}
+}
+
+//<template-args> ::= I <template-arg>+ E
+//The arguments of the entity's own name are what template parameters refer to.
+func (p *itaniumParser) templateArguments(record bool)[]*cxxNode{
+	p.expect('I')
+	var arguments []*cxxNode
+	for !p.atEnd() && !p.consume("E"){
+		arguments = append(arguments, p.templateArgument())
+	}
+	if record{
+		p.templateArgs = arguments
+	}
+	return arguments
+}
+
+func (p *itaniumParser) templateArgument()*cxxNode{
+	switch p.peek(){
+	case 'X':
+		p.pos++
+		expression := p.expression()
+		p.expect('E')
+		return expression
+	case 'J':
+		p.pos++
+		pack := &cxxNode{kind: cxxArgumentPack}
+		for !p.atEnd() && !p.consume("E"){
+			pack.children = append(pack.children, p.templateArgument())
+		}
+		return pack
+	case 'L':
+		return p.literal()
+	}
+	return p.typ()
+}
+
+//Only template parameters and literals, which is what shows up in function names in practice.
+func (p *itaniumParser) expression()*cxxNode{
+	switch p.peek(){
+	case 'T':
+		return p.templateParameter()
+	case 'L':
+		return p.literal()
+	}
+	return p.fail()
+}
+
+//<expr-primary> ::= L <type> <value number> E | L _Z <encoding> E
+func (p *itaniumParser) literal()*cxxNode{
+	p.expect('L')
+	if p.consume("_Z") || p.consume("Z"){
+		encoding := p.encoding()
+		p.expect('E')
+		return encoding
+	}
+	if p.consume("DnE"){
+		return &cxxNode{kind: cxxName, text: "nullptr"}
+	}
+
+	c := p.peek()
+	if 'b' == c && ('0' == p.peekAt(1) || '1' == p.peekAt(1)) && 'E' == p.peekAt(2){
+		p.pos += 3
+		if '1' == p.text[p.pos-2]{
+			return &cxxNode{kind: cxxName, text: "true"}
+		}
+		return &cxxNode{kind: cxxName, text: "false"}
+	}
+	if suffix, ok := cxxLiteralSuffixes[c]; ok{
+		p.pos++
+		value := p.number()
+		p.expect('E')
+		return &cxxNode{kind: cxxName, text: value + suffix}
+	}
+
+	literalType := p.typ()
+	if nil == literalType{
+		return nil
+	}
+	start := p.pos
+	for !p.atEnd() && 'E' != p.peek(){
+		p.pos++
+	}
+	value := strings.Replace(p.text[start:p.pos], "n", "-", 1)
+	p.expect('E')
+	return &cxxNode{kind: cxxName, text: "(" + literalType.String() + ")" + value}
+}
+
+//<CV-qualifiers> ::= [r] [V] [K]
+func (p *itaniumParser) cvQualifiers()string{
+	restrict := p.consume("r")
+	volatile := p.consume("V")
+	constant := p.consume("K")
+	qualifiers := ""
+	if constant{
+		qualifiers += " const"
+	}
+	if volatile{
+		qualifiers += " volatile"
+	}
+	if restrict{
+		qualifiers += " restrict"
+	}
+	return qualifiers
+}
+
+func (p *itaniumParser) typ()*cxxNode{
+	if p.atEnd(){
+		return p.fail()
+	}
+	c := p.peek()
+	if builtin, ok := cxxBuiltinTypes[c]; ok{
+		p.pos++
+		return &cxxNode{kind: cxxName, text: builtin}
+	}
+
+	var node *cxxNode
+	switch c{
+	case 'r', 'V', 'K':
+		qualifiers := p.cvQualifiers()
+		inner := p.typ()
+		if nil == inner{
+			return nil
+		}
+		if cxxFunctionType == inner.kind{
+			node = &cxxNode{kind: cxxFunctionType, text: qualifiers + inner.text, children: inner.children}
+		} else {
+			node = &cxxNode{kind: cxxQualified, text: qualifiers, children: []*cxxNode{inner}}
+		}
+	case 'P', 'R', 'O':
+		p.pos++
+		kind := map[byte]cxxKind{'P': cxxPointer, 'R': cxxReference, 'O': cxxRValueReference}[c]
+		node = p.wrap(kind, "", p.typ())
+	case 'C':
+		p.pos++
+		node = p.wrap(cxxPostfix, " complex", p.typ())
+	case 'G':
+		p.pos++
+		node = p.wrap(cxxPostfix, " imaginary", p.typ())
+	case 'F':
+		node = p.functionType()
+	case 'A':
+		node = p.arrayType()
+	case 'M':
+		p.pos++
+		class := p.typ()
+		member := p.typ()
+		if nil == class || nil == member{
+			return nil
+		}
+		node = &cxxNode{kind: cxxMemberPointer, children: []*cxxNode{class, member}}
+	case 'T':
+		node = p.templateParameter()
+		if 'I' == p.peek(){
+			p.addSubstitution(node)
+			node = p.template(node, p.templateArguments(false))
+		}
+	case 'S':
+		if 't' == p.peekAt(1){
+			node = p.name(nil)
+			break
+		}
+		substitution := p.substitution()
+		if 'I' != p.peek(){
+			//already a substitution candidate
+			return substitution
+		}
+		node = p.template(substitution, p.templateArguments(false))
+	case 'D':
+		switch c := p.peekAt(1); {
+		case 'p' == c:
+			p.pos += 2
+			node = p.wrap(cxxPackExpansion, "", p.typ())
+		case 'v' == c:
+			p.pos += 2
+			count := p.number()
+			p.expect('_')
+			node = p.wrap(cxxPostfix, " vector[" + count + "]", p.typ())
+		case 'o' == c:
+			p.pos += 2
+			inner := p.typ()
+			if nil == inner || cxxFunctionType != inner.kind{
+				return p.fail()
+			}
+			node = &cxxNode{kind: cxxFunctionType, text: inner.text + " noexcept", children: inner.children}
+		case "" != cxxExtendedTypes[c]:
+			p.pos += 2
+			return &cxxNode{kind: cxxName, text: cxxExtendedTypes[c]}
+		default:
+			return p.fail()
+		}
+	case 'u':
+		p.pos++
+		node = p.sourceName()
+	case 'N', 'Z', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		node = p.name(nil)
+	default:
+		return p.fail()
+	}
+	p.addSubstitution(node)
+	return node
+}
+
+//<function-type> ::= F [Y] <bare-function-type> [<ref-qualifier>] E
+func (p *itaniumParser) functionType()*cxxNode{
+	p.expect('F')
+	p.consume("Y")
+	returnType := p.typ()
+	node := &cxxNode{kind: cxxFunctionType, children: []*cxxNode{returnType}}
+	for !p.atEnd() && !p.consume("E"){
+		switch{
+		case p.consume("vE"):
+			p.pos--
+		case p.consume("RE"):
+			node.text += " &"
+			p.pos--
+		case p.consume("OE"):
+			node.text += " &&"
+			p.pos--
+		default:
+			node.children = append(node.children, p.typ())
+		}
+	}
+	if p.failed{
+		return nil
+	}
+	return node
+}
+
+//<array-type> ::= A <positive dimension number> _ <element type> | A _ <element type>
+func (p *itaniumParser) arrayType()*cxxNode{
+	p.expect('A')
+	dimension := ""
+	if isDigit(p.peek()){
+		dimension = p.number()
+	}
+	p.expect('_')
+	return p.wrap(cxxArray, dimension, p.typ())
+}
+
+func (p *itaniumParser) wrap(kind cxxKind, text string, child *cxxNode)*cxxNode{
+	if nil == child{
+		return p.fail()
+	}
+	return &cxxNode{kind: kind, text: text, children: []*cxxNode{child}}
+}
+
+func (p *itaniumParser) nested(prefix *cxxNode, name *cxxNode)*cxxNode{
+	if nil == name{
+		return p.fail()
+	}
+	if nil == prefix{
+		return name
+	}
+	return &cxxNode{kind: cxxNested, children: []*cxxNode{prefix, name}}
+}
+
+func (p *itaniumParser) template(name *cxxNode, arguments []*cxxNode)*cxxNode{
+	if nil == name || p.failed{
+		return p.fail()
+	}
+	return &cxxNode{kind: cxxTemplate, children: append([]*cxxNode{name}, arguments...)}
+}
+
+//The unqualified name of the class a constructor or destructor belongs to.
+func cxxBaseName(n *cxxNode)string{
+	for{
+		switch n.kind{
+		case cxxNested:
+			n = n.children[1]
+		case cxxTemplate, cxxAbiTag:
+			n = n.children[0]
+		default:
+			if base, ok := cxxStandardBaseNames[n.text]; ok{
+				return base
+			}
+			return n.String()
+		}
+	}
+}
+
+/*
+	Printing is split in a left and a right part so declarators nest inside out: a pointer to a function
+	returning int prints "int (*" on the left and ")(char)" on the right.
+*/
+
+//True for types which print something after the name they declare.
+func (n *cxxNode) hasRight()bool{
+	switch n.kind{
+	case cxxFunctionType, cxxArray:
+		return true
+	case cxxPointer, cxxReference, cxxRValueReference, cxxQualified:
+		return n.children[0].hasRight()
+	case cxxMemberPointer:
+		return n.children[1].hasRight()
+	}
+	return false
+}
+
+func (n *cxxNode) printLeft(out []byte)[]byte{
+	switch n.kind{
+	case cxxName:
+		return append(out, n.text...)
+	case cxxNested, cxxLocal:
+		return append(out, (n.children[0].String() + "::" + n.children[1].String())...)
+	case cxxTemplate:
+		out = append(out, n.children[0].String()...)
+		if '<' == out[len(out)-1]{
+			out = append(out, ' ')
+		}
+		out = append(out, '<')
+		out = append(out, joinCxx(n.children[1:])...)
+		return append(out, '>')
+	case cxxArgumentPack:
+		return append(out, joinCxx(n.children)...)
+	case cxxQualified:
+		out = n.children[0].printLeft(out)
+		return append(out, n.text...)
+	case cxxPostfix:
+		out = append(out, n.children[0].String()...)
+		return append(out, n.text...)
+	case cxxPointer, cxxReference, cxxRValueReference:
+		pointee := n.children[0]
+		out = pointee.printLeft(out)
+		if cxxArray == pointee.kind{
+			out = append(out, " ("...)
+		} else if pointee.hasRight(){
+			out = append(out, '(')
+		}
+		return append(out, map[cxxKind]string{cxxPointer: "*", cxxReference: "&", cxxRValueReference: "&&"}[n.kind]...)
+	case cxxFunctionType:
+		if nil != n.children[0]{
+			out = n.children[0].printLeft(out)
+		}
+		return append(out, ' ')
+	case cxxArray:
+		return n.children[0].printLeft(out)
+	case cxxMemberPointer:
+		member := n.children[1]
+		out = member.printLeft(out)
+		if member.hasRight(){
+			out = append(out, '(')
+		} else {
+			out = append(out, ' ')
+		}
+		return append(out, (n.children[0].String() + "::*")...)
+	case cxxEncoding:
+		if returnType := n.children[1]; nil != returnType{
+			out = returnType.printLeft(out)
+			if !returnType.hasRight(){
+				out = append(out, ' ')
+			}
+		}
+		return append(out, n.children[0].String()...)
+	case cxxSpecial:
+		return append(out, (n.text + n.children[0].String())...)
+	case cxxPackExpansion:
+		pack := n.children[0].findPack()
+		if nil == pack{
+			return append(out, (n.children[0].String() + "...")...)
+		}
+		//a resolved pack expands to one copy of the pattern per element
+		expanded := make([]*cxxNode, len(pack.children))
+		for i, element := range pack.children{
+			expanded[i] = n.children[0].replace(pack, element)
+		}
+		return append(out, joinCxx(expanded)...)
+	case cxxAbiTag:
+		return append(out, (n.children[0].String() + "[abi:" + n.text + "]")...)
+	case cxxConversion:
+		return append(out, ("operator " + n.children[0].String())...)
+	}
+	return out
+}
+
+func (n *cxxNode) printRight(out []byte)[]byte{
+	switch n.kind{
+	case cxxQualified:
+		return n.children[0].printRight(out)
+	case cxxPointer, cxxReference, cxxRValueReference:
+		pointee := n.children[0]
+		if pointee.hasRight(){
+			out = append(out, ')')
+		}
+		return pointee.printRight(out)
+	case cxxFunctionType:
+		out = append(out, '(')
+		out = append(out, joinCxx(n.children[1:])...)
+		out = append(out, ')')
+		out = append(out, n.text...)
+		if nil != n.children[0]{
+			out = n.children[0].printRight(out)
+		}
+		return out
+	case cxxArray:
+		if 0 == len(out) || ']' != out[len(out)-1]{
+			out = append(out, ' ')
+		}
+		out = append(out, ("[" + n.text + "]")...)
+		return n.children[0].printRight(out)
+	case cxxMemberPointer:
+		member := n.children[1]
+		if member.hasRight(){
+			out = append(out, ')')
+		}
+		return member.printRight(out)
+	case cxxEncoding:
+		out = append(out, '(')
+		out = append(out, joinCxx(n.children[2:])...)
+		out = append(out, ')')
+		if returnType := n.children[1]; nil != returnType{
+			out = returnType.printRight(out)
+		}
+		return append(out, n.text...)
+	}
+	return out
+}
+
+//The first argument pack in the pattern of a pack expansion
+func (n *cxxNode) findPack()*cxxNode{
+	if nil == n || cxxPackExpansion == n.kind{
+		return nil
+	}
+	if cxxArgumentPack == n.kind{
+		return n
+	}
+	for _, child := range n.children{
+		if pack := child.findPack(); nil != pack{
+			return pack
+		}
+	}
+	return nil
+}
+
+//Copies n with every occurrence of from replaced by to.
+func (n *cxxNode) replace(from *cxxNode, to *cxxNode)*cxxNode{
+	if n == from{
+		return to
+	}
+	if nil == n || 0 == len(n.children){
+		return n
+	}
+	copied := *n
+	copied.children = make([]*cxxNode, len(n.children))
+	for i, child := range n.children{
+		copied.children[i] = child.replace(from, to)
+	}
+	return &copied
+}
+
+func joinCxx(nodes []*cxxNode)string{
+	parts := make([]string, 0, len(nodes))
+	for _, node := range nodes{
+		if nil == node{
+			continue
+		}
+		//empty packs print nothing
+		if part := node.String(); "" != part{
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func isDigit(c byte)bool{
+	return c >= '0' && c <= '9'
+}
+
+func isUpper(c byte)bool{
+	return c >= 'A' && c <= 'Z'
+}
+
+func isLower(c byte)bool{
+	return c >= 'a' && c <= 'z'
+}
@@ -0,0 +1,1503 @@
+package demangle
+
+import (
+	"fmt"
+	"strings"
+)
+
+//Swift name mangling as used since Swift 4, see docs/ABI/Mangling.rst in the Swift repository. The
+//mangling is postfix: operands are pushed on a stack and an operator character pops them, so this follows
+//the structure of the reference demangler (lib/Demangling/Demangler.cpp) closely.
+
+//Limit on the word substitutions in identifiers, one per letter
+const SWIFT_MAX_WORDS = 26
+
+//Resolves a symbolic reference in a mangled name from reflection metadata. offset is the position of the
+//control byte (0x01-0x17 for a 4 byte relative reference, 0x18-0x1f for an 8 byte absolute one); the
+//result is the name of the referenced type, or "" if it cannot be resolved.
+type SymbolicResolver func(offset int)string
+
+type swiftDemangler struct{
+	text string
+	pos int
+	stack []*swiftNode
+	substitutions []*swiftNode
+	words []string
+	oldFunctionTypes bool			//_T0 names keep argument labels in the parameter tuple
+	resolve SymbolicResolver
+}
+
+/*
+	//////////////////////////////////////// PUBLIC METHODS ////////////////////////////////////////
+*/
+
+//Demangles a Swift symbol: "_$s4main3FooV3bar1xySi_tF" is "main.Foo.bar(x: Swift.Int) -> ()". Names from
+//before Swift 4 ("__TFC4main3Foo3barfT_T_") and the Objective-C runtime names of Swift classes
+//("_TtC4main3Foo") are handled too.
+func Swift(name string)(string, error){
+	mangled := trimSwiftUnderscore(name)
+	var node *swiftNode
+	switch{
+	case strings.HasPrefix(mangled, "$s") || strings.HasPrefix(mangled, "$S") || strings.HasPrefix(mangled, "$e"):
+		node = demangleSwift(mangled[2:], false, nil)
+	case strings.HasPrefix(mangled, "_T0"):
+		node = demangleSwift(mangled[3:], true, nil)
+	case strings.HasPrefix(mangled, "_T"):
+		node = demangleOldSwift(mangled[2:])
+	default:
+		return "", ErrNotMangled
+	}
+	if nil == node{
+		return "", fmt.Errorf("%w: %s", ErrInvalid, name)
+	}
+	return swiftPrinter{}.print(node), nil
+}
+
+//Demangles a bare type mangling as found in Swift reflection metadata, such as "SaySSG", which prints as
+//"[Swift.String]". Symbolic references are resolved through resolve, which may be nil if there are none.
+func SwiftType(mangled string, resolve SymbolicResolver)(string, error){
+	node := demangleSwift(mangled, false, resolve)
+	if nil == node{
+		return "", fmt.Errorf("%w: %q", ErrInvalid, mangled)
+	}
+	return swiftPrinter{sugar: true}.print(node), nil
+}
+
+/*
+	//////////////////////////////////////// PRIVATE METHODS ////////////////////////////////////////
+*/
+
+//Runs the operators in text and returns the Global node, or nil if the text is not understood.
+func demangleSwift(text string, oldFunctionTypes bool, resolve SymbolicResolver)*swiftNode{
+	d := &swiftDemangler{text: text, oldFunctionTypes: oldFunctionTypes, resolve: resolve}
+	for d.pos < len(d.text){
+		node := d.operator()
+		if nil == node{
+			return nil
+		}
+		d.push(node)
+	}
+
+	global := &swiftNode{kind: swiftGlobal}
+	for attribute := d.pop(swiftAttribute); nil != attribute; attribute = d.pop(swiftAttribute){
+		global.children = append(global.children, attribute)
+	}
+	//anything besides one entity and its suffix means part of the name was not understood
+	entities := 0
+	for _, node := range d.stack{
+		if isSwiftFragment(node.kind){
+			return nil
+		}
+		if swiftSuffix != node.kind{
+			entities++
+		}
+		global.children = append(global.children, node)
+	}
+	if 1 != entities{
+		return nil
+	}
+	return global
+}
+
+func (d *swiftDemangler) next()byte{
+	if d.pos >= len(d.text){
+		return 0
+	}
+	d.pos++
+	return d.text[d.pos-1]
+}
+
+func (d *swiftDemangler) peek()byte{
+	if d.pos >= len(d.text){
+		return 0
+	}
+	return d.text[d.pos]
+}
+
+func (d *swiftDemangler) nextIf(c byte)bool{
+	if d.peek() != c || 0 == c{
+		return false
+	}
+	d.pos++
+	return true
+}
+
+func (d *swiftDemangler) push(node *swiftNode){
+	d.stack = append(d.stack, node)
+}
+
+//Pops the top of the stack if it is one of kinds.
+func (d *swiftDemangler) pop(kinds ...swiftKind)*swiftNode{
+	return d.popIf(func(kind swiftKind)bool{
+		for _, k := range kinds{
+			if k == kind{
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (d *swiftDemangler) popIf(match func(swiftKind)bool)*swiftNode{
+	if 0 == len(d.stack){
+		return nil
+	}
+	top := d.stack[len(d.stack)-1]
+	if !match(top.kind){
+		return nil
+	}
+	d.stack = d.stack[:len(d.stack)-1]
+	return top
+}
+
+func (d *swiftDemangler) addSubstitution(node *swiftNode){
+	if nil != node{
+		d.substitutions = append(d.substitutions, node)
+	}
+}
+
+//<natural> ::= [0-9]+, -1 if there are no digits
+func (d *swiftDemangler) natural()int{
+	if !isDigit(d.peek()){
+		return -1
+	}
+	n := 0
+	for isDigit(d.peek()){
+		n = n*10 + int(d.next() - '0')
+		if n > len(d.text){
+			return -1
+		}
+	}
+	return n
+}
+
+//<index> ::= _ | <natural> _ with _ being 0, -1 if malformed
+func (d *swiftDemangler) index()int{
+	if d.nextIf('_'){
+		return 0
+	}
+	if n := d.natural(); n >= 0 && d.nextIf('_'){
+		return n + 1
+	}
+	return -1
+}
+
+func (d *swiftDemangler) indexNode()*swiftNode{
+	index := d.index()
+	if index < 0{
+		return nil
+	}
+	return &swiftNode{kind: swiftNumber, index: index}
+}
+
+func (d *swiftDemangler) operator()*swiftNode{
+	c := d.next()
+	switch{
+	case c >= 0x01 && c <= 0x17:
+		return d.symbolicReference(4)
+	case c >= 0x18 && c <= 0x1f:
+		return d.symbolicReference(8)
+	}
+
+	switch c{
+	case 'A':
+		return d.multiSubstitutions()
+	case 'B':
+		return d.builtinType()
+	case 'C':
+		return d.anyGenericType(swiftClass)
+	case 'D':
+		return newSwiftNode(swiftTypeMangling, d.pop(swiftType))
+	case 'E':
+		return d.extensionContext()
+	case 'F':
+		return d.plainFunction()
+	case 'G':
+		return d.boundGenericType()
+	case 'K':
+		return &swiftNode{kind: swiftThrows}
+	case 'L':
+		return d.localIdentifier()
+	case 'M':
+		return d.metadata()
+	case 'N':
+		return swiftDescribe("type metadata for %s", d.pop(swiftType))
+	case 'O':
+		return d.anyGenericType(swiftEnum)
+	case 'P':
+		return d.anyGenericType(swiftProtocol)
+	case 'Q':
+		return d.archetype()
+	case 'R':
+		return d.genericRequirement()
+	case 'S':
+		return d.standardSubstitution()
+	case 'T':
+		return d.thunk()
+	case 'V':
+		return d.anyGenericType(swiftStructure)
+	case 'W':
+		return d.witness()
+	case 'X':
+		return d.specialType()
+	case 'Y':
+		return d.typeAnnotation()
+	case 'Z':
+		return newSwiftNode(swiftStatic, d.popEntity())
+	case 'a':
+		return d.anyGenericType(swiftTypeAlias)
+	case 'c':
+		return d.functionType("")
+	case 'd':
+		return &swiftNode{kind: swiftVariadicMarker}
+	case 'f':
+		return d.functionEntity()
+	case 'h':
+		return d.typePrefix("__shared ")
+	case 'i':
+		return d.subscript()
+	case 'l':
+		return d.genericSignature(false)
+	case 'm':
+		return swiftTypeOf(newSwiftNode(swiftMetatype, d.pop(swiftType)))
+	case 'n':
+		return d.typePrefix("__owned ")
+	case 'o':
+		return d.operatorIdentifier()
+	case 'p':
+		return d.protocolListType()
+	case 'q':
+		return swiftTypeOf(d.genericParameterIndex())
+	case 'r':
+		return d.genericSignature(true)
+	case 's':
+		return &swiftNode{kind: swiftModule, text: "Swift"}
+	case 't':
+		return d.tupleType()
+	case 'v':
+		return d.accessor(d.entity(swiftVariable))
+	case 'x':
+		return swiftTypeOf(&swiftNode{kind: swiftGenericParameter})
+	case 'y':
+		return &swiftNode{kind: swiftEmptyList}
+	case 'z':
+		return d.typePrefix("inout ")
+	case '_':
+		return &swiftNode{kind: swiftFirstElementMarker}
+	case '.':
+		d.pos--
+		suffix := &swiftNode{kind: swiftSuffix, text: d.text[d.pos:]}
+		d.pos = len(d.text)
+		return suffix
+	}
+	d.pos--
+	return d.identifier()
+}
+
+func (d *swiftDemangler) symbolicReference(size int)*swiftNode{
+	offset := d.pos - 1
+	if nil == d.resolve || d.pos + size > len(d.text){
+		return nil
+	}
+	d.pos += size
+	name := d.resolve(offset)
+	if "" == name{
+		return nil
+	}
+	node := swiftTypeOf(&swiftNode{kind: swiftSymbolicReference, text: name})
+	d.addSubstitution(node)
+	return node
+}
+
+//<identifier> ::= <natural> <chars> | 0 <word substitutions and parts> | 00 <punycode>
+//Every identifier registers its words (runs of letters starting at an upper case letter or after a
+//non-letter) so later identifiers can refer to them by letter.
+func (d *swiftDemangler) identifier()*swiftNode{
+	if !isDigit(d.peek()){
+		return nil
+	}
+	words, punycoded := false, false
+	if d.nextIf('0'){
+		if d.nextIf('0'){
+			punycoded = true
+		} else {
+			words = true
+		}
+	}
+
+	var identifier strings.Builder
+	for{
+		for words && isLetter(d.peek()){
+			c := d.next()
+			index := int(c - 'a')
+			if isUpper(c){
+				index = int(c - 'A')
+				words = false
+			}
+			if index >= len(d.words){
+				return nil
+			}
+			identifier.WriteString(d.words[index])
+		}
+		if d.nextIf('0'){
+			break
+		}
+		length := d.natural()
+		if length <= 0{
+			return nil
+		}
+		if punycoded{
+			d.nextIf('_')
+		}
+		if d.pos + length > len(d.text){
+			return nil
+		}
+		part := d.text[d.pos : d.pos+length]
+		d.pos += length
+		if punycoded{
+			decoded, ok := decodeSwiftPunycode(part)
+			if !ok{
+				return nil
+			}
+			identifier.WriteString(decoded)
+		} else {
+			identifier.WriteString(part)
+			d.addWords(part)
+		}
+		if !words{
+			break
+		}
+	}
+	if 0 == identifier.Len(){
+		return nil
+	}
+	node := &swiftNode{kind: swiftIdentifier, text: identifier.String()}
+	d.addSubstitution(node)
+	return node
+}
+
+func (d *swiftDemangler) addWords(part string){
+	start := -1
+	for i := 0; i <= len(part); i++{
+		var c byte
+		if i < len(part){
+			c = part[i]
+		}
+		if start >= 0 && ('_' == c || 0 == c || (!isUpper(part[i-1]) && isUpper(c))){
+			if i - start >= 2 && len(d.words) < SWIFT_MAX_WORDS{
+				d.words = append(d.words, part[start:i])
+			}
+			start = -1
+		}
+		if start < 0 && !isDigit(c) && '_' != c && 0 != c{
+			start = i
+		}
+	}
+}
+
+//A followed by substitution letters, lower case ones push and continue, an upper case one ends the list.
+//A number before a letter repeats it, A<n>_ refers to substitution 27+n.
+func (d *swiftDemangler) multiSubstitutions()*swiftNode{
+	repeat := -1
+	for{
+		c := d.next()
+		switch{
+		case 0 == c:
+			return nil
+		case isLower(c):
+			node := d.repeatSubstitution(repeat, int(c - 'a'))
+			if nil == node{
+				return nil
+			}
+			d.push(node)
+			repeat = -1
+		case isUpper(c):
+			return d.repeatSubstitution(repeat, int(c - 'A'))
+		case '_' == c:
+			index := repeat + 27
+			if index < 0 || index >= len(d.substitutions){
+				return nil
+			}
+			return d.substitutions[index]
+		default:
+			d.pos--
+			if repeat = d.natural(); repeat < 0{
+				return nil
+			}
+		}
+	}
+}
+
+func (d *swiftDemangler) repeatSubstitution(repeat int, index int)*swiftNode{
+	if index >= len(d.substitutions){
+		return nil
+	}
+	node := d.substitutions[index]
+	for ; repeat > 1; repeat--{
+		d.push(node)
+	}
+	return node
+}
+
+//S<letter> for the common standard library types, So and SC for imported C modules.
+func (d *swiftDemangler) standardSubstitution()*swiftNode{
+	switch d.peek(){
+	case 'o':
+		d.pos++
+		return &swiftNode{kind: swiftModule, text: "__C"}
+	case 'C':
+		d.pos++
+		return &swiftNode{kind: swiftModule, text: "__C_Synthesized"}
+	case 'g':
+		d.pos++
+		optional := swiftTypeOf(newSwiftNode(swiftBoundGeneric, swiftStandardType(swiftEnum, "Optional"),
+			newSwiftNode(swiftTypeList, d.pop(swiftType))))
+		d.addSubstitution(optional)
+		return optional
+	}
+
+	repeat := d.natural()
+	table := swiftStandardTypes
+	if d.nextIf('c'){
+		table = swiftConcurrencyTypes
+	}
+	standard, ok := table[d.next()]
+	if !ok{
+		return nil
+	}
+	node := swiftStandardType(standard.kind, standard.name)
+	for ; repeat > 1; repeat--{
+		d.push(node)
+	}
+	return node
+}
+
+func (d *swiftDemangler) popModule()*swiftNode{
+	if identifier := d.pop(swiftIdentifier); nil != identifier{
+		return &swiftNode{kind: swiftModule, text: identifier.text}
+	}
+	return d.pop(swiftModule)
+}
+
+func (d *swiftDemangler) popContext()*swiftNode{
+	if module := d.popModule(); nil != module{
+		return module
+	}
+	if typ := d.pop(swiftType); nil != typ{
+		if 1 != len(typ.children) || !isSwiftContext(typ.children[0].kind){
+			return nil
+		}
+		return typ.children[0]
+	}
+	return d.popIf(isSwiftContext)
+}
+
+func (d *swiftDemangler) popDeclName()*swiftNode{
+	return d.popIf(isSwiftDeclName)
+}
+
+func (d *swiftDemangler) popEntity()*swiftNode{
+	return d.popIf(func(kind swiftKind)bool{
+		return swiftType == kind || isSwiftContext(kind)
+	})
+}
+
+//The type under the Type wrapper on top of the stack
+func (d *swiftDemangler) popTypeChild()*swiftNode{
+	typ := d.pop(swiftType)
+	if nil == typ{
+		return nil
+	}
+	return typ.children[0]
+}
+
+func (d *swiftDemangler) typePrefix(prefix string)*swiftNode{
+	node := newSwiftNode(swiftTypePrefix, d.popTypeChild())
+	if nil == node{
+		return nil
+	}
+	node.text = prefix
+	return swiftTypeOf(node)
+}
+
+func (d *swiftDemangler) anyGenericType(kind swiftKind)*swiftNode{
+	name := d.popDeclName()
+	context := d.popContext()
+	typ := swiftTypeOf(newSwiftNode(kind, context, name))
+	d.addSubstitution(typ)
+	return typ
+}
+
+//L<index> local declaration, LL private declaration, Ll private discriminator on its own
+func (d *swiftDemangler) localIdentifier()*swiftNode{
+	if d.nextIf('L'){
+		discriminator := d.pop(swiftIdentifier)
+		name := d.popDeclName()
+		return newSwiftNode(swiftPrivateDeclName, discriminator, name)
+	}
+	if d.nextIf('l'){
+		return newSwiftNode(swiftPrivateDeclName, d.pop(swiftIdentifier))
+	}
+	if c := d.peek(); (c >= 'a' && c <= 'j') || (c >= 'A' && c <= 'J'){
+		d.pos++
+		kind := &swiftNode{kind: swiftIdentifier, text: string(c)}
+		return newSwiftNode(swiftRelatedEntityDeclName, kind, d.popDeclName())
+	}
+	discriminator := d.indexNode()
+	return newSwiftNode(swiftLocalDeclName, discriminator, d.popDeclName())
+}
+
+func (d *swiftDemangler) extensionContext()*swiftNode{
+	signature := d.pop(swiftGenericSignature)
+	module := d.popModule()
+	extended := d.popTypeChild()
+	if nil != extended && !isSwiftNominal(extended.kind) && swiftBoundGeneric != extended.kind && swiftSymbolicReference != extended.kind{
+		return nil
+	}
+	extension := newSwiftNode(swiftExtension, module, extended)
+	if nil != extension && nil != signature{
+		extension.children = append(extension.children, signature)
+	}
+	return extension
+}
+
+//<type> y (<type>* _)* <type>* G, the arguments of the innermost type come last
+func (d *swiftDemangler) boundGenericType()*swiftNode{
+	var lists [][]*swiftNode
+	for{
+		var list []*swiftNode
+		for typ := d.pop(swiftType); nil != typ; typ = d.pop(swiftType){
+			list = append([]*swiftNode{typ}, list...)
+		}
+		lists = append(lists, list)
+		if nil != d.pop(swiftEmptyList){
+			break
+		}
+		if nil == d.pop(swiftFirstElementMarker){
+			return nil
+		}
+	}
+	nominal := d.popTypeChild()
+	if nil == nominal{
+		return nil
+	}
+	bound := d.boundGenericArguments(nominal, lists, 0)
+	typ := swiftTypeOf(bound)
+	d.addSubstitution(typ)
+	return typ
+}
+
+func (d *swiftDemangler) boundGenericArguments(nominal *swiftNode, lists [][]*swiftNode, index int)*swiftNode{
+	if nil == nominal || index >= len(lists){
+		return nil
+	}
+	arguments := lists[index]
+	index++
+	if index < len(lists){
+		//arguments of an enclosing generic type
+		if !isSwiftNominal(nominal.kind){
+			return nil
+		}
+		context := nominal.children[0]
+		var parent *swiftNode
+		if swiftExtension == context.kind{
+			parent = newSwiftNode(swiftExtension, context.children[0], d.boundGenericArguments(context.children[1], lists, index))
+			if nil != parent{
+				parent.children = append(parent.children, context.children[2:]...)
+			}
+		} else {
+			parent = d.boundGenericArguments(context, lists, index)
+		}
+		if nil == parent{
+			return nil
+		}
+		nominal = &swiftNode{kind: nominal.kind, children: append([]*swiftNode{parent}, nominal.children[1:]...)}
+	}
+	if 0 == len(arguments){
+		return nominal
+	}
+	return newSwiftNode(swiftBoundGeneric, swiftTypeOf(nominal), &swiftNode{kind: swiftTypeList, children: arguments})
+}
+
+//Pops the pieces of a function type: effects, then the parameters, then the result.
+func (d *swiftDemangler) functionType(prefix string)*swiftNode{
+	function := &swiftNode{kind: swiftFunctionType, text: prefix}
+	for _, kind := range []swiftKind{swiftThrows, swiftSendable, swiftAsync}{
+		if effect := d.pop(kind); nil != effect{
+			function.children = append(function.children, effect)
+		}
+	}
+	parameters := d.functionParameters(swiftArgumentTuple)
+	result := d.functionParameters(swiftReturnType)
+	if nil == parameters || nil == result{
+		return nil
+	}
+	function.children = append(function.children, parameters, result)
+	return swiftTypeOf(function)
+}
+
+func (d *swiftDemangler) functionParameters(kind swiftKind)*swiftNode{
+	if nil != d.pop(swiftEmptyList){
+		return newSwiftNode(kind, swiftTypeOf(&swiftNode{kind: swiftTuple}))
+	}
+	return newSwiftNode(kind, d.pop(swiftType))
+}
+
+//Argument labels follow the name of a function: an identifier or _ per parameter, or y if none has one.
+func (d *swiftDemangler) functionParameterLabels(typ *swiftNode)*swiftNode{
+	if !d.oldFunctionTypes && nil != d.pop(swiftEmptyList){
+		return &swiftNode{kind: swiftLabelList}
+	}
+	if nil == typ || swiftType != typ.kind{
+		return nil
+	}
+	function := typ.children[0]
+	if swiftDependentGenericType == function.kind{
+		function = function.children[1].unwrapped()
+	}
+	if swiftFunctionType != function.kind{
+		return nil
+	}
+	parameters := function.child(swiftArgumentTuple).children[0].unwrapped()
+	count := 1
+	if swiftTuple == parameters.kind{
+		count = len(parameters.children)
+	}
+	if 0 == count || d.oldFunctionTypes{
+		return nil
+	}
+
+	labels := &swiftNode{kind: swiftLabelList}
+	hasLabels := false
+	for i := 0; i < count; i++{
+		label := d.pop(swiftIdentifier, swiftFirstElementMarker)
+		if nil == label{
+			return nil
+		}
+		labels.children = append([]*swiftNode{label}, labels.children...)
+		hasLabels = hasLabels || swiftIdentifier == label.kind
+	}
+	if !hasLabels{
+		return &swiftNode{kind: swiftLabelList}
+	}
+	return labels
+}
+
+func (d *swiftDemangler) plainFunction()*swiftNode{
+	signature := d.pop(swiftGenericSignature)
+	typ := d.functionType("")
+	labels := d.functionParameterLabels(typ)
+	if nil != signature{
+		typ = swiftTypeOf(newSwiftNode(swiftDependentGenericType, signature, typ))
+	}
+	name := d.popDeclName()
+	context := d.popContext()
+	if nil != labels{
+		return newSwiftNode(swiftFunction, context, name, labels, typ)
+	}
+	return newSwiftNode(swiftFunction, context, name, typ)
+}
+
+//f<kind>: initializers, deinitializers, closures and other unnamed functions
+func (d *swiftDemangler) functionEntity()*swiftNode{
+	const (
+		none = iota
+		typeAndName
+		typeAndIndex
+		index
+	)
+	arguments := none
+	var kind swiftKind
+	switch d.next(){
+	case 'D':
+		kind = swiftDeallocator
+	case 'd':
+		kind = swiftDestructor
+	case 'E':
+		kind = swiftIVarDestroyer
+	case 'e':
+		kind = swiftIVarInitializer
+	case 'i':
+		kind = swiftInitializer
+	case 'C':
+		arguments, kind = typeAndName, swiftAllocator
+	case 'c':
+		arguments, kind = typeAndName, swiftConstructor
+	case 'U':
+		arguments, kind = typeAndIndex, swiftExplicitClosure
+	case 'u':
+		arguments, kind = typeAndIndex, swiftImplicitClosure
+	case 'A':
+		arguments, kind = index, swiftDefaultArgument
+	case 'P':
+		kind = swiftPropertyWrapperInitializer
+	default:
+		return nil
+	}
+
+	var name, typ, labels *swiftNode
+	switch arguments{
+	case typeAndName:
+		name = d.pop(swiftPrivateDeclName)
+		typ = d.pop(swiftType)
+		labels = d.functionParameterLabels(typ)
+	case typeAndIndex:
+		name = d.indexNode()
+		typ = d.pop(swiftType)
+	case index:
+		name = d.indexNode()
+	}
+
+	entity := newSwiftNode(kind, d.popContext())
+	if nil == entity{
+		return nil
+	}
+	switch arguments{
+	case typeAndName:
+		if nil == typ{
+			return nil
+		}
+		if nil != labels{
+			entity.children = append(entity.children, labels)
+		}
+		entity.children = append(entity.children, typ)
+		if nil != name{
+			entity.children = append(entity.children, name)
+		}
+	case typeAndIndex:
+		if nil == name || nil == typ{
+			return nil
+		}
+		entity.children = append(entity.children, name, typ)
+	case index:
+		if nil == name{
+			return nil
+		}
+		entity.children = append(entity.children, name)
+	}
+	return entity
+}
+
+//Variables: the type, then labels for subscripts, the name and the context.
+func (d *swiftDemangler) entity(kind swiftKind)*swiftNode{
+	typ := d.pop(swiftType)
+	labels := d.functionParameterLabels(typ)
+	name := d.popDeclName()
+	context := d.popContext()
+	if nil != labels{
+		return newSwiftNode(kind, context, name, labels, typ)
+	}
+	return newSwiftNode(kind, context, name, typ)
+}
+
+func (d *swiftDemangler) subscript()*swiftNode{
+	privateName := d.pop(swiftPrivateDeclName)
+	typ := d.pop(swiftType)
+	labels := d.functionParameterLabels(typ)
+	subscript := newSwiftNode(swiftSubscript, d.popContext())
+	if nil == subscript || nil == typ{
+		return nil
+	}
+	if nil != labels{
+		subscript.children = append(subscript.children, labels)
+	}
+	subscript.children = append(subscript.children, typ)
+	if nil != privateName{
+		subscript.children = append(subscript.children, privateName)
+	}
+	return d.accessor(subscript)
+}
+
+func (d *swiftDemangler) accessor(storage *swiftNode)*swiftNode{
+	if nil == storage{
+		return nil
+	}
+	var name string
+	switch d.next(){
+	case 'm':
+		name = "materializeForSet"
+	case 's':
+		name = "setter"
+	case 'g', 'G':
+		name = "getter"
+	case 'w':
+		name = "willset"
+	case 'W':
+		name = "didset"
+	case 'r':
+		name = "read"
+	case 'M':
+		name = "modify"
+	case 'i':
+		name = "init"
+	case 'a':
+		name = map[byte]string{'O': "owningMutableAddressor", 'o': "nativeOwningMutableAddressor",
+			'p': "nativePinningMutableAddressor", 'u': "unsafeMutableAddressor"}[d.next()]
+	case 'l':
+		name = map[byte]string{'O': "owningAddressor", 'o': "nativeOwningAddressor",
+			'p': "nativePinningAddressor", 'u': "unsafeAddressor"}[d.next()]
+	case 'p':
+		//the variable or subscript itself
+		return storage
+	}
+	if "" == name{
+		return nil
+	}
+	return &swiftNode{kind: swiftAccessor, text: name, children: []*swiftNode{storage}}
+}
+
+func (d *swiftDemangler) tupleType()*swiftNode{
+	tuple := &swiftNode{kind: swiftTuple}
+	if nil == d.pop(swiftEmptyList){
+		for{
+			first := nil != d.pop(swiftFirstElementMarker)
+			element := &swiftNode{kind: swiftTupleElement}
+			if variadic := d.pop(swiftVariadicMarker); nil != variadic{
+				element.children = append(element.children, variadic)
+			}
+			if label := d.pop(swiftIdentifier); nil != label{
+				element.children = append(element.children, &swiftNode{kind: swiftTupleElementName, text: label.text})
+			}
+			typ := d.pop(swiftType)
+			if nil == typ{
+				return nil
+			}
+			element.children = append(element.children, typ)
+			tuple.children = append([]*swiftNode{element}, tuple.children...)
+			if first{
+				break
+			}
+		}
+	}
+	return swiftTypeOf(tuple)
+}
+
+func (d *swiftDemangler) popProtocol()*swiftNode{
+	if 0 != len(d.stack) && swiftType == d.stack[len(d.stack)-1].kind{
+		protocol := d.stack[len(d.stack)-1]
+		if kind := protocol.children[0].kind; swiftProtocol != kind && swiftSymbolicReference != kind{
+			return nil
+		}
+		return d.pop(swiftType)
+	}
+	name := d.popDeclName()
+	context := d.popContext()
+	return swiftTypeOf(newSwiftNode(swiftProtocol, context, name))
+}
+
+func (d *swiftDemangler) protocolList()*swiftNode{
+	list := &swiftNode{kind: swiftTypeList}
+	if nil == d.pop(swiftEmptyList){
+		for{
+			first := nil != d.pop(swiftFirstElementMarker)
+			protocol := d.popProtocol()
+			if nil == protocol{
+				return nil
+			}
+			list.children = append([]*swiftNode{protocol}, list.children...)
+			if first{
+				break
+			}
+		}
+	}
+	return newSwiftNode(swiftProtocolList, list)
+}
+
+func (d *swiftDemangler) protocolListType()*swiftNode{
+	return swiftTypeOf(d.protocolList())
+}
+
+//<type> <protocol> <module> with an optional generic signature on top
+func (d *swiftDemangler) popProtocolConformance()*swiftNode{
+	signature := d.pop(swiftGenericSignature)
+	module := d.popModule()
+	protocol := d.popProtocol()
+	typ := d.pop(swiftType)
+	if nil == typ{
+		//conformances of local types carry an extra identifier
+		d.pop(swiftIdentifier)
+		typ = d.pop(swiftType)
+	}
+	if nil != signature{
+		typ = swiftTypeOf(newSwiftNode(swiftDependentGenericType, signature, typ))
+	}
+	return newSwiftNode(swiftProtocolConformance, typ, protocol, module)
+}
+
+//Generic parameters: q<index> is (0, index+1), qd<depth><index>, qz and x are (0, 0)
+func (d *swiftDemangler) genericParameterIndex()*swiftNode{
+	if d.nextIf('d'){
+		depth := d.index() + 1
+		index := d.index()
+		if depth <= 0 || index < 0{
+			return nil
+		}
+		return &swiftNode{kind: swiftGenericParameter, depth: depth, index: index}
+	}
+	if d.nextIf('z'){
+		return &swiftNode{kind: swiftGenericParameter}
+	}
+	index := d.index()
+	if index < 0{
+		return nil
+	}
+	return &swiftNode{kind: swiftGenericParameter, index: index + 1}
+}
+
+//l is one generic parameter, r<counts>l gives the number of parameters at each depth. The requirements
+//come before it on the stack.
+func (d *swiftDemangler) genericSignature(hasCounts bool)*swiftNode{
+	signature := &swiftNode{kind: swiftGenericSignature}
+	if hasCounts{
+		for !d.nextIf('l'){
+			count := 0
+			if !d.nextIf('z'){
+				index := d.index()
+				if index < 0{
+					return nil
+				}
+				count = index + 1
+			}
+			signature.children = append(signature.children, &swiftNode{kind: swiftParameterCount, index: count})
+		}
+	} else {
+		signature.children = append(signature.children, &swiftNode{kind: swiftParameterCount, index: 1})
+	}
+
+	var requirements []*swiftNode
+	for requirement := d.pop(swiftRequirement, swiftLayoutRequirement); nil != requirement; requirement = d.pop(swiftRequirement, swiftLayoutRequirement){
+		requirements = append([]*swiftNode{requirement}, requirements...)
+	}
+	signature.children = append(signature.children, requirements...)
+	return signature
+}
+
+//R<kind>: a protocol, superclass, same type or layout constraint on a generic parameter (the default),
+//an associated type of one (lower case) or a substituted type (upper case).
+func (d *swiftDemangler) genericRequirement()*swiftNode{
+	const (
+		generic = iota
+		associated
+		substitution
+	)
+	const (
+		protocol = iota
+		baseClass
+		sameType
+		layout
+	)
+	typeKind, constraint := generic, protocol
+	switch d.next(){
+	case 'c':
+		typeKind, constraint = associated, baseClass
+	case 'b':
+		typeKind, constraint = generic, baseClass
+	case 'B':
+		typeKind, constraint = substitution, baseClass
+	case 't':
+		typeKind, constraint = associated, sameType
+	case 's':
+		typeKind, constraint = generic, sameType
+	case 'S':
+		typeKind, constraint = substitution, sameType
+	case 'm':
+		typeKind, constraint = associated, layout
+	case 'l':
+		typeKind, constraint = generic, layout
+	case 'L':
+		typeKind, constraint = substitution, layout
+	case 'p':
+		typeKind, constraint = associated, protocol
+	case 'Q':
+		typeKind, constraint = substitution, protocol
+	case 'C', 'T', 'M', 'P', 'h', 'v', 'V', 'i', 'I':
+		//compound associated types, same shape and value requirements
+		return nil
+	default:
+		d.pos--
+	}
+
+	var constrained *swiftNode
+	switch typeKind{
+	case generic:
+		constrained = swiftTypeOf(d.genericParameterIndex())
+	case associated:
+		constrained = d.associatedType(swiftTypeOf(d.genericParameterIndex()))
+		d.addSubstitution(constrained)
+	case substitution:
+		constrained = d.pop(swiftType)
+	}
+	if nil == constrained{
+		return nil
+	}
+
+	var requirement *swiftNode
+	switch constraint{
+	case protocol:
+		requirement = newSwiftNode(swiftRequirement, constrained, d.popProtocol())
+		if nil != requirement{
+			requirement.text = ": "
+		}
+	case baseClass:
+		requirement = newSwiftNode(swiftRequirement, constrained, d.pop(swiftType))
+		if nil != requirement{
+			requirement.text = ": "
+		}
+	case sameType:
+		requirement = newSwiftNode(swiftRequirement, constrained, d.pop(swiftType))
+		if nil != requirement{
+			requirement.text = " == "
+		}
+	case layout:
+		name := d.layoutConstraint()
+		if "" == name{
+			return nil
+		}
+		requirement = &swiftNode{kind: swiftLayoutRequirement, text: name, children: []*swiftNode{constrained}}
+	}
+	return requirement
+}
+
+func (d *swiftDemangler) layoutConstraint()string{
+	switch c := d.next(); c{
+	case 'U':
+		return "_UnknownLayout"
+	case 'R':
+		return "_RefCountedObject"
+	case 'N':
+		return "_NativeRefCountedObject"
+	case 'C':
+		return "AnyObject"
+	case 'D':
+		return "_NativeClass"
+	case 'T':
+		return "_Trivial"
+	case 'E', 'M', 'e', 'm':
+		size := d.index() - 1
+		name := "_Trivial"
+		if 'M' == c || 'm' == c{
+			name = "_TrivialAtMost"
+		}
+		if 'e' == c || 'm' == c{
+			alignment := d.index() - 1
+			return fmt.Sprintf("%s(%d, %d)", name, size, alignment)
+		}
+		return fmt.Sprintf("%s(%d)", name, size)
+	}
+	return ""
+}
+
+//An associated type of base, named by the identifier (and optional protocol) on the stack.
+//A nil base is popped from the stack too.
+func (d *swiftDemangler) associatedType(base *swiftNode)*swiftNode{
+	if 0 != len(d.stack) && swiftType == d.stack[len(d.stack)-1].kind{
+		if nil == d.popProtocol(){
+			return nil
+		}
+	}
+	name := d.pop(swiftIdentifier)
+	if nil == name{
+		return nil
+	}
+	if nil == base{
+		base = d.pop(swiftType)
+	}
+	member := newSwiftNode(swiftDependentMemberType, base, &swiftNode{kind: swiftAssociatedTypeRef, text: name.text})
+	return swiftTypeOf(member)
+}
+
+//Q<kind>: associated types of generic parameters. Opaque result types are not supported.
+func (d *swiftDemangler) archetype()*swiftNode{
+	var typ *swiftNode
+	switch d.next(){
+	case 'y':
+		typ = d.associatedType(swiftTypeOf(d.genericParameterIndex()))
+	case 'z':
+		typ = d.associatedType(swiftTypeOf(&swiftNode{kind: swiftGenericParameter}))
+	case 'x':
+		typ = d.associatedType(nil)
+	default:
+		return nil
+	}
+	d.addSubstitution(typ)
+	return typ
+}
+
+func (d *swiftDemangler) builtinType()*swiftNode{
+	var name string
+	switch d.next(){
+	case 'b':
+		name = "Builtin.BridgeObject"
+	case 'B':
+		name = "Builtin.UnsafeValueBuffer"
+	case 'e':
+		name = "Builtin.Executor"
+	case 'D':
+		name = "Builtin.DefaultActorStorage"
+	case 'c':
+		name = "Builtin.RawUnsafeContinuation"
+	case 'j':
+		name = "Builtin.Job"
+	case 'I':
+		name = "Builtin.IntLiteral"
+	case 'O':
+		name = "Builtin.UnknownObject"
+	case 'o':
+		name = "Builtin.NativeObject"
+	case 'p':
+		name = "Builtin.RawPointer"
+	case 't':
+		name = "Builtin.SILToken"
+	case 'w':
+		name = "Builtin.Word"
+	case 'f':
+		size := d.index() - 1
+		if size <= 0{
+			return nil
+		}
+		name = fmt.Sprintf("Builtin.FPIEEE%d", size)
+	case 'i':
+		size := d.index() - 1
+		if size <= 0{
+			return nil
+		}
+		name = fmt.Sprintf("Builtin.Int%d", size)
+	case 'v':
+		count := d.index() - 1
+		element := d.popTypeChild()
+		if count <= 0 || nil == element{
+			return nil
+		}
+		name = fmt.Sprintf("Builtin.Vec%dx%s", count, swiftPrinter{}.print(element))
+	default:
+		return nil
+	}
+	typ := swiftTypeOf(&swiftNode{kind: swiftBuiltinType, text: name})
+	d.addSubstitution(typ)
+	return typ
+}
+
+//o<fixity> turns the identifier on the stack into an operator, its letters standing for operator characters.
+func (d *swiftDemangler) operatorIdentifier()*swiftNode{
+	identifier := d.pop(swiftIdentifier)
+	if nil == identifier{
+		return nil
+	}
+	const characters = "& @/= >    <*!|+?%-~   ^ ."
+	var operator strings.Builder
+	for i := 0; i < len(identifier.text); i++{
+		c := identifier.text[i]
+		if c >= 0x80{
+			operator.WriteByte(c)
+			continue
+		}
+		if !isLower(c) || ' ' == characters[c - 'a']{
+			return nil
+		}
+		operator.WriteByte(characters[c - 'a'])
+	}
+
+	kinds := map[byte]swiftKind{'i': swiftInfixOperator, 'p': swiftPrefixOperator, 'P': swiftPostfixOperator}
+	kind, ok := kinds[d.next()]
+	if !ok{
+		return nil
+	}
+	return &swiftNode{kind: kind, text: operator.String()}
+}
+
+//X<kind>: function conventions, reference ownership and other special types
+func (d *swiftDemangler) specialType()*swiftNode{
+	switch d.next(){
+	case 'f':
+		return d.functionType("@convention(thin) ")
+	case 'B':
+		return d.functionType("@convention(block) ")
+	case 'C':
+		return d.functionType("@convention(c) ")
+	case 'E':
+		return d.functionType("")
+	case 'o':
+		return d.typePrefix("unowned ")
+	case 'u':
+		return d.typePrefix("unowned(unsafe) ")
+	case 'w':
+		return d.typePrefix("weak ")
+	case 'D':
+		return swiftTypeOf(newSwiftNode(swiftDynamicSelf, d.pop(swiftType)))
+	case 'l':
+		return swiftTypeOf(newSwiftNode(swiftProtocolListWithAnyObject, d.protocolList()))
+	case 'p':
+		return swiftTypeOf(newSwiftNode(swiftExistentialMetatype, d.pop(swiftType)))
+	case 'M':
+		representation := map[byte]string{'t': "@thin ", 'T': "@thick ", 'o': "@objc_metatype "}[d.next()]
+		metatype := newSwiftNode(swiftMetatype, d.pop(swiftType))
+		if nil == metatype || "" == representation{
+			return nil
+		}
+		metatype.text = representation
+		return swiftTypeOf(metatype)
+	}
+	return nil
+}
+
+//Y<kind>: function type effects
+func (d *swiftDemangler) typeAnnotation()*swiftNode{
+	switch d.next(){
+	case 'a':
+		return &swiftNode{kind: swiftAsync}
+	case 'b':
+		return &swiftNode{kind: swiftSendable}
+	}
+	return nil
+}
+
+//M<kind>: type metadata and descriptors
+func (d *swiftDemangler) metadata()*swiftNode{
+	c := d.next()
+	typeDescriptions := map[byte]string{
+		'a': "type metadata accessor for %s",
+		'f': "full type metadata for %s",
+		'n': "nominal type descriptor for %s",
+		'm': "metaclass for %s",
+		'L': "lazy cache variable for type metadata for %s",
+		'l': "type metadata singleton initialization cache for %s",
+		'i': "type metadata instantiation function for %s",
+		'I': "type metadata instantiation cache for %s",
+		'r': "type metadata completion function for %s",
+		'u': "method lookup function for %s",
+		'U': "ObjC metadata update function for %s",
+		'o': "class metadata base offset for %s",
+		'P': "generic type metadata pattern for %s",
+		'F': "reflection metadata field descriptor %s",
+		'B': "reflection metadata builtin descriptor %s",
+		'D': "demangling cache variable for type metadata for %s",
+		's': "ObjC resilient class stub for %s",
+		't': "full ObjC resilient class stub for %s",
+		'N': "noncanonical specialized generic type metadata for %s",
+	}
+	if format, ok := typeDescriptions[c]; ok{
+		return swiftDescribe(format, d.pop(swiftType))
+	}
+
+	switch c{
+	case 'p':
+		return swiftDescribe("protocol descriptor for %s", d.popProtocol())
+	case 'S':
+		return swiftDescribe("protocol self-conformance descriptor for %s", d.popProtocol())
+	case 'c':
+		return swiftDescribe("protocol conformance descriptor for %s", d.popProtocolConformance())
+	case 'V':
+		return swiftDescribe("property descriptor for %s", d.popEntity())
+	case 'X':
+		switch d.next(){
+		case 'E':
+			return swiftDescribe("extension descriptor %s", d.popContext())
+		case 'M':
+			return swiftDescribe("module descriptor %s", d.popContext())
+		case 'Y':
+			return swiftDescribe("anonymous descriptor %s", d.popContext())
+		}
+	}
+	return nil
+}
+
+//W<kind>: witness tables and field offsets
+func (d *swiftDemangler) witness()*swiftNode{
+	c := d.next()
+	conformanceDescriptions := map[byte]string{
+		'P': "protocol witness table for %s",
+		'p': "protocol witness table pattern for %s",
+		'G': "generic protocol witness table for %s",
+		'I': "instantiation function for generic protocol witness table for %s",
+		'r': "resilient protocol witness table for %s",
+		'a': "protocol witness table accessor for %s",
+	}
+	if format, ok := conformanceDescriptions[c]; ok{
+		return swiftDescribe(format, d.popProtocolConformance())
+	}
+
+	switch c{
+	case 'V':
+		return swiftDescribe("value witness table for %s", d.pop(swiftType))
+	case 'v':
+		format := map[byte]string{'d': "direct field offset for %s", 'i': "indirect field offset for %s"}[d.next()]
+		if "" == format{
+			return nil
+		}
+		return swiftDescribe(format, d.popEntity())
+	case 'S':
+		return swiftDescribe("protocol self-conformance witness table for %s", d.popProtocol())
+	case 'l', 'L':
+		conformance := d.popProtocolConformance()
+		typ := d.pop(swiftType)
+		if 'l' == c{
+			return swiftDescribe("lazy protocol witness table accessor for type %s and conformance %s", typ, conformance)
+		}
+		return swiftDescribe("lazy protocol witness table cache variable for type %s and conformance %s", typ, conformance)
+	case 't':
+		name := d.popDeclName()
+		conformance := d.popProtocolConformance()
+		return swiftDescribe("associated type metadata accessor for %s in %s", name, conformance)
+	case 'O':
+		format := map[byte]string{
+			'y': "outlined copy of %s", 'e': "outlined consume of %s", 'r': "outlined retain of %s",
+			's': "outlined release of %s", 'b': "outlined init with take of %s", 'c': "outlined init with copy of %s",
+			'd': "outlined assign with take of %s", 'f': "outlined assign with copy of %s", 'h': "outlined destroy of %s",
+		}[d.next()]
+		if "" == format{
+			return nil
+		}
+		signature := d.pop(swiftGenericSignature)
+		typ := d.pop(swiftType)
+		if nil != signature{
+			typ = swiftTypeOf(newSwiftNode(swiftDependentGenericType, signature, typ))
+		}
+		return swiftDescribe(format, typ)
+	}
+	return nil
+}
+
+//T<kind>: thunks and attributes of the function before them
+func (d *swiftDemangler) thunk()*swiftNode{
+	c := d.next()
+	attributes := map[byte]string{
+		'o': "@objc ",
+		'O': "@nonobjc ",
+		'D': "dynamic ",
+		'd': "super ",
+		'A': "partial apply forwarder for ",
+		'a': "partial apply ObjC forwarder for ",
+		'm': "merged ",
+		'X': "dynamically replaceable variable for ",
+		'x': "dynamically replaceable key for ",
+		'I': "dynamically replaceable thunk for ",
+	}
+	if text, ok := attributes[c]; ok{
+		return &swiftNode{kind: swiftAttribute, text: text}
+	}
+	entityDescriptions := map[byte]string{
+		'c': "curry thunk of %s",
+		'j': "dispatch thunk of %s",
+		'q': "method descriptor for %s",
+		'u': "async function pointer to %s",
+		'S': "protocol self-conformance witness for %s",
+	}
+	if format, ok := entityDescriptions[c]; ok{
+		return swiftDescribe(format, d.popEntity())
+	}
+
+	switch c{
+	case 'W':
+		entity := d.popEntity()
+		conformance := d.popProtocolConformance()
+		return swiftDescribe("protocol witness for %s in conformance %s", entity, conformance)
+	case 'V':
+		base := d.popEntity()
+		derived := d.popEntity()
+		return swiftDescribe("vtable thunk for %s dispatching to %s", base, derived)
+	case 'Q', 'Y':
+		index := d.indexNode()
+		if nil == index{
+			return nil
+		}
+		text := "await resume"
+		if 'Y' == c{
+			text = "suspend resume"
+		}
+		return &swiftNode{kind: swiftAttribute, text: fmt.Sprintf("(%d) %s partial function for ", index.index, text)}
+	}
+	return nil
+}
+
+//Swift's punycode variant: digits are a-z then A-J, the delimiter is _ and ASCII characters which are not
+//valid in identifiers are mapped to 0xD800 and up.
+func decodeSwiftPunycode(input string)(string, bool){
+	const (
+		base = 36
+		tMin = 1
+		tMax = 26
+		skew = 38
+		damp = 700
+	)
+	adapt := func(delta int, points int, first bool)int{
+		if first{
+			delta /= damp
+		} else {
+			delta /= 2
+		}
+		delta += delta / points
+		k := 0
+		for delta > ((base - tMin) * tMax) / 2{
+			delta /= base - tMin
+			k += base
+		}
+		return k + ((base - tMin + 1) * delta) / (delta + skew)
+	}
+
+	var output []rune
+	if delimiter := strings.LastIndexByte(input, '_'); -1 != delimiter{
+		for _, c := range []byte(input[:delimiter]){
+			if c >= 0x80{
+				return "", false
+			}
+			output = append(output, rune(c))
+		}
+		input = input[delimiter+1:]
+	}
+
+	n, i, bias := 128, 0, 72
+	for 0 != len(input){
+		old, w := i, 1
+		for k := base; ; k += base{
+			if 0 == len(input){
+				return "", false
+			}
+			c := input[0]
+			input = input[1:]
+			digit := -1
+			switch{
+			case isLower(c):
+				digit = int(c - 'a')
+			case c >= 'A' && c <= 'J':
+				digit = int(c - 'A') + 26
+			}
+			if digit < 0{
+				return "", false
+			}
+			i += digit * w
+			t := k - bias
+			if k <= bias{
+				t = tMin
+			} else if k >= bias + tMax{
+				t = tMax
+			}
+			if digit < t{
+				break
+			}
+			w *= base - t
+			if w > 0x10ffff{
+				return "", false
+			}
+		}
+		bias = adapt(i - old, len(output) + 1, 0 == old)
+		n += i / (len(output) + 1)
+		i %= len(output) + 1
+		if n < 0x80 || n > 0x10ffff{
+			return "", false
+		}
+		output = append(output[:i], append([]rune{rune(n)}, output[i:]...)...)
+		i++
+	}
+
+	for j, c := range output{
+		if c >= 0xD800 && c < 0xD880{
+			output[j] = c - 0xD800
+		}
+	}
+	return string(output), true
+}
+
+func isLetter(c byte)bool{
+	return isUpper(c) || isLower(c)
+}
+
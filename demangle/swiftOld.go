@@ -0,0 +1,688 @@
+package demangle
+
+import (
+	"fmt"
+	"strings"
+)
+
+//Swift name mangling before Swift 4 (docs/ABI/OldMangling.rst), still found in old binaries and in the
+//Objective-C runtime names of Swift classes ("_TtC4main3Foo"). Unlike the current mangling it is prefix
+//ordered, so this is a plain recursive descent parser building the same nodes as swiftDemangler.
+
+type oldSwiftDemangler struct{
+	text string
+	pos int
+	substitutions []*swiftNode
+	failed bool
+}
+
+/*
+	//////////////////////////////////////// PRIVATE METHODS ////////////////////////////////////////
+*/
+
+//Demangles what follows "_T", returning nil if it is not understood.
+func demangleOldSwift(text string)*swiftNode{
+	d := &oldSwiftDemangler{text: text}
+	global := &swiftNode{kind: swiftGlobal}
+	for{
+		attribute := map[string]string{"To": "@objc ", "TO": "@nonobjc ", "TD": "dynamic ", "Td": "super "}[d.peekString(2)]
+		if "" == attribute{
+			break
+		}
+		d.pos += 2
+		global.children = append(global.children, &swiftNode{kind: swiftAttribute, text: attribute})
+	}
+
+	var node *swiftNode
+	switch c := d.next(); c{
+	case 't':
+		node = d.typ()
+	case 'M':
+		node = d.metadata()
+	case 'W':
+		node = d.witness()
+	case 'T':
+		if d.nextIf('W'){
+			conformance := d.protocolConformance()
+			entity := d.entity()
+			node = swiftDescribe("protocol witness for %s in conformance %s", entity, conformance)
+		}
+	default:
+		//"_T" on its own is just a C global named T
+		if d.failed{
+			return nil
+		}
+		d.pos--
+		node = d.entity()
+	}
+	if nil == node || d.failed || d.pos != len(d.text){
+		return nil
+	}
+	global.children = append(global.children, node)
+	return global
+}
+
+func (d *oldSwiftDemangler) fail()*swiftNode{
+	d.failed = true
+	return nil
+}
+
+func (d *oldSwiftDemangler) next()byte{
+	if d.pos >= len(d.text){
+		d.failed = true
+		return 0
+	}
+	d.pos++
+	return d.text[d.pos-1]
+}
+
+func (d *oldSwiftDemangler) peek()byte{
+	if d.pos < 0 || d.pos >= len(d.text){
+		return 0
+	}
+	return d.text[d.pos]
+}
+
+func (d *oldSwiftDemangler) peekString(n int)string{
+	if d.pos + n > len(d.text){
+		return ""
+	}
+	return d.text[d.pos : d.pos+n]
+}
+
+func (d *oldSwiftDemangler) nextIf(c byte)bool{
+	if d.peek() != c || 0 == c{
+		return false
+	}
+	d.pos++
+	return true
+}
+
+func (d *oldSwiftDemangler) natural()int{
+	if !isDigit(d.peek()){
+		return -1
+	}
+	n := 0
+	for isDigit(d.peek()){
+		n = n*10 + int(d.next() - '0')
+		if n > len(d.text){
+			return -1
+		}
+	}
+	return n
+}
+
+//<index> ::= _ | <natural> _ with _ being 0
+func (d *oldSwiftDemangler) index()int{
+	if d.nextIf('_'){
+		return 0
+	}
+	if n := d.natural(); n >= 0 && d.nextIf('_'){
+		return n + 1
+	}
+	d.failed = true
+	return -1
+}
+
+func (d *oldSwiftDemangler) addSubstitution(node *swiftNode){
+	if nil != node{
+		d.substitutions = append(d.substitutions, node)
+	}
+}
+
+//<identifier> ::= <natural> <chars> | X <punycode> | o <fixity> <operator chars>
+func (d *oldSwiftDemangler) identifier()*swiftNode{
+	punycoded := d.nextIf('X')
+	kind := swiftIdentifier
+	if d.nextIf('o'){
+		kinds := map[byte]swiftKind{'i': swiftInfixOperator, 'p': swiftPrefixOperator, 'P': swiftPostfixOperator}
+		var ok bool
+		if kind, ok = kinds[d.next()]; !ok{
+			return d.fail()
+		}
+	}
+	length := d.natural()
+	if length <= 0 || d.pos + length > len(d.text){
+		return d.fail()
+	}
+	text := d.text[d.pos : d.pos+length]
+	d.pos += length
+	if punycoded{
+		decoded, ok := decodeSwiftPunycode(text)
+		if !ok{
+			return d.fail()
+		}
+		text = decoded
+	}
+	if swiftIdentifier != kind{
+		//operators are mangled with letters standing for the operator characters
+		const characters = "& @/= >    <*!|+?%-~   ^ ."
+		var operator strings.Builder
+		for i := 0; i < len(text); i++{
+			c := text[i]
+			switch{
+			case c >= 0x80:
+				operator.WriteByte(c)
+			case isLower(c) && ' ' != characters[c - 'a']:
+				operator.WriteByte(characters[c - 'a'])
+			default:
+				return d.fail()
+			}
+		}
+		text = operator.String()
+	}
+	return &swiftNode{kind: kind, text: text}
+}
+
+//<decl-name> ::= <identifier> | L <index> <identifier> | P <discriminator> <identifier>
+func (d *oldSwiftDemangler) declName()*swiftNode{
+	if d.nextIf('L'){
+		index := d.index()
+		return newSwiftNode(swiftLocalDeclName, &swiftNode{kind: swiftNumber, index: index}, d.identifier())
+	}
+	if d.nextIf('P'){
+		discriminator := d.identifier()
+		return newSwiftNode(swiftPrivateDeclName, discriminator, d.identifier())
+	}
+	return d.identifier()
+}
+
+func (d *oldSwiftDemangler) module()*swiftNode{
+	if d.nextIf('s'){
+		return &swiftNode{kind: swiftModule, text: "Swift"}
+	}
+	if d.nextIf('S'){
+		module := d.substitution()
+		if nil == module || swiftModule != module.kind{
+			return d.fail()
+		}
+		return module
+	}
+	identifier := d.identifier()
+	if nil == identifier{
+		return nil
+	}
+	module := &swiftNode{kind: swiftModule, text: identifier.text}
+	d.addSubstitution(module)
+	return module
+}
+
+//Follows an S: a back reference, a known module or a standard library type.
+func (d *oldSwiftDemangler) substitution()*swiftNode{
+	if d.nextIf('o'){
+		return &swiftNode{kind: swiftModule, text: "__ObjC"}
+	}
+	if d.nextIf('C'){
+		return &swiftNode{kind: swiftModule, text: "__C"}
+	}
+	if d.nextIf('s'){
+		return &swiftNode{kind: swiftModule, text: "Swift"}
+	}
+	standard := map[byte]struct{kind swiftKind; name string}{
+		'a': {swiftStructure, "Array"},
+		'b': {swiftStructure, "Bool"},
+		'c': {swiftStructure, "UnicodeScalar"},
+		'd': {swiftStructure, "Double"},
+		'f': {swiftStructure, "Float"},
+		'i': {swiftStructure, "Int"},
+		'V': {swiftStructure, "UnsafeRawPointer"},
+		'v': {swiftStructure, "UnsafeMutableRawPointer"},
+		'P': {swiftStructure, "UnsafePointer"},
+		'p': {swiftStructure, "UnsafeMutablePointer"},
+		'q': {swiftEnum, "Optional"},
+		'Q': {swiftEnum, "ImplicitlyUnwrappedOptional"},
+		'R': {swiftStructure, "UnsafeBufferPointer"},
+		'r': {swiftStructure, "UnsafeMutableBufferPointer"},
+		'S': {swiftStructure, "String"},
+		'u': {swiftStructure, "UInt"},
+	}
+	if known, ok := standard[d.peek()]; ok{
+		d.pos++
+		return swiftStandardType(known.kind, known.name).children[0]
+	}
+	index := d.index()
+	if index < 0 || index >= len(d.substitutions){
+		return d.fail()
+	}
+	return d.substitutions[index]
+}
+
+//<context> ::= <module> | <entity> | E <module> <context> | e <module> <generic-signature> <context>
+func (d *oldSwiftDemangler) context()*swiftNode{
+	switch d.peek(){
+	case 'E', 'e':
+		hasSignature := 'e' == d.next()
+		module := d.module()
+		var signature *swiftNode
+		if hasSignature{
+			signature = d.genericSignature()
+		}
+		extended := d.context()
+		extension := newSwiftNode(swiftExtension, module, extended)
+		if nil != extension && nil != signature{
+			extension.children = append(extension.children, signature)
+		}
+		return extension
+	case 'S':
+		d.pos++
+		return d.substitution()
+	case 'F', 'I', 'v', 'P', 'Z', 'C', 'V', 'O':
+		return d.entity()
+	}
+	return d.module()
+}
+
+//<nominal-type> ::= C|V|O|P <context> <decl-name>, added to the substitutions
+func (d *oldSwiftDemangler) nominalType()*swiftNode{
+	kinds := map[byte]swiftKind{'C': swiftClass, 'V': swiftStructure, 'O': swiftEnum, 'P': swiftProtocol}
+	kind, ok := kinds[d.next()]
+	if !ok{
+		return d.fail()
+	}
+	context := d.context()
+	nominal := newSwiftNode(kind, context, d.declName())
+	d.addSubstitution(nominal)
+	return nominal
+}
+
+func (d *oldSwiftDemangler) protocolName()*swiftNode{
+	if d.nextIf('S'){
+		protocol := d.substitution()
+		if nil == protocol || swiftProtocol != protocol.kind{
+			return d.fail()
+		}
+		return swiftTypeOf(protocol)
+	}
+	context := d.context()
+	protocol := newSwiftNode(swiftProtocol, context, d.declName())
+	d.addSubstitution(protocol)
+	return swiftTypeOf(protocol)
+}
+
+//<entity> ::= Z? <entity-kind> <context> <entity-name> | <nominal-type>
+func (d *oldSwiftDemangler) entity()*swiftNode{
+	static := d.nextIf('Z')
+	var kind swiftKind
+	switch d.next(){
+	case 'F':
+		kind = swiftFunction
+	case 'v':
+		kind = swiftVariable
+	case 'I':
+		kind = swiftInitializer
+	case 'i':
+		kind = swiftSubscript
+	default:
+		if d.failed || static{
+			return d.fail()
+		}
+		d.pos--
+		return d.nominalType()
+	}
+
+	context := d.context()
+	if nil == context{
+		return nil
+	}
+	var entity *swiftNode
+	c := d.next()
+	switch{
+	case 'D' == c || 'd' == c || 'e' == c || 'E' == c:
+		kinds := map[byte]swiftKind{'D': swiftDeallocator, 'd': swiftDestructor, 'e': swiftIVarInitializer, 'E': swiftIVarDestroyer}
+		entity = newSwiftNode(kinds[c], context)
+	case 'C' == c || 'c' == c:
+		kinds := map[byte]swiftKind{'C': swiftAllocator, 'c': swiftConstructor}
+		entity = newSwiftNode(kinds[c], context, d.typ())
+	case 'U' == c || 'u' == c:
+		kinds := map[byte]swiftKind{'U': swiftExplicitClosure, 'u': swiftImplicitClosure}
+		index := d.index()
+		entity = newSwiftNode(kinds[c], context, &swiftNode{kind: swiftNumber, index: index}, d.typ())
+	case swiftInitializer == kind && 'A' == c:
+		entity = newSwiftNode(swiftDefaultArgument, context, &swiftNode{kind: swiftNumber, index: d.index()})
+	case swiftInitializer == kind && 'i' == c:
+		entity = newSwiftNode(swiftInitializer, newSwiftNode(swiftVariable, context, d.declName()))
+	case strings.IndexByte("gGsmwWal", c) >= 0:
+		entity = d.accessor(c, context)
+	default:
+		if d.failed{
+			return nil
+		}
+		d.pos--
+		entity = newSwiftNode(kind, context, d.declName(), d.typ())
+	}
+	if nil == entity{
+		return d.fail()
+	}
+	if static{
+		return newSwiftNode(swiftStatic, entity)
+	}
+	return entity
+}
+
+func (d *oldSwiftDemangler) accessor(c byte, context *swiftNode)*swiftNode{
+	name := map[byte]string{'g': "getter", 'G': "getter", 's': "setter", 'm': "materializeForSet",
+		'w': "willset", 'W': "didset"}[c]
+	switch c{
+	case 'a':
+		name = map[byte]string{'O': "owningMutableAddressor", 'o': "nativeOwningMutableAddressor",
+			'p': "nativePinningMutableAddressor", 'u': "unsafeMutableAddressor"}[d.next()]
+	case 'l':
+		name = map[byte]string{'O': "owningAddressor", 'o': "nativeOwningAddressor",
+			'p': "nativePinningAddressor", 'u': "unsafeAddressor"}[d.next()]
+	}
+	if "" == name{
+		return d.fail()
+	}
+	declName := d.declName()
+	typ := d.typ()
+	if nil == declName || nil == typ{
+		return nil
+	}
+	storage := newSwiftNode(swiftVariable, context, declName, typ)
+	if swiftIdentifier == declName.kind && "subscript" == declName.text{
+		storage = newSwiftNode(swiftSubscript, context, typ)
+	}
+	return &swiftNode{kind: swiftAccessor, text: name, children: []*swiftNode{storage}}
+}
+
+func (d *oldSwiftDemangler) typ()*swiftNode{
+	if d.failed{
+		return nil
+	}
+	switch c := d.next(); c{
+	case 'B':
+		return d.builtinType()
+	case 'a':
+		context := d.context()
+		alias := newSwiftNode(swiftTypeAlias, context, d.declName())
+		d.addSubstitution(alias)
+		return swiftTypeOf(alias)
+	case 'b':
+		return d.functionType("@convention(block) ")
+	case 'c':
+		return d.functionType("@convention(c) ")
+	case 'F', 'f':
+		return d.functionType("")
+	case 'K':
+		return d.functionType("@autoclosure ")
+	case 'D':
+		return swiftTypeOf(newSwiftNode(swiftDynamicSelf, d.typ()))
+	case 'G':
+		nominal := d.typ()
+		list := &swiftNode{kind: swiftTypeList}
+		for !d.nextIf('_'){
+			argument := d.typ()
+			if nil == argument{
+				return nil
+			}
+			list.children = append(list.children, argument)
+		}
+		return swiftTypeOf(newSwiftNode(swiftBoundGeneric, nominal, list))
+	case 'M':
+		return swiftTypeOf(newSwiftNode(swiftMetatype, d.typ()))
+	case 'P':
+		if d.nextIf('M'){
+			return swiftTypeOf(newSwiftNode(swiftExistentialMetatype, d.typ()))
+		}
+		list := &swiftNode{kind: swiftTypeList}
+		for !d.nextIf('_'){
+			protocol := d.protocolName()
+			if nil == protocol{
+				return nil
+			}
+			list.children = append(list.children, protocol)
+		}
+		return swiftTypeOf(newSwiftNode(swiftProtocolList, list))
+	case 'Q':
+		return swiftTypeOf(d.genericParameter())
+	case 'q':
+		return swiftTypeOf(d.genericParameter())
+	case 'x':
+		return swiftTypeOf(&swiftNode{kind: swiftGenericParameter})
+	case 'w':
+		base := swiftTypeOf(d.genericParameter())
+		name := d.identifier()
+		if nil == name{
+			return nil
+		}
+		return swiftTypeOf(newSwiftNode(swiftDependentMemberType, base, &swiftNode{kind: swiftAssociatedTypeRef, text: name.text}))
+	case 'R':
+		return d.typePrefix("inout ")
+	case 'S':
+		substitution := d.substitution()
+		if nil == substitution{
+			return nil
+		}
+		if swiftType == substitution.kind{
+			return substitution
+		}
+		return swiftTypeOf(substitution)
+	case 'T', 't':
+		return d.tupleType('t' == c)
+	case 'u':
+		signature := d.genericSignature()
+		return swiftTypeOf(newSwiftNode(swiftDependentGenericType, signature, d.typ()))
+	case 'C', 'V', 'O':
+		d.pos--
+		return swiftTypeOf(d.nominalType())
+	case 'X':
+		switch d.next(){
+		case 'o':
+			return d.typePrefix("unowned ")
+		case 'u':
+			return d.typePrefix("unowned(unsafe) ")
+		case 'w':
+			return d.typePrefix("weak ")
+		case 'M':
+			representation := map[byte]string{'t': "@thin ", 'T': "@thick ", 'o': "@objc_metatype "}[d.next()]
+			metatype := newSwiftNode(swiftMetatype, d.typ())
+			if nil == metatype || "" == representation{
+				return d.fail()
+			}
+			metatype.text = representation
+			return swiftTypeOf(metatype)
+		}
+	}
+	return d.fail()
+}
+
+func (d *oldSwiftDemangler) typePrefix(prefix string)*swiftNode{
+	typ := d.typ()
+	if nil == typ{
+		return nil
+	}
+	return swiftTypeOf(&swiftNode{kind: swiftTypePrefix, text: prefix, children: []*swiftNode{typ.children[0]}})
+}
+
+//<function-type> ::= z? <argument tuple> <result>, z marking throwing functions
+func (d *oldSwiftDemangler) functionType(prefix string)*swiftNode{
+	function := &swiftNode{kind: swiftFunctionType, text: prefix}
+	if d.nextIf('z'){
+		function.children = append(function.children, &swiftNode{kind: swiftThrows})
+	}
+	parameters := newSwiftNode(swiftArgumentTuple, d.typ())
+	result := newSwiftNode(swiftReturnType, d.typ())
+	if nil == parameters || nil == result{
+		return nil
+	}
+	function.children = append(function.children, parameters, result)
+	return swiftTypeOf(function)
+}
+
+//T (<identifier>? <type>)* _ and t for a tuple with a variadic last element
+func (d *oldSwiftDemangler) tupleType(variadic bool)*swiftNode{
+	tuple := &swiftNode{kind: swiftTuple}
+	for !d.nextIf('_'){
+		element := &swiftNode{kind: swiftTupleElement}
+		if isDigit(d.peek()) || 'X' == d.peek(){
+			label := d.identifier()
+			if nil == label{
+				return nil
+			}
+			element.children = append(element.children, &swiftNode{kind: swiftTupleElementName, text: label.text})
+		}
+		typ := d.typ()
+		if nil == typ{
+			return nil
+		}
+		element.children = append(element.children, typ)
+		tuple.children = append(tuple.children, element)
+	}
+	if variadic && 0 != len(tuple.children){
+		last := tuple.children[len(tuple.children)-1]
+		last.children = append([]*swiftNode{{kind: swiftVariadicMarker}}, last.children...)
+	}
+	return swiftTypeOf(tuple)
+}
+
+//<generic-param> ::= x | <index> | d <index> <index>, after Q or q
+func (d *oldSwiftDemangler) genericParameter()*swiftNode{
+	if d.nextIf('x'){
+		return &swiftNode{kind: swiftGenericParameter}
+	}
+	if d.nextIf('d'){
+		depth := d.index() + 1
+		index := d.index()
+		if d.failed{
+			return nil
+		}
+		return &swiftNode{kind: swiftGenericParameter, depth: depth, index: index}
+	}
+	index := d.index()
+	if d.failed{
+		return nil
+	}
+	return &swiftNode{kind: swiftGenericParameter, index: index + 1}
+}
+
+//<generic-signature> ::= <count>* (R <requirement>*)? r, a count being z for none or an index
+func (d *oldSwiftDemangler) genericSignature()*swiftNode{
+	signature := &swiftNode{kind: swiftGenericSignature}
+	for 'R' != d.peek() && 'r' != d.peek(){
+		count := 0
+		if !d.nextIf('z'){
+			if count = d.index() + 1; d.failed{
+				return nil
+			}
+		}
+		signature.children = append(signature.children, &swiftNode{kind: swiftParameterCount, index: count})
+	}
+	if 0 == len(signature.children){
+		signature.children = append(signature.children, &swiftNode{kind: swiftParameterCount, index: 1})
+	}
+	if d.nextIf('R'){
+		for !d.nextIf('r'){
+			c := d.next()
+			constrained := d.typ()
+			var requirement *swiftNode
+			switch c{
+			case 'P', 'p':
+				requirement = newSwiftNode(swiftRequirement, constrained, d.protocolName())
+				if nil != requirement{
+					requirement.text = ": "
+				}
+			case 'C':
+				requirement = newSwiftNode(swiftRequirement, constrained, d.typ())
+				if nil != requirement{
+					requirement.text = ": "
+				}
+			case 'E':
+				requirement = newSwiftNode(swiftRequirement, constrained, d.typ())
+				if nil != requirement{
+					requirement.text = " == "
+				}
+			}
+			if nil == requirement{
+				return d.fail()
+			}
+			signature.children = append(signature.children, requirement)
+		}
+	} else if !d.nextIf('r'){
+		return d.fail()
+	}
+	return signature
+}
+
+func (d *oldSwiftDemangler) builtinType()*swiftNode{
+	var name string
+	switch c := d.next(); c{
+	case 'f', 'i':
+		size := d.index() - 1
+		if size <= 0{
+			return d.fail()
+		}
+		if 'f' == c{
+			name = fmt.Sprintf("Builtin.FPIEEE%d", size)
+		} else {
+			name = fmt.Sprintf("Builtin.Int%d", size)
+		}
+	default:
+		name = map[byte]string{'O': "Builtin.UnknownObject", 'o': "Builtin.NativeObject", 'p': "Builtin.RawPointer",
+			'w': "Builtin.Word", 'b': "Builtin.BridgeObject", 't': "Builtin.SILToken", 'B': "Builtin.UnsafeValueBuffer"}[c]
+		if "" == name{
+			return d.fail()
+		}
+	}
+	return swiftTypeOf(&swiftNode{kind: swiftBuiltinType, text: name})
+}
+
+//<protocol-conformance> ::= (u <generic-signature>)? <type> <protocol> <module>
+func (d *oldSwiftDemangler) protocolConformance()*swiftNode{
+	var signature *swiftNode
+	if d.nextIf('u'){
+		signature = d.genericSignature()
+	}
+	typ := d.typ()
+	if nil != signature{
+		typ = swiftTypeOf(newSwiftNode(swiftDependentGenericType, signature, typ))
+	}
+	protocol := d.protocolName()
+	return newSwiftNode(swiftProtocolConformance, typ, protocol, d.module())
+}
+
+//M<kind> <type>: type metadata and descriptors
+func (d *oldSwiftDemangler) metadata()*swiftNode{
+	c := d.next()
+	switch c{
+	case 'd', 'i':
+		return swiftDescribe("type metadata for %s", d.typ())
+	case 'p':
+		return swiftDescribe("protocol descriptor for %s", d.protocolName())
+	case 'P':
+		d.nextIf('d')
+		return swiftDescribe("generic type metadata pattern for %s", d.typ())
+	}
+	format := map[byte]string{
+		'a': "type metadata accessor for %s",
+		'f': "full type metadata for %s",
+		'm': "metaclass for %s",
+		'n': "nominal type descriptor for %s",
+		'L': "lazy cache variable for type metadata for %s",
+		'o': "class metadata base offset for %s",
+	}[c]
+	if "" == format{
+		return d.fail()
+	}
+	return swiftDescribe(format, d.typ())
+}
+
+//W<kind>: witness tables and field offsets
+func (d *oldSwiftDemangler) witness()*swiftNode{
+	switch d.next(){
+	case 'V':
+		return swiftDescribe("value witness table for %s", d.typ())
+	case 'P':
+		return swiftDescribe("protocol witness table for %s", d.protocolConformance())
+	case 'a':
+		return swiftDescribe("protocol witness table accessor for %s", d.protocolConformance())
+	case 'o':
+		return swiftDescribe("witness table offset for %s", d.entity())
+	case 'v':
+		format := map[byte]string{'d': "direct field offset for %s", 'i': "indirect field offset for %s"}[d.next()]
+		if "" == format{
+			return d.fail()
+		}
+		return swiftDescribe(format, d.entity())
+	}
+	return d.fail()
+}
@@ -0,0 +1,587 @@
+package demangle
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//The Swift demanglers build the same tree as swift-demangle (a subset of its node kinds) and print it
+//the way swift-demangle does by default: "main.Foo.bar(x: Swift.Int) -> ()".
+
+type swiftKind int
+
+const (
+	swiftGlobal swiftKind = iota
+	swiftModule
+	swiftIdentifier
+	swiftType							//wraps every type
+	swiftClass							//children: context, name
+	swiftStructure
+	swiftEnum
+	swiftProtocol
+	swiftTypeAlias
+	swiftSymbolicReference				//a type named through a reference in reflection metadata, text
+	swiftBoundGeneric					//children: Type, TypeList
+	swiftTypeList
+	swiftTuple
+	swiftTupleElement					//children: [VariadicMarker] [TupleElementName] Type
+	swiftTupleElementName
+	swiftVariadicMarker
+	swiftEmptyList
+	swiftFirstElementMarker
+	swiftFunctionType					//text is a prefix such as "@convention(c) "
+	swiftArgumentTuple
+	swiftReturnType
+	swiftThrows
+	swiftAsync
+	swiftSendable
+	swiftLabelList
+	swiftMetatype						//text is the representation, "@thick "
+	swiftExistentialMetatype
+	swiftTypePrefix						//text followed by the type, "inout ", "weak "...
+	swiftDynamicSelf
+	swiftProtocolList					//children: TypeList
+	swiftProtocolListWithAnyObject
+	swiftBuiltinType
+	swiftGenericParameter				//depth and index
+	swiftDependentMemberType			//children: base Type, AssociatedTypeRef
+	swiftAssociatedTypeRef
+	swiftGenericSignature				//ParameterCount children followed by requirements
+	swiftParameterCount
+	swiftRequirement					//children: constrained Type, constraint, text is ": " or " == "
+	swiftLayoutRequirement				//children: constrained Type, text the layout
+	swiftDependentGenericType			//children: GenericSignature, Type
+	swiftExtension						//children: Module, extended type, [GenericSignature]
+	swiftLocalDeclName					//children: Number, name
+	swiftPrivateDeclName				//children: discriminator Identifier, [name]
+	swiftRelatedEntityDeclName			//children: kind Identifier, name
+	swiftInfixOperator
+	swiftPrefixOperator
+	swiftPostfixOperator
+	swiftNumber
+	swiftFunction						//children: context, name, [LabelList], Type
+	swiftVariable						//children: context, name, Type
+	swiftSubscript						//children: context, [LabelList], Type, [PrivateDeclName]
+	swiftAllocator						//children: context, [LabelList], Type
+	swiftConstructor
+	swiftDestructor						//children: context
+	swiftDeallocator
+	swiftIVarInitializer
+	swiftIVarDestroyer
+	swiftInitializer					//variable initializer expression
+	swiftExplicitClosure				//children: context, Number, Type
+	swiftImplicitClosure
+	swiftDefaultArgument				//children: context, Number
+	swiftPropertyWrapperInitializer
+	swiftAccessor						//children: Variable or Subscript, text is the accessor
+	swiftStatic
+	swiftProtocolConformance			//children: Type, protocol Type, Module
+	swiftDescription					//text is a format with a %s per child
+	swiftAttribute						//text is printed before the entity, "@objc "
+	swiftTypeMangling
+	swiftSuffix
+)
+
+//Types named by the standard substitutions S<letter>
+var swiftStandardTypes = map[byte]struct{kind swiftKind; name string}{
+	'A': {swiftStructure, "AutoreleasingUnsafeMutablePointer"},
+	'a': {swiftStructure, "Array"},
+	'b': {swiftStructure, "Bool"},
+	'D': {swiftStructure, "Dictionary"},
+	'd': {swiftStructure, "Double"},
+	'f': {swiftStructure, "Float"},
+	'h': {swiftStructure, "Set"},
+	'I': {swiftStructure, "DefaultIndices"},
+	'i': {swiftStructure, "Int"},
+	'J': {swiftStructure, "Character"},
+	'N': {swiftStructure, "ClosedRange"},
+	'n': {swiftStructure, "Range"},
+	'O': {swiftStructure, "ObjectIdentifier"},
+	'P': {swiftStructure, "UnsafePointer"},
+	'p': {swiftStructure, "UnsafeMutablePointer"},
+	'R': {swiftStructure, "UnsafeBufferPointer"},
+	'r': {swiftStructure, "UnsafeMutableBufferPointer"},
+	'S': {swiftStructure, "String"},
+	's': {swiftStructure, "Substring"},
+	'u': {swiftStructure, "UInt"},
+	'V': {swiftStructure, "UnsafeRawPointer"},
+	'v': {swiftStructure, "UnsafeMutableRawPointer"},
+	'W': {swiftStructure, "UnsafeRawBufferPointer"},
+	'w': {swiftStructure, "UnsafeMutableRawBufferPointer"},
+	'q': {swiftEnum, "Optional"},
+	'B': {swiftProtocol, "BinaryFloatingPoint"},
+	'E': {swiftProtocol, "Encodable"},
+	'e': {swiftProtocol, "Decodable"},
+	'F': {swiftProtocol, "FloatingPoint"},
+	'G': {swiftProtocol, "RandomNumberGenerator"},
+	'H': {swiftProtocol, "Hashable"},
+	'j': {swiftProtocol, "Numeric"},
+	'K': {swiftProtocol, "BidirectionalCollection"},
+	'k': {swiftProtocol, "RandomAccessCollection"},
+	'L': {swiftProtocol, "Comparable"},
+	'l': {swiftProtocol, "Collection"},
+	'M': {swiftProtocol, "MutableCollection"},
+	'm': {swiftProtocol, "RangeReplaceableCollection"},
+	'Q': {swiftProtocol, "Equatable"},
+	'T': {swiftProtocol, "Sequence"},
+	't': {swiftProtocol, "IteratorProtocol"},
+	'U': {swiftProtocol, "UnsignedInteger"},
+	'X': {swiftProtocol, "RangeExpression"},
+	'x': {swiftProtocol, "Strideable"},
+	'Y': {swiftProtocol, "RawRepresentable"},
+	'y': {swiftProtocol, "StringProtocol"},
+	'Z': {swiftProtocol, "SignedInteger"},
+	'z': {swiftProtocol, "BinaryInteger"},
+}
+
+//Concurrency types named by Sc<letter>
+var swiftConcurrencyTypes = map[byte]struct{kind swiftKind; name string}{
+	'A': {swiftProtocol, "Actor"},
+	'C': {swiftStructure, "CheckedContinuation"},
+	'c': {swiftStructure, "UnsafeContinuation"},
+	'E': {swiftStructure, "CancellationError"},
+	'e': {swiftStructure, "UnownedSerialExecutor"},
+	'F': {swiftProtocol, "Executor"},
+	'f': {swiftProtocol, "SerialExecutor"},
+	'G': {swiftStructure, "TaskGroup"},
+	'g': {swiftStructure, "ThrowingTaskGroup"},
+	'I': {swiftProtocol, "AsyncIteratorProtocol"},
+	'i': {swiftProtocol, "AsyncSequence"},
+	'J': {swiftStructure, "UnownedJob"},
+	'M': {swiftClass, "MainActor"},
+	'P': {swiftStructure, "TaskPriority"},
+	'S': {swiftStructure, "AsyncStream"},
+	's': {swiftStructure, "AsyncThrowingStream"},
+	'T': {swiftStructure, "Task"},
+	't': {swiftStructure, "UnsafeCurrentTask"},
+}
+
+type swiftNode struct{
+	kind swiftKind
+	text string
+	depth int
+	index int
+	children []*swiftNode
+}
+
+type swiftPrinter struct{
+	sugar bool			//print [T], [K : V] and T? for arrays, dictionaries and optionals
+}
+
+/*
+	//////////////////////////////////////// PRIVATE METHODS ////////////////////////////////////////
+*/
+
+//Builds a node, or returns nil if any child is missing so failures propagate up.
+func newSwiftNode(kind swiftKind, children ...*swiftNode)*swiftNode{
+	for _, child := range children{
+		if nil == child{
+			return nil
+		}
+	}
+	return &swiftNode{kind: kind, children: children}
+}
+
+func swiftTypeOf(child *swiftNode)*swiftNode{
+	return newSwiftNode(swiftType, child)
+}
+
+func swiftStandardType(kind swiftKind, name string)*swiftNode{
+	return swiftTypeOf(newSwiftNode(kind, &swiftNode{kind: swiftModule, text: "Swift"}, &swiftNode{kind: swiftIdentifier, text: name}))
+}
+
+func swiftDescribe(format string, children ...*swiftNode)*swiftNode{
+	node := newSwiftNode(swiftDescription, children...)
+	if nil != node{
+		node.text = format
+	}
+	return node
+}
+
+func (n *swiftNode) child(kind swiftKind)*swiftNode{
+	for _, child := range n.children{
+		if kind == child.kind{
+			return child
+		}
+	}
+	return nil
+}
+
+//Looks through the Type wrapper.
+func (n *swiftNode) unwrapped()*swiftNode{
+	for nil != n && swiftType == n.kind && 1 == len(n.children){
+		n = n.children[0]
+	}
+	return n
+}
+
+//True for the markers and lists that are only ever part of a larger node, which the printer cannot
+//print on their own.
+func isSwiftFragment(kind swiftKind)bool{
+	switch kind{
+	case swiftTypeList, swiftTupleElement, swiftTupleElementName, swiftVariadicMarker, swiftEmptyList,
+		swiftFirstElementMarker, swiftArgumentTuple, swiftReturnType, swiftThrows, swiftAsync, swiftSendable,
+		swiftLabelList, swiftAssociatedTypeRef, swiftGenericSignature, swiftParameterCount, swiftRequirement,
+		swiftLayoutRequirement, swiftNumber, swiftAttribute:
+		return true
+	}
+	return false
+}
+
+func isSwiftNominal(kind swiftKind)bool{
+	switch kind{
+	case swiftClass, swiftStructure, swiftEnum, swiftProtocol, swiftTypeAlias:
+		return true
+	}
+	return false
+}
+
+func isSwiftDeclName(kind swiftKind)bool{
+	switch kind{
+	case swiftIdentifier, swiftLocalDeclName, swiftPrivateDeclName, swiftRelatedEntityDeclName,
+		swiftInfixOperator, swiftPrefixOperator, swiftPostfixOperator:
+		return true
+	}
+	return false
+}
+
+//Entities which can contain other declarations.
+func isSwiftContext(kind swiftKind)bool{
+	switch kind{
+	case swiftModule, swiftExtension, swiftFunction, swiftVariable, swiftSubscript, swiftAllocator,
+		swiftConstructor, swiftDestructor, swiftDeallocator, swiftIVarInitializer, swiftIVarDestroyer,
+		swiftInitializer, swiftExplicitClosure, swiftImplicitClosure, swiftDefaultArgument,
+		swiftPropertyWrapperInitializer, swiftAccessor, swiftStatic, swiftBoundGeneric,
+		swiftSymbolicReference:
+		return true
+	}
+	return isSwiftNominal(kind)
+}
+
+//Contexts printed as a dotted prefix. Declarations inside functions print as "x in context" instead.
+func isSwiftNamespace(n *swiftNode)bool{
+	n = n.unwrapped()
+	switch n.kind{
+	case swiftModule, swiftExtension, swiftBoundGeneric, swiftSymbolicReference:
+		return true
+	}
+	return isSwiftNominal(n.kind) && isSwiftNamespace(n.children[0])
+}
+
+func (pr swiftPrinter) print(n *swiftNode)string{
+	if nil == n{
+		return ""
+	}
+	switch n.kind{
+	case swiftGlobal:
+		var b strings.Builder
+		for _, child := range n.children{
+			b.WriteString(pr.print(child))
+		}
+		return b.String()
+	case swiftType, swiftTypeMangling:
+		return pr.print(n.children[0])
+	case swiftModule, swiftIdentifier, swiftTupleElementName, swiftSymbolicReference, swiftBuiltinType:
+		return n.text
+	case swiftClass, swiftStructure, swiftEnum, swiftProtocol, swiftTypeAlias:
+		return pr.entity(n, false)
+	case swiftBoundGeneric:
+		return pr.boundGeneric(n)
+	case swiftTypeList:
+		return pr.join(n.children, ", ")
+	case swiftTuple:
+		return "(" + pr.join(n.children, ", ") + ")"
+	case swiftTupleElement:
+		s := ""
+		if name := n.child(swiftTupleElementName); nil != name{
+			s = name.text + ": "
+		}
+		s += pr.print(n.child(swiftType))
+		if nil != n.child(swiftVariadicMarker){
+			s += "..."
+		}
+		return s
+	case swiftFunctionType:
+		return pr.functionType(n, nil)
+	case swiftMetatype:
+		return n.text + pr.print(n.children[0]) + ".Type"
+	case swiftExistentialMetatype:
+		return pr.print(n.children[0]) + ".Type"
+	case swiftTypePrefix:
+		return n.text + pr.print(n.children[0])
+	case swiftDynamicSelf:
+		return "Self"
+	case swiftProtocolList:
+		if 0 == len(n.children[0].children){
+			return "Any"
+		}
+		return pr.join(n.children[0].children, " & ")
+	case swiftProtocolListWithAnyObject:
+		if 0 == len(n.children[0].children[0].children){
+			return "AnyObject"
+		}
+		return pr.join(n.children[0].children[0].children, " & ") + " & AnyObject"
+	case swiftGenericParameter:
+		return swiftGenericParameterName(n.depth, n.index)
+	case swiftDependentMemberType:
+		return pr.print(n.children[0]) + "." + n.children[1].text
+	case swiftGenericSignature:
+		return pr.genericSignature(n)
+	case swiftRequirement:
+		return pr.print(n.children[0]) + n.text + pr.print(n.children[1])
+	case swiftLayoutRequirement:
+		return pr.print(n.children[0]) + ": " + n.text
+	case swiftDependentGenericType:
+		return pr.print(n.children[0]) + " " + pr.print(n.children[1])
+	case swiftExtension:
+		return "(extension in " + pr.print(n.children[0]) + "):" + pr.print(n.children[1])
+	case swiftLocalDeclName:
+		return pr.print(n.children[1]) + " #" + strconv.Itoa(n.children[0].index + 1)
+	case swiftPrivateDeclName:
+		if 1 == len(n.children){
+			return "(in " + n.children[0].text + ")"
+		}
+		return "(" + pr.print(n.children[1]) + " in " + n.children[0].text + ")"
+	case swiftRelatedEntityDeclName:
+		return "related decl '" + n.children[0].text + "' for " + pr.print(n.children[1])
+	case swiftInfixOperator:
+		return n.text + " infix"
+	case swiftPrefixOperator:
+		return n.text + " prefix"
+	case swiftPostfixOperator:
+		return n.text + " postfix"
+	case swiftNumber:
+		return strconv.Itoa(n.index)
+	case swiftAccessor:
+		return pr.accessor(n)
+	case swiftStatic:
+		return "static " + pr.print(n.children[0])
+	case swiftProtocolConformance:
+		return pr.print(n.children[0]) + " : " + pr.print(n.children[1]) + " in " + pr.print(n.children[2])
+	case swiftDescription:
+		arguments := make([]interface{}, len(n.children))
+		for i, child := range n.children{
+			arguments[i] = pr.print(child)
+		}
+		return fmt.Sprintf(n.text, arguments...)
+	case swiftAttribute:
+		return n.text
+	case swiftSuffix:
+		return " with unmangled suffix " + strconv.Quote(n.text)
+	}
+	return pr.entity(n, true)
+}
+
+func (pr swiftPrinter) join(nodes []*swiftNode, separator string)string{
+	parts := make([]string, len(nodes))
+	for i, node := range nodes{
+		parts[i] = pr.print(node)
+	}
+	return strings.Join(parts, separator)
+}
+
+//Prints a declaration, its context first and, if withType, its signature or type.
+func (pr swiftPrinter) entity(n *swiftNode, withType bool)string{
+	context := n.children[0]
+	name := ""
+	var labels, typ *swiftNode
+	for _, child := range n.children[1:]{
+		switch{
+		case swiftLabelList == child.kind:
+			labels = child
+		case swiftType == child.kind:
+			typ = child
+		case swiftNumber == child.kind:
+			name = strconv.Itoa(child.index + 1)
+		case isSwiftDeclName(child.kind) && "" == name:
+			name = pr.print(child)
+		}
+	}
+
+	switch n.kind{
+	case swiftAllocator:
+		name = "init"
+		if swiftClass == context.unwrapped().kind{
+			name = "__allocating_init"
+		}
+	case swiftConstructor:
+		name = "init"
+	case swiftDestructor:
+		name = "deinit"
+	case swiftDeallocator:
+		name = "deinit"
+		if swiftClass == context.unwrapped().kind{
+			name = "__deallocating_deinit"
+		}
+	case swiftIVarInitializer:
+		name = "__ivar_initializer"
+	case swiftIVarDestroyer:
+		name = "__ivar_destroyer"
+	case swiftInitializer:
+		name = "variable initialization expression"
+	case swiftExplicitClosure:
+		name = "closure #" + name
+	case swiftImplicitClosure:
+		name = "implicit closure #" + name
+	case swiftDefaultArgument:
+		name = "default argument " + strconv.Itoa(n.children[1].index)
+	case swiftPropertyWrapperInitializer:
+		name = "property wrapper backing initializer"
+	case swiftSubscript:
+		name = "subscript"
+	}
+
+	var s string
+	switch{
+	case isSwiftNamespace(context):
+		s = pr.print(context) + "." + name
+	case isSwiftNominal(context.unwrapped().kind):
+		s = "(" + pr.print(context) + ")." + name
+	default:
+		s = name + " in " + pr.print(context)
+	}
+	if !withType || nil == typ{
+		return s
+	}
+	switch n.kind{
+	case swiftExplicitClosure, swiftImplicitClosure:
+		return s
+	case swiftVariable:
+		return s + " : " + pr.print(typ)
+	}
+	return s + pr.signature(typ, labels)
+}
+
+//Prints what follows a function name: "<A where A: Swift.Equatable>(x: A) -> ()".
+func (pr swiftPrinter) signature(typ *swiftNode, labels *swiftNode)string{
+	inner := typ.unwrapped()
+	prefix := ""
+	if swiftDependentGenericType == inner.kind{
+		prefix = pr.print(inner.children[0])
+		inner = inner.children[1].unwrapped()
+	}
+	if swiftFunctionType != inner.kind{
+		return prefix + " : " + pr.print(inner)
+	}
+	return prefix + pr.functionType(inner, labels)
+}
+
+func (pr swiftPrinter) functionType(n *swiftNode, labels *swiftNode)string{
+	parameters := n.child(swiftArgumentTuple).children[0].unwrapped()
+	var elements []*swiftNode
+	if swiftTuple == parameters.kind{
+		elements = parameters.children
+	} else {
+		elements = []*swiftNode{parameters}
+	}
+
+	parts := make([]string, len(elements))
+	for i, element := range elements{
+		parts[i] = pr.print(element)
+		if nil == labels || i >= len(labels.children){
+			continue
+		}
+		if swiftTupleElement == element.kind{
+			element = element.child(swiftType)
+		}
+		label := "_"
+		if swiftIdentifier == labels.children[i].kind{
+			label = labels.children[i].text
+		}
+		parts[i] = label + ": " + pr.print(element)
+		if swiftTuple == parameters.kind && nil != parameters.children[i].child(swiftVariadicMarker){
+			parts[i] += "..."
+		}
+	}
+
+	s := n.text
+	if nil != n.child(swiftSendable){
+		s += "@Sendable "
+	}
+	s += "(" + strings.Join(parts, ", ") + ")"
+	if nil != n.child(swiftAsync){
+		s += " async"
+	}
+	if nil != n.child(swiftThrows){
+		s += " throws"
+	}
+	return s + " -> " + pr.print(n.child(swiftReturnType).children[0])
+}
+
+func (pr swiftPrinter) boundGeneric(n *swiftNode)string{
+	nominal := n.children[0].unwrapped()
+	arguments := n.children[1].children
+	if pr.sugar && isSwiftNominal(nominal.kind) && swiftModule == nominal.children[0].kind && "Swift" == nominal.children[0].text{
+		switch nominal.children[1].text{
+		case "Optional":
+			if 1 == len(arguments){
+				return pr.print(arguments[0]) + "?"
+			}
+		case "Array":
+			if 1 == len(arguments){
+				return "[" + pr.print(arguments[0]) + "]"
+			}
+		case "Dictionary":
+			if 2 == len(arguments){
+				return "[" + pr.print(arguments[0]) + " : " + pr.print(arguments[1]) + "]"
+			}
+		}
+	}
+	return pr.print(n.children[0]) + "<" + pr.join(arguments, ", ") + ">"
+}
+
+//"main.Foo.x.getter : Swift.Int" for variables, "main.Foo.subscript.getter : (Swift.Int) -> Swift.String"
+//for subscripts.
+func (pr swiftPrinter) accessor(n *swiftNode)string{
+	storage := n.children[0]
+	s := pr.entity(storage, false)
+	if swiftStatic == storage.kind{
+		s = "static " + pr.entity(storage.children[0], false)
+		storage = storage.children[0]
+	}
+	s += "." + n.text
+	typ := storage.child(swiftType)
+	if nil == typ{
+		return s
+	}
+	if swiftSubscript == storage.kind{
+		return s + " : " + strings.TrimPrefix(pr.signature(typ, storage.child(swiftLabelList)), " : ")
+	}
+	return s + " : " + pr.print(typ)
+}
+
+//"<A, B where A: Swift.Equatable>"
+func (pr swiftPrinter) genericSignature(n *swiftNode)string{
+	var parameters, requirements []string
+	for depth, child := range n.children{
+		if swiftParameterCount != child.kind{
+			requirements = append(requirements, pr.print(child))
+			continue
+		}
+		for index := 0; index < child.index; index++{
+			parameters = append(parameters, swiftGenericParameterName(depth, index))
+		}
+	}
+	s := "<" + strings.Join(parameters, ", ")
+	if 0 != len(requirements){
+		s += " where " + strings.Join(requirements, ", ")
+	}
+	return s + ">"
+}
+
+//Generic parameters are named A, B, ... Z, BA, ... with the depth appended when it is not 0.
+func swiftGenericParameterName(depth int, index int)string{
+	var name []byte
+	for{
+		name = append([]byte{byte('A' + index % 26)}, name...)
+		index /= 26
+		if 0 == index{
+			break
+		}
+	}
+	if 0 != depth{
+		return string(name) + strconv.Itoa(depth)
+	}
+	return string(name)
+}
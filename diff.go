@@ -0,0 +1,54 @@
+package main
+
+import (
+	"cycle1/demangle"
+	"cycle1/machoHeader"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+//diff [-demangle] [-json] <old> <new>
+//Exits with 1 if the files differ, like diff.
+func diff(args []string){
+	asJSON, demangled := false, false
+	for 0 != len(args) && ("-json" == args[0] || "-demangle" == args[0]){
+		asJSON = asJSON || "-json" == args[0]
+		demangled = demangled || "-demangle" == args[0]
+		args = args[1:]
+	}
+	if 2 != len(args){
+		usage()
+	}
+
+	differences := machoHeader.Diff(machoHeader.LoadStruct(args[0]), machoHeader.LoadStruct(args[1]))
+	if demangled{
+		for i := range differences{
+			if "symbol" == differences[i].Area || "export" == differences[i].Area{
+				differences[i].Name = demangle.Demangle(differences[i].Name)
+			}
+		}
+	}
+
+	if asJSON{
+		if nil == differences{
+			differences = []machoHeader.Difference{}
+		}
+		out, err := json.MarshalIndent(differences, "", "  ")
+		if nil != err{
+			fmt.Fprintln(os.Stderr, "diff:", err)
+			os.Exit(2)
+		}
+		fmt.Println(string(out))
+	} else {
+		fmt.Println("---", args[0])
+		fmt.Println("+++", args[1])
+		for _, difference := range differences{
+			fmt.Println(difference)
+		}
+	}
+
+	if 0 != len(differences){
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"cycle1/machoHeader"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+//disassemble [-demangle] [-json] <file> [<segment>,<section> | <function> | <address>]
+//Disassembles __TEXT,__text by default, or the given section, or the function with the given name or
+//containing the given address. The function may be named by its demangled form either way.
+func disassemble(args []string){
+	asJSON, demangled := false, false
+	for 0 != len(args) && ("-json" == args[0] || "-demangle" == args[0]){
+		asJSON = asJSON || "-json" == args[0]
+		demangled = demangled || "-demangle" == args[0]
+		args = args[1:]
+	}
+	if 1 != len(args) && 2 != len(args){
+		usage()
+	}
+
+	m := machoHeader.LoadStruct(args[0])
+	var instructions []machoHeader.Instruction
+	var err error
+	switch{
+	case 1 == len(args):
+		instructions, err = m.DisassembleSection("__TEXT", "__text")
+	case strings.Contains(args[1], ","):
+		names := strings.SplitN(args[1], ",", 2)
+		instructions, err = m.DisassembleSection(names[0], names[1])
+	default:
+		instructions, err = m.DisassembleFunction(args[1])
+	}
+	if nil != err{
+		fmt.Fprintln(os.Stderr, "disassemble:", err)
+		os.Exit(1)
+	}
+	if demangled{
+		for i := range instructions{
+			instructions[i] = instructions[i].Demangled()
+		}
+	}
+
+	if asJSON{
+		if nil == instructions{
+			instructions = []machoHeader.Instruction{}
+		}
+		out, err := json.MarshalIndent(instructions, "", "  ")
+		if nil != err{
+			fmt.Fprintln(os.Stderr, "disassemble:", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(out))
+		return
+	}
+	for _, instruction := range instructions{
+		if "" != instruction.Label{
+			fmt.Printf("%s:\n", instruction.Label)
+		}
+		fmt.Println(instruction)
+	}
+}
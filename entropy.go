@@ -0,0 +1,88 @@
+package main
+
+import (
+	"cycle1/machoHeader"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+//entropy [-json] [-window size] <file>...
+//Prints the entropy of every segment and section, the anomalies found and the suspiciousness score. Universal
+//binaries are reported one slice at a time. Exits with 1 if any file cannot be read.
+func entropy(args []string){
+	asJSON, window := false, 0
+	for 0 != len(args){
+		if "-json" == args[0]{
+			asJSON = true
+			args = args[1:]
+		} else if "-window" == args[0] && len(args) > 1{
+			size, err := strconv.Atoi(args[1])
+			if nil != err || size <= 0{
+				fmt.Fprintln(os.Stderr, "entropy: bad window size", args[1])
+				os.Exit(2)
+			}
+			window = size
+			args = args[2:]
+		} else {
+			break
+		}
+	}
+	if 0 == len(args){
+		usage()
+	}
+
+	var reports []machoHeader.EntropyReport
+	failed := false
+	for _, fileName := range args{
+		images, ok := loadImages("entropy", fileName)
+		failed = failed || !ok
+		for _, image := range images{
+			report := image.header.EntropyReport(window)
+			report.File = image.label
+			reports = append(reports, report)
+		}
+	}
+
+	if asJSON{
+		out, err := json.MarshalIndent(reports, "", "  ")
+		if nil != err{
+			fmt.Fprintln(os.Stderr, "entropy:", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(out))
+	} else {
+		printEntropyReports(reports)
+	}
+
+	if failed{
+		os.Exit(1)
+	}
+}
+
+func printEntropyReports(reports []machoHeader.EntropyReport){
+	for _, report := range reports{
+		fmt.Println(report.File)
+		for _, region := range report.Segments{
+			printEntropyRegion(region, "\t")
+			for _, section := range report.Sections{
+				if region.Name == section.Segment{
+					printEntropyRegion(section, "\t\t")
+				}
+			}
+		}
+		for _, anomaly := range report.Anomalies{
+			fmt.Println("\tANOMALY:", anomaly)
+		}
+		fmt.Printf("\tSuspiciousness: %d/100\n", report.Suspiciousness)
+	}
+}
+
+func printEntropyRegion(region machoHeader.EntropyRegion, indent string){
+	fmt.Printf("%s%-24s offset 0x%08x size 0x%08x entropy %.2f", indent, region.Name, region.Offset, region.Size, region.Entropy)
+	if len(region.Windows) > 1{
+		fmt.Printf(" (max %.2f at 0x%x)", region.MaxWindow, region.MaxWindowOffset)
+	}
+	fmt.Println()
+}
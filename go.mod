@@ -0,0 +1,5 @@
+module cycle1
+
+go 1.26.0
+
+require golang.org/x/arch v0.31.0
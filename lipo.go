@@ -0,0 +1,90 @@
+package main
+
+import (
+	"cycle1/machoHeader"
+	"fmt"
+	"os"
+)
+
+//lipo -info <file>
+//lipo -create <input>... -output <output>
+//lipo <input> -thin <arch> -output <output>
+//lipo <input> -remove <arch> -output <output>
+func lipo(args []string){
+	if len(args) < 2{
+		usage()
+	}
+
+	var out []byte
+	var err error
+
+	switch{
+	case "-info" == args[0]:
+		lipoInfo(args[1])
+		return
+	case "-create" == args[0] && len(args) >= 4 && "-output" == args[len(args)-2]:
+		var headers []machoHeader.FileHeader
+		for _, input := range args[1:len(args)-2]{
+			headers = append(headers, machoHeader.LoadStruct(input))
+		}
+		out, err = machoHeader.CreateFat(headers)
+	case 5 == len(args) && ("-thin" == args[1] || "-remove" == args[1]) && "-output" == args[3]:
+		fat := loadFat(args[0])
+		cpu, subCpu, parseErr := machoHeader.ParseArch(args[2])
+		if nil != parseErr{
+			fmt.Fprintln(os.Stderr, "lipo:", parseErr)
+			os.Exit(2)
+		}
+		if "-thin" == args[1]{
+			out, err = fat.Thin(cpu, subCpu)
+		} else {
+			out, err = fat.Remove(cpu, subCpu)
+		}
+	default:
+		usage()
+	}
+
+	if nil == err{
+		err = os.WriteFile(args[len(args)-1], out, 0755)
+	}
+	if nil != err{
+		fmt.Fprintln(os.Stderr, "lipo:", err)
+		os.Exit(1)
+	}
+}
+
+func lipoInfo(fileName string){
+	data, err := os.ReadFile(fileName)
+	if nil != err{
+		fmt.Fprintln(os.Stderr, "lipo:", err)
+		os.Exit(1)
+	}
+	if !machoHeader.IsFat(data){
+		myMachoFile := machoHeader.LoadBytes(data)
+		fmt.Println("Non-fat file:", fileName, "is architecture:", machoHeader.ArchName(myMachoFile.Header.Cpu, myMachoFile.Header.SubCpu))
+		return
+	}
+	fat := loadFat(fileName)
+	fmt.Print("Architectures in the fat file: ", fileName, " are:")
+	for _, arch := range fat.Arches{
+		fmt.Print(" ", machoHeader.ArchName(arch.Cpu, arch.SubCpu))
+	}
+	fmt.Println()
+	for _, arch := range fat.Arches{
+		fmt.Printf("\t%-10s offset 0x%x size 0x%x align 2^%d\n", machoHeader.ArchName(arch.Cpu, arch.SubCpu), arch.Offset, arch.Size, arch.Align)
+	}
+}
+
+func loadFat(fileName string)machoHeader.FatFile{
+	data, err := os.ReadFile(fileName)
+	if nil == err{
+		var fat machoHeader.FatFile
+		fat, err = machoHeader.LoadFat(data)
+		if nil == err{
+			return fat
+		}
+	}
+	fmt.Fprintln(os.Stderr, "lipo:", err)
+	os.Exit(1)
+	return machoHeader.FatFile{}
+}
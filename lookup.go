@@ -0,0 +1,62 @@
+package main
+
+import (
+	"cycle1/machoHeader"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+//lookup <file> va <address>  or  lookup <file> offset <offset>
+func lookup(args []string){
+	if 3 != len(args){
+		usage()
+	}
+
+	value, err := strconv.ParseUint(args[2], 0, 64)
+	if nil != err{
+		fmt.Fprintln(os.Stderr, "invalid value:", args[2])
+		os.Exit(2)
+	}
+
+	myMachoFile := machoHeader.LoadStruct(args[0])
+
+	var address uint64
+	switch args[1]{
+	case "va":
+		address = value
+		offset, err := myMachoFile.VAToOffset(address)
+		if nil != err && !errors.Is(err, machoHeader.ErrZeroFill){
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		} else if nil != err{
+			fmt.Println("File offset: none,", err)
+		} else {
+			fmt.Printf("File offset: 0x%x\n", offset)
+		}
+	case "offset":
+		address, err = myMachoFile.OffsetToVA(value)
+		if nil != err{
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Printf("VM address: 0x%x\n", address)
+	default:
+		usage()
+	}
+
+	segment, err := myMachoFile.SegmentForVA(address)
+	if nil != err{
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Segment: %s (0x%x-0x%x)\n", segment.SegmentName, segment.VmAddress, segment.VmAddress+segment.VmSize)
+
+	section, err := myMachoFile.SectionForVA(address)
+	if nil != err{
+		fmt.Println("Section: none")
+		return
+	}
+	fmt.Printf("Section: %s,%s (+0x%x)\n", section.SegmentName, section.SectionName, address-section.Address)
+}
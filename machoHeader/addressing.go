@@ -0,0 +1,119 @@
+package machoHeader
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrUnmapped = errors.New("address is not mapped by any segment")
+	ErrZeroFill = errors.New("address is in a zero fill region and has no file backing")
+)
+
+/*
+	//////////////////////////////////////// PUBLIC CLASS METHODS ////////////////////////////////////////
+*/
+
+//Returns the segment whose VM range contains addr. __PAGEZERO is returned like any other segment so callers
+//can tell a NULL-page address apart from one which is not mapped at all.
+func (m FileHeader) SegmentForVA(addr uint64)(*LoadCommand, error){
+	for i := range m.LoadCommands{
+		segment := &m.LoadCommands[i]
+		if LC_SEGMENT_64 != segment.Command{
+			continue
+		}
+		if addr >= segment.VmAddress && addr - segment.VmAddress < segment.VmSize{
+			return segment, nil
+		}
+	}
+	return nil, fmt.Errorf("0x%x: %w", addr, ErrUnmapped)
+}
+
+//Returns the section whose address range contains addr.
+func (m FileHeader) SectionForVA(addr uint64)(*SectionHeader, error){
+	segment, err := m.SegmentForVA(addr)
+	if nil != err{
+		return nil, err
+	}
+	for i := range segment.Sections{
+		section := &segment.Sections[i]
+		if addr >= section.Address && addr - section.Address < section.Size{
+			return section, nil
+		}
+	}
+	return nil, fmt.Errorf("0x%x: not inside any section of %s", addr, segment.SegmentName)
+}
+
+//Translates a virtual address into an offset in the file. Addresses in __PAGEZERO, in the zero filled tail of a
+//segment (VmSize larger than FileSize) or in a zero fill section return ErrZeroFill.
+func (m FileHeader) VAToOffset(addr uint64)(uint64, error){
+	segment, err := m.SegmentForVA(addr)
+	if nil != err{
+		return 0, err
+	}
+
+	delta := addr - segment.VmAddress
+	if delta >= segment.FileSize{
+		return 0, fmt.Errorf("0x%x in %s: %w", addr, segment.SegmentName, ErrZeroFill)
+	}
+
+	section, err := m.SectionForVA(addr)
+	if nil == err && section.isZeroFill(){
+		return 0, fmt.Errorf("0x%x in %s,%s: %w", addr, section.SegmentName, section.SectionName, ErrZeroFill)
+	}
+
+	return segment.FileOffset + delta, nil
+}
+
+//Translates a file offset into the virtual address it is mapped at.
+func (m FileHeader) OffsetToVA(offset uint64)(uint64, error){
+	for i := range m.LoadCommands{
+		segment := &m.LoadCommands[i]
+		if LC_SEGMENT_64 != segment.Command || 0 == segment.FileSize{
+			continue
+		}
+		if offset >= segment.FileOffset && offset - segment.FileOffset < segment.FileSize{
+			return segment.VmAddress + (offset - segment.FileOffset), nil
+		}
+	}
+	return 0, fmt.Errorf("offset 0x%x: %w", offset, ErrUnmapped)
+}
+
+//Returns size bytes of the file starting at offset.
+func (m FileHeader) ReadBytes(offset uint64, size uint64)([]byte, error){
+	if offset > uint64(len(m.data)) || size > uint64(len(m.data)) - offset{
+		return nil, fmt.Errorf("0x%x bytes at offset 0x%x are past the end of the file", size, offset)
+	}
+	return m.data[offset : offset + size], nil
+}
+
+//Returns the contents of a section. Zero fill sections have no bytes in the file and return ErrZeroFill.
+func (m FileHeader) SectionData(section SectionHeader)([]byte, error){
+	if section.isZeroFill(){
+		return nil, fmt.Errorf("%s,%s: %w", section.SegmentName, section.SectionName, ErrZeroFill)
+	}
+	return m.ReadBytes(uint64(section.Offset), section.Size)
+}
+
+//Returns the section with the given segment and section names, or nil.
+func (m FileHeader) Section(segmentName string, sectionName string)*SectionHeader{
+	for i := range m.LoadCommands{
+		for j := range m.LoadCommands[i].Sections{
+			section := &m.LoadCommands[i].Sections[j]
+			if segmentName == section.SegmentName && sectionName == section.SectionName{
+				return section
+			}
+		}
+	}
+	return nil
+}
+
+//Returns the segment with the given name, or nil.
+func (m FileHeader) Segment(segmentName string)*LoadCommand{
+	for i := range m.LoadCommands{
+		if LC_SEGMENT_64 == m.LoadCommands[i].Command && segmentName == m.LoadCommands[i].SegmentName{
+			return &m.LoadCommands[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package machoHeader
+
+import (
+	"errors"
+	"testing"
+)
+
+//The fixture maps __PAGEZERO over the first 4GB, then __TEXT, __DATA_CONST and __DATA a page each from
+//0x100000000, and __LINKEDIT, of which only 0x10c bytes are in the file, from 0x100003000.
+func TestSegmentForVA(t *testing.T){
+	m := loadFixture(t)
+	cases := []struct{
+		address uint64
+		segment string		//empty for ErrUnmapped
+	}{
+		{0, "__PAGEZERO"},
+		{0xffffffff, "__PAGEZERO"},
+		{0x100000000, "__TEXT"},
+		{0x100000fff, "__TEXT"},
+		{0x100001000, "__DATA_CONST"},
+		{0x100002fff, "__DATA"},
+		{0x100003fff, "__LINKEDIT"},
+		{0x100004000, ""},
+		{^uint64(0), ""},
+	}
+	for _, c := range cases{
+		segment, err := m.SegmentForVA(c.address)
+		switch{
+		case "" == c.segment && !errors.Is(err, ErrUnmapped):
+			t.Errorf("0x%x: %v, want ErrUnmapped", c.address, err)
+		case "" != c.segment && (nil != err || c.segment != segment.SegmentName):
+			t.Errorf("0x%x: %+v, %v, want %s", c.address, segment, err, c.segment)
+		}
+	}
+}
+
+func TestSectionForVA(t *testing.T){
+	m := loadFixture(t)
+	cases := []struct{
+		address uint64
+		section string		//empty when there is none
+		unmapped bool
+	}{
+		{0x100000f50, "__text", false},
+		{0x100000f80, "__text", false},
+		//between __text and __stubs
+		{0x100000f81, "", false},
+		{0x100000f82, "__stubs", false},
+		{0x100000000, "", false},
+		{0x100002008, "__data", false},
+		{0x100002010, "", false},
+		{0x100004000, "", true},
+	}
+	for _, c := range cases{
+		section, err := m.SectionForVA(c.address)
+		switch{
+		case c.unmapped && !errors.Is(err, ErrUnmapped):
+			t.Errorf("0x%x: %v, want ErrUnmapped", c.address, err)
+		case !c.unmapped && "" == c.section && (nil == err || errors.Is(err, ErrUnmapped)):
+			t.Errorf("0x%x: %+v, %v, want no section in a mapped segment", c.address, section, err)
+		case "" != c.section && (nil != err || c.section != section.SectionName):
+			t.Errorf("0x%x: %+v, %v, want %s", c.address, section, err, c.section)
+		}
+	}
+}
+
+type translation struct{
+	from uint64
+	to uint64
+	err error
+}
+
+func checkTranslations(t *testing.T, name string, translate func(uint64)(uint64, error), cases []translation){
+	t.Helper()
+	for _, c := range cases{
+		to, err := translate(c.from)
+		if nil != c.err && !errors.Is(err, c.err){
+			t.Errorf("%s(0x%x): 0x%x, %v, want %v", name, c.from, to, err, c.err)
+		}
+		if nil == c.err && (nil != err || c.to != to){
+			t.Errorf("%s(0x%x): 0x%x, %v, want 0x%x", name, c.from, to, err, c.to)
+		}
+	}
+}
+
+func TestVAToOffset(t *testing.T){
+	m := loadFixture(t)
+	checkTranslations(t, "VAToOffset", m.VAToOffset, []translation{
+		{0x100000000, 0, nil},
+		{0x100000f50, 0xf50, nil},
+		{0x100000fff, 0xfff, nil},
+		{0x100001000, 0x1000, nil},
+		{0x100002008, 0x2008, nil},
+		{0x100003000, 0x3000, nil},
+		{0x10000310b, 0x310b, nil},
+		//the rest of __LINKEDIT's page and all of __PAGEZERO are not in the file
+		{0x10000310c, 0, ErrZeroFill},
+		{0x100003fff, 0, ErrZeroFill},
+		{0x1000, 0, ErrZeroFill},
+		{0x100004000, 0, ErrUnmapped},
+	})
+
+	//a zero fill section inside a segment which is backed by the file
+	bss := imageSection{segment: "__DATA", name: "__bss", address: IMAGE_DATA + 0x100, size: 0x100, flags: uint32(S_ZEROFILL)}
+	built := buildImage(t, []imageSection{bss})
+	checkTranslations(t, "VAToOffset", built.VAToOffset, []translation{
+		{IMAGE_DATA + 0xff, IMAGE_SEGMENT_SIZE + 0xff, nil},
+		{IMAGE_DATA + 0x100, 0, ErrZeroFill},
+		{IMAGE_DATA + 0x1ff, 0, ErrZeroFill},
+		{IMAGE_DATA + 0x200, IMAGE_SEGMENT_SIZE + 0x200, nil},
+	})
+	if _, err := built.SectionData(*built.Section("__DATA", "__bss")); !errors.Is(err, ErrZeroFill){
+		t.Errorf("SectionData(__bss): %v, want ErrZeroFill", err)
+	}
+}
+
+func TestOffsetToVA(t *testing.T){
+	m := loadFixture(t)
+	checkTranslations(t, "OffsetToVA", m.OffsetToVA, []translation{
+		//__PAGEZERO maps no file bytes, so offset 0 belongs to __TEXT
+		{0, 0x100000000, nil},
+		{0xfa2, 0x100000fa2, nil},
+		{0xfff, 0x100000fff, nil},
+		{0x1000, 0x100001000, nil},
+		{0x310b, 0x10000310b, nil},
+		{0x310c, 0, ErrUnmapped},
+		{^uint64(0), 0, ErrUnmapped},
+	})
+}
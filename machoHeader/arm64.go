@@ -0,0 +1,150 @@
+package machoHeader
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"golang.org/x/arch/arm64/arm64asm"
+)
+
+//Instruction classes the annotations need, by encoding mask and value from the ARM Architecture Reference Manual
+const (
+	ARM64_ADRP_MASK				= 0x9f000000
+	ARM64_ADRP					= 0x90000000
+	ARM64_ADD_IMMEDIATE_MASK	= 0xff800000
+	ARM64_ADD_IMMEDIATE			= 0x91000000		//64 bit ADD (immediate)
+	ARM64_LDST_UNSIGNED_MASK	= 0x3b000000
+	ARM64_LDST_UNSIGNED			= 0x39000000		//load/store register (unsigned immediate)
+)
+
+/*
+	//////////////////////////////////////// PRIVATE METHODS ////////////////////////////////////////
+*/
+
+//Disassembles arm64 code loaded at start. Branch and literal targets are printed as absolute addresses
+//and ADRP pages are followed into the ADD or LDR/STR using them, so the address they build can be named.
+func (d *disassembly) arm64(code []byte, start uint64)[]Instruction{
+	var instructions []Instruction
+	//the address each register holds after an ADRP or ADRP+ADD, when known
+	var bases [32]uint64
+	var known [32]bool
+
+	for i := 0; i + 4 <= len(code); {
+		pc := start + uint64(i)
+		if entry := d.dataAt(pc); nil != entry{
+			data := d.data(entry, code[i:], pc)
+			instructions = append(instructions, data...)
+			for _, directive := range data{
+				i += len(directive.Bytes) / 2
+			}
+			continue
+		}
+
+		word := binary.LittleEndian.Uint32(code[i:])
+		instruction := Instruction{Address: pc, Bytes: hex.EncodeToString(code[i : i+4])}
+		i += 4
+		if d.label(&instruction){
+			known = [32]bool{}
+		}
+		inst, err := arm64asm.Decode(code[i-4 : i])
+		if nil != err{
+			instruction.Text = fmt.Sprintf(".long 0x%08x", word)
+			instructions = append(instructions, instruction)
+			continue
+		}
+		instruction.Text = strings.TrimSpace(arm64asm.GNUSyntax(inst))
+
+		//registers the instruction overwrites, whose base is no longer known
+		var written []int
+		switch{
+		case ARM64_ADRP == word & ARM64_ADRP_MASK:
+			relative := inst.Args[1].(arm64asm.PCRel)
+			page := (pc &^ 0xfff) + uint64(relative)
+			instruction.Text = absoluteOperand(instruction.Text, strings.ToLower(relative.String()), page)
+			rd := word & 0x1f
+			bases[rd], known[rd] = page, true
+		case ARM64_ADD_IMMEDIATE == word & ARM64_ADD_IMMEDIATE_MASK:
+			rn, rd := (word >> 5) & 0x1f, word & 0x1f
+			immediate := uint64((word >> 10) & 0xfff) << (12 * ((word >> 22) & 1))
+			if known[rn]{
+				d.refer(&instruction, bases[rn] + immediate)
+				bases[rd], known[rd] = bases[rn] + immediate, true
+			} else {
+				written = append(written, int(rd))
+			}
+		case ARM64_LDST_UNSIGNED == word & ARM64_LDST_UNSIGNED_MASK:
+			rn, rt := (word >> 5) & 0x1f, word & 0x1f
+			scale := word >> 30
+			if 0 != (word >> 26) & 1 && 0 != (word >> 23) & 1{
+				//128 bit SIMD&FP register
+				scale = 4
+			}
+			if known[rn]{
+				d.refer(&instruction, bases[rn] + uint64((word >> 10) & 0xfff) << scale)
+			}
+			if 0 != (word >> 22) & 3 && 0 == (word >> 26) & 1{
+				//a load into a general purpose register
+				written = append(written, int(rt))
+			}
+		default:
+			for _, arg := range inst.Args{
+				if relative, ok := arg.(arm64asm.PCRel); ok{
+					target := pc + uint64(relative)
+					instruction.Text = absoluteOperand(instruction.Text, strings.ToLower(relative.String()), target)
+					d.refer(&instruction, target)
+				}
+			}
+			written = armWrittenRegisters(inst)
+			if arm64asm.BL == inst.Op || arm64asm.BLR == inst.Op{
+				//the callee may change x0-x17 and the platform register x18
+				for r := 0; r <= 18; r++{
+					known[r] = false
+				}
+			}
+		}
+		for _, r := range written{
+			if r >= 0{
+				known[r] = false
+			}
+		}
+		instructions = append(instructions, instruction)
+	}
+	return instructions
+}
+
+//The registers an instruction may write: its first operand, the second destination of a load pair and a base
+//register updated by pre or post indexing. Stores list their source too, which only forgets a base early.
+func armWrittenRegisters(inst arm64asm.Inst)[]int{
+	var written []int
+	if nil != inst.Args[0]{
+		written = append(written, armRegisterIndex(inst.Args[0]))
+	}
+	switch inst.Op{
+	case arm64asm.LDP, arm64asm.LDNP, arm64asm.LDPSW, arm64asm.LDXP, arm64asm.LDAXP:
+		written = append(written, armRegisterIndex(inst.Args[1]))
+	}
+	for _, arg := range inst.Args{
+		if memory, ok := arg.(arm64asm.MemImmediate); ok && arm64asm.AddrOffset != memory.Mode{
+			written = append(written, armRegisterIndex(memory.Base))
+		}
+	}
+	return written
+}
+
+//The register number of a general purpose register operand, or -1.
+func armRegisterIndex(arg arm64asm.Arg)int{
+	switch r := arg.(type){
+	case arm64asm.Reg:
+		switch{
+		case r >= arm64asm.W0 && r <= arm64asm.WZR:
+			return int(r - arm64asm.W0)
+		case r >= arm64asm.X0 && r <= arm64asm.XZR:
+			return int(r - arm64asm.X0)
+		}
+	case arm64asm.RegSP:
+		return armRegisterIndex(arm64asm.Reg(r))
+	}
+	return -1
+}
@@ -0,0 +1,74 @@
+package machoHeader
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+)
+
+//Follows ADRP pages through ADD and LDR/STR, and forgets them when the register is overwritten, by a load pair or
+//a writeback too, or a call may have clobbered it. The symbolizer stub names every 8 bytes of the page the ADRPs
+//build.
+func TestArm64Bases(t *testing.T){
+	const START, PAGE = 0x100004000, 0x100005000
+	symbols := map[uint64]string{}
+	for addr := uint64(PAGE); addr < PAGE + 0x40; addr += 8{
+		symbols[addr] = fmt.Sprintf("_data_%x", addr - PAGE)
+	}
+	d := &disassembly{s: &symbolizer{symbols: symbols, imports: map[uint64]Reference{}, literals: map[uint64]StringLiteral{}}}
+
+	cases := []struct{
+		word uint32
+		text string
+		target uint64		//0 for no reference
+	}{
+		{0xb0000008, "adrp x8, 0x100005000", 0},
+		{0x91004100, "add x0, x8, #0x10", PAGE + 0x10},
+		//128 bit SIMD loads scale the immediate by 16, not by the size field
+		{0x3dc00901, "ldr q1, [x8,#32]", PAGE + 0x20},
+		{0xf9000508, "str x8, [x8,#8]", PAGE + 0x8},
+		//a load into x8 replaces the page it held
+		{0xf9400d08, "ldr x8, [x8,#24]", PAGE + 0x18},
+		{0xf940010a, "ldr x10, [x8]", 0},
+		//x0-x18 do not survive a call, x19 and up do
+		{0xb0000013, "adrp x19, 0x100005000", 0},
+		{0xb0000012, "adrp x18, 0x100005000", 0},
+		{0x94000040, "bl 0x100004120", 0},
+		{0x91002001, "add x1, x0, #0x8", 0},
+		{0x91002242, "add x2, x18, #0x8", 0},
+		{0x91006263, "add x3, x19, #0x18", PAGE + 0x18},
+		{0xb0000011, "adrp x17, 0x100005000", 0},
+		{0xd63f0200, "blr x16", 0},
+		{0x91002224, "add x4, x17, #0x8", 0},
+		{0x91002265, "add x5, x19, #0x8", PAGE + 0x8},
+		//both destinations of a load pair are overwritten
+		{0xb0000009, "adrp x9, 0x100005000", 0},
+		{0xa9412668, "ldp x8, x9, [x19,#16]", 0},
+		{0x91002126, "add x6, x9, #0x8", 0},
+		//and so is the base of a pre or post indexed load
+		{0xb0000008, "adrp x8, 0x100005000", 0},
+		{0xf8408d00, "ldr x0, [x8,#8]!", 0},
+		{0x91002107, "add x7, x8, #0x8", 0},
+	}
+	code := make([]byte, 4 * len(cases))
+	for i, c := range cases{
+		binary.LittleEndian.PutUint32(code[4*i:], c.word)
+	}
+
+	instructions := d.arm64(code, START)
+	if len(cases) != len(instructions){
+		t.Fatalf("%d instructions, want %d", len(instructions), len(cases))
+	}
+	for i, c := range cases{
+		instruction := instructions[i]
+		if c.text != instruction.Text{
+			t.Errorf("0x%x: %q, want %q", instruction.Address, instruction.Text, c.text)
+		}
+		switch{
+		case 0 == c.target && nil != instruction.Reference:
+			t.Errorf("%s: reference to 0x%x, want none", c.text, instruction.Reference.Address)
+		case 0 != c.target && (nil == instruction.Reference || c.target != instruction.Reference.Address):
+			t.Errorf("%s: reference %+v, want 0x%x", c.text, instruction.Reference, c.target)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package machoHeader
+
+import (
+	"bytes"
+	"debug/macho"
+	"fmt"
+)
+
+//Result of Checksec. Anything listed in Violations breaks the default hardening policy.
+type SecurityReport struct{
+	File string					`json:"file,omitempty"`
+	PIE bool					`json:"pie"`
+	NoHeapExecution bool		`json:"no_heap_execution"`
+	AllowStackExecution bool	`json:"allow_stack_execution"`
+	StackCanary bool			`json:"stack_canary"`
+	ARC bool					`json:"arc"`
+	Signed bool					`json:"signed"`
+	CodeSigningFlags []string	`json:"code_signing_flags"`
+	HardenedRuntime bool		`json:"hardened_runtime"`
+	LibraryValidation bool		`json:"library_validation"`
+	Restricted bool				`json:"restricted"`
+	Encrypted bool				`json:"encrypted"`
+	RWXSegments []string		`json:"rwx_segments"`
+	Violations []string			`json:"violations"`
+	Suspiciousness int			`json:"suspiciousness"`	//see EntropyReport, without RWX segments
+	Anomalies []Anomaly			`json:"anomalies"`
+}
+
+/*
+	//////////////////////////////////////// PUBLIC CLASS METHODS ////////////////////////////////////////
+*/
+
+//Builds a checksec style summary of the image's hardening. The default policy requires PIE for executables,
+//no executable stack and no segments which are both writable and executable. The anomalies and
+//suspiciousness score from EntropyReport are included but are not violations. RWX segments are left out of
+//them since they are already violations.
+func (m FileHeader) Checksec()SecurityReport{
+	imports := m.ImportSet()
+	report := SecurityReport{
+		PIE:					m.HasFlag(MH_PIE),
+		NoHeapExecution:		m.HasFlag(MH_NO_HEAP_EXECUTION),
+		AllowStackExecution:	m.HasFlag(MH_ALLOW_STACK_EXECUTION),
+		StackCanary:			imports["___stack_chk_guard"] || imports["___stack_chk_fail"],
+		ARC:					imports["_objc_release"],
+		CodeSigningFlags:		[]string{},
+		RWXSegments:			[]string{},
+		Violations:				[]string{},
+	}
+
+	signature, err := m.CodeSignature()
+	if nil != err{
+		report.Violations = append(report.Violations, "code signature is malformed: " + err.Error())
+	}
+	if nil != signature{
+		report.Signed = true
+		report.CodeSigningFlags = append(report.CodeSigningFlags, signature.FlagNames()...)
+		report.HardenedRuntime = signature.HasFlag(CS_RUNTIME)
+		//The hardened runtime turns on library validation unless the entitlement switches it back off
+		disabled := bytes.Contains(signature.Entitlements, []byte("com.apple.security.cs.disable-library-validation"))
+		report.LibraryValidation = signature.HasFlag(CS_REQUIRE_LV) || signature.HasFlag(CS_FORCED_LV) || (report.HardenedRuntime && !disabled)
+		report.Restricted = signature.HasFlag(CS_RESTRICT)
+	}
+
+	for i := range m.LoadCommands{
+		segment := &m.LoadCommands[i]
+		if LC_SEGMENT_64 != segment.Command{
+			continue
+		}
+		if "__RESTRICT" == segment.SegmentName{
+			report.Restricted = true
+		}
+		if segment.IsWritableExecutable(){
+			report.RWXSegments = append(report.RWXSegments, segment.SegmentName)
+		}
+	}
+
+	for _, c := range append(m.FindCommands(LC_ENCRYPTION_INFO), m.FindCommands(LC_ENCRYPTION_INFO_64)...){
+		if info, ok := c.(EncryptionInfoCommand); ok && 0 != info.CryptID{
+			report.Encrypted = true
+		}
+	}
+
+	if !report.PIE && macho.TypeExec == m.Header.Type{
+		report.Violations = append(report.Violations, "executable is not position independent (MH_PIE)")
+	}
+	if report.AllowStackExecution{
+		report.Violations = append(report.Violations, "stack is executable (MH_ALLOW_STACK_EXECUTION)")
+	}
+	for _, name := range report.RWXSegments{
+		report.Violations = append(report.Violations, fmt.Sprintf("segment %s is writable and executable", name))
+	}
+
+	report.Anomalies = []Anomaly{}
+	for _, anomaly := range m.entropyReport(ENTROPY_WINDOW, false).Anomalies{
+		if ANOMALY_WRITABLE_EXECUTABLE != anomaly.Kind{
+			report.Anomalies = append(report.Anomalies, anomaly)
+		}
+	}
+	report.Suspiciousness = suspiciousness(report.Anomalies)
+	return report
+}
@@ -0,0 +1,82 @@
+package machoHeader
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+//Makes the fixture's __TEXT writable and executable, and moves __text to 0x600 and stretches it over 0xa00
+//random bytes up to the end of the segment.
+func packedFixture(t *testing.T)FileHeader{
+	t.Helper()
+	const TEXT_START = 0x600
+	m := loadFixture(t)
+	data := append([]byte(nil), m.data...)
+	for i := range m.LoadCommands{
+		segment := m.LoadCommands[i]
+		if LC_SEGMENT_64 != segment.Command || "__TEXT" != segment.SegmentName{
+			continue
+		}
+		//maxprot and initprot
+		binary.LittleEndian.PutUint32(data[segment.Offset + 56:], 7)
+		binary.LittleEndian.PutUint32(data[segment.Offset + 60:], 7)
+		for j, section := range segment.Sections{
+			if "__text" == section.SectionName{
+				header := data[segment.Offset + MACH_HEADER_SIZE + uint64(j) * SECTION_HEADER_SIZE:]
+				binary.LittleEndian.PutUint64(header[32:], segment.VmAddress + TEXT_START)
+				binary.LittleEndian.PutUint64(header[40:], segment.FileSize - TEXT_START)
+				binary.LittleEndian.PutUint32(header[48:], TEXT_START)
+			}
+		}
+		rand.New(rand.NewSource(1)).Read(data[TEXT_START : segment.FileSize])
+	}
+	packed, err := ParseBytes(data)
+	if nil != err{
+		t.Fatal(err)
+	}
+	return packed
+}
+
+func TestChecksec(t *testing.T){
+	report := loadFixture(t).Checksec()
+	if !report.PIE || 0 != len(report.Violations) || 0 != len(report.RWXSegments) || 0 != report.Suspiciousness{
+		t.Errorf("fixture report %+v", report)
+	}
+	//lists are empty rather than null in JSON, including the flags of an unsigned image
+	encoded, err := json.Marshal(report)
+	if nil != err{
+		t.Fatal(err)
+	}
+	for _, field := range []string{`"code_signing_flags":[]`, `"rwx_segments":[]`, `"violations":[]`, `"anomalies":[]`}{
+		if !strings.Contains(string(encoded), field){
+			t.Errorf("report has no %s: %s", field, encoded)
+		}
+	}
+
+	m := packedFixture(t)
+	report = m.Checksec()
+	if !reflect.DeepEqual([]string{"__TEXT"}, report.RWXSegments) || 1 != len(report.Violations){
+		t.Errorf("rwx %v violations %v", report.RWXSegments, report.Violations)
+	}
+	//the RWX segment is a violation, so it is not counted again as an anomaly
+	entropy := m.EntropyReport(0)
+	var anomalies []Anomaly
+	for _, anomaly := range entropy.Anomalies{
+		if ANOMALY_WRITABLE_EXECUTABLE != anomaly.Kind{
+			anomalies = append(anomalies, anomaly)
+		}
+	}
+	if len(anomalies) == len(entropy.Anomalies){
+		t.Fatalf("EntropyReport did not flag __TEXT as writable and executable: %v", entropy.Anomalies)
+	}
+	if 0 == len(anomalies) || !reflect.DeepEqual(anomalies, report.Anomalies){
+		t.Errorf("checksec anomalies %v, want %v", report.Anomalies, anomalies)
+	}
+	if want := entropy.Suspiciousness - anomalyScores[ANOMALY_WRITABLE_EXECUTABLE]; want != report.Suspiciousness{
+		t.Errorf("checksec suspiciousness %d, want %d", report.Suspiciousness, want)
+	}
+}
@@ -0,0 +1,449 @@
+package machoHeader
+
+import (
+	"crypto/sha256"
+	"debug/macho"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+//taken from the xnu sources, osfmk/kern/cs_blobs.h. Everything in a code signature is big endian.
+const (
+	CSMAGIC_REQUIREMENTS			= 0xfade0c01	/* Requirements vector (internal requirements) */
+	CSMAGIC_CODEDIRECTORY			= 0xfade0c02	/* CodeDirectory blob */
+	CSMAGIC_EMBEDDED_SIGNATURE		= 0xfade0cc0	/* embedded form of signature data */
+	CSMAGIC_EMBEDDED_ENTITLEMENTS	= 0xfade7171	/* embedded entitlements */
+	CSMAGIC_BLOBWRAPPER				= 0xfade0b01	/* CMS Signature, among other things */
+
+	CSSLOT_CODEDIRECTORY			= 0
+	CSSLOT_INFOSLOT					= 1
+	CSSLOT_REQUIREMENTS				= 2
+	CSSLOT_RESOURCEDIR				= 3
+	CSSLOT_APPLICATION				= 4
+	CSSLOT_ENTITLEMENTS				= 5
+	CSSLOT_SIGNATURESLOT			= 0x10000
+
+	CS_ADHOC						= 0x00000002	/* ad hoc signed */
+	CS_HASHTYPE_SHA256				= 2
+	CS_EXECSEG_MAIN_BINARY			= 0x1			/* executable segment denotes main binary */
+
+	CS_SUPPORTSEXECSEG				= 0x20400
+	CS_PAGE_SHIFT					= 12			/* codesign hashes 4k pages on every architecture */
+)
+
+//Size of a CodeDirectory header at version CS_SUPPORTSEXECSEG, up to and including execSegFlags
+const codeDirectorySize = 88
+
+//Code signing flags held in the CodeDirectory, also from cs_blobs.h
+const (
+	CS_VALID					= 0x00000001	/* dynamically valid */
+	CS_GET_TASK_ALLOW			= 0x00000004	/* has get-task-allow entitlement */
+	CS_INSTALLER				= 0x00000008	/* has installer entitlement */
+	CS_FORCED_LV				= 0x00000010	/* Library Validation required by Hardened System Policy */
+	CS_INVALID_ALLOWED			= 0x00000020	/* (macOS Only) Page invalidation allowed by task port policy */
+	CS_HARD						= 0x00000100	/* don't load invalid pages */
+	CS_KILL						= 0x00000200	/* kill process if it becomes invalid */
+	CS_CHECK_EXPIRATION			= 0x00000400	/* force expiration checking */
+	CS_RESTRICT					= 0x00000800	/* tell dyld to treat restricted */
+	CS_ENFORCEMENT				= 0x00001000	/* require enforcement */
+	CS_REQUIRE_LV				= 0x00002000	/* require library validation */
+	CS_ENTITLEMENTS_VALIDATED	= 0x00004000	/* code signature permits restricted entitlements */
+	CS_NVRAM_UNRESTRICTED		= 0x00008000	/* has com.apple.rootless.restricted-nvram-variables.heritable entitlement */
+	CS_RUNTIME					= 0x00010000	/* Apply hardened runtime policies */
+	CS_LINKER_SIGNED			= 0x00020000	/* Automatically signed by the linker */
+
+	CSSLOT_DER_ENTITLEMENTS				= 7
+	CSSLOT_ALTERNATE_CODEDIRECTORIES	= 0x1000
+	CSMAGIC_EMBEDDED_DER_ENTITLEMENTS	= 0xfade7172
+
+	CS_SUPPORTSTEAMID			= 0x20200
+)
+
+var codeSigningFlagNames = []struct{
+	flag uint32
+	name string
+}{
+	{CS_VALID,					"CS_VALID"},
+	{CS_ADHOC,					"CS_ADHOC"},
+	{CS_GET_TASK_ALLOW,			"CS_GET_TASK_ALLOW"},
+	{CS_INSTALLER,				"CS_INSTALLER"},
+	{CS_FORCED_LV,				"CS_FORCED_LV"},
+	{CS_INVALID_ALLOWED,		"CS_INVALID_ALLOWED"},
+	{CS_HARD,					"CS_HARD"},
+	{CS_KILL,					"CS_KILL"},
+	{CS_CHECK_EXPIRATION,		"CS_CHECK_EXPIRATION"},
+	{CS_RESTRICT,				"CS_RESTRICT"},
+	{CS_ENFORCEMENT,			"CS_ENFORCEMENT"},
+	{CS_REQUIRE_LV,				"CS_REQUIRE_LV"},
+	{CS_ENTITLEMENTS_VALIDATED,	"CS_ENTITLEMENTS_VALIDATED"},
+	{CS_NVRAM_UNRESTRICTED,		"CS_NVRAM_UNRESTRICTED"},
+	{CS_RUNTIME,				"CS_RUNTIME"},
+	{CS_LINKER_SIGNED,			"CS_LINKER_SIGNED"},
+}
+
+//The parts of an embedded signature the tool reports on. Only the primary CodeDirectory is decoded.
+type CodeSignature struct{
+	Identifier string
+	TeamID string
+	Version uint32
+	Flags uint32
+	HashType uint8
+	PageSize uint32
+	CodeLimit uint64
+	NumCodeSlots uint32
+	Entitlements []byte		//XML plist, without the blob header
+	DEREntitlements []byte
+	HasCMS bool				//true when signed with a certificate rather than ad-hoc
+}
+
+/*
+	//////////////////////////////////////// PUBLIC CLASS METHODS ////////////////////////////////////////
+*/
+
+//Page size the kernel maps the image with, 16k on arm64 and 4k everywhere else.
+func (m FileHeader) PageSize()uint64{
+	if CPU_TYPE_ARM64 == m.Header.Cpu || CPU_TYPE_ARM64_32 == m.Header.Cpu{
+		return 0x4000
+	}
+	return 0x1000
+}
+
+//Returns the __LINKEDIT segment, or nil if there is not one.
+func (m *FileHeader) Linkedit()*LoadCommand{
+	return m.Segment("__LINKEDIT")
+}
+
+//Removes LC_CODE_SIGNATURE and the signature blob it points at. When the blob is at the end of the file, which
+//is where ld and codesign put it, the file is truncated and __LINKEDIT is shrunk to match; otherwise the blob
+//is zeroed in place. The result has to be written out with Bytes or WriteFile.
+func (m *FileHeader) RemoveSignature()error{
+	index := -1
+	var signature LinkeditDataCommand
+	for i := range m.LoadCommands{
+		if c, ok := m.LoadCommands[i].Decoded.(LinkeditDataCommand); ok && LC_CODE_SIGNATURE == c.Command{
+			index, signature = i, c
+			break
+		}
+	}
+	if -1 == index{
+		return errors.New("image is not signed")
+	}
+
+	start := uint64(signature.DataOffset)
+	end := start + uint64(signature.DataSize)
+	if end > uint64(len(m.data)){
+		return fmt.Errorf("code signature 0x%x-0x%x is past the end of the file", start, end)
+	}
+
+	*m = m.clone()
+	linkedit := m.Linkedit()
+	if nil != linkedit && end >= linkedit.FileOffset + linkedit.FileSize && start >= linkedit.FileOffset && end >= uint64(len(m.data)){
+		//ld and AdHocSign start the signature 16 byte aligned, so the padding in front of it goes too
+		if contents := m.linkeditContentEnd(index); contents > linkedit.FileOffset && contents < start && start - contents < 16{
+			start = contents
+		}
+		linkedit.FileSize = start - linkedit.FileOffset
+		linkedit.VmSize = alignUp(linkedit.FileSize, m.PageSize())
+		m.data = m.data[:start]
+	} else {
+		data := make([]byte, len(m.data))
+		copy(data, m.data)
+		for i := start; i < end; i++{
+			data[i] = 0
+		}
+		m.data = data
+	}
+
+	m.RemoveCommand(index)
+	return nil
+}
+
+//Parses the embedded code signature. Returns nil without an error if the image is not signed.
+func (m FileHeader) CodeSignature()(*CodeSignature, error){
+	var location LinkeditDataCommand
+	found := false
+	for _, c := range m.FindCommands(LC_CODE_SIGNATURE){
+		location, found = c.(LinkeditDataCommand)
+	}
+	if !found{
+		return nil, nil
+	}
+
+	data, err := m.ReadBytes(uint64(location.DataOffset), uint64(location.DataSize))
+	if nil != err{
+		return nil, fmt.Errorf("code signature: %w", err)
+	}
+	if len(data) < 12 || CSMAGIC_EMBEDDED_SIGNATURE != binary.BigEndian.Uint32(data[0:4]){
+		return nil, errors.New("code signature does not start with an embedded signature SuperBlob")
+	}
+
+	signature := &CodeSignature{}
+	count := binary.BigEndian.Uint32(data[8:12])
+	for i := uint64(0); i < uint64(count) && 20 + 8 * i <= uint64(len(data)); i++{
+		slot := binary.BigEndian.Uint32(data[12 + 8 * i:])
+		offset := uint64(binary.BigEndian.Uint32(data[16 + 8 * i:]))
+		if offset + 8 > uint64(len(data)){
+			return nil, fmt.Errorf("code signature slot %d is outside the signature", slot)
+		}
+		length := uint64(binary.BigEndian.Uint32(data[offset + 4:]))
+		if length < 8 || offset + length > uint64(len(data)){
+			return nil, fmt.Errorf("code signature slot %d has an invalid length", slot)
+		}
+		blob := data[offset : offset + length]
+
+		switch slot{
+		case CSSLOT_CODEDIRECTORY:
+			err = signature.parseCodeDirectory(blob)
+		case CSSLOT_ENTITLEMENTS:
+			signature.Entitlements = blob[8:]
+		case CSSLOT_DER_ENTITLEMENTS:
+			signature.DEREntitlements = blob[8:]
+		case CSSLOT_SIGNATURESLOT:
+			signature.HasCMS = length > 8
+		}
+		if nil != err{
+			return nil, err
+		}
+	}
+	return signature, nil
+}
+
+//Signs the image ad-hoc, the way codesign -s - does. Any existing signature is removed first, then a
+//SuperBlob holding a SHA-256 CodeDirectory, an empty requirements set and, when entitlements is not empty,
+//the entitlements plist is appended to __LINKEDIT and LC_CODE_SIGNATURE is added to point at it. The
+//FileHeader is reloaded from the signed image, so it can be written straight out with WriteFile. If signing
+//fails the FileHeader is left as it was.
+func (m *FileHeader) AdHocSign(identifier string, entitlements []byte)error{
+	signed := m.clone()
+	if signed.IsSigned(){
+		if err := signed.RemoveSignature(); nil != err{
+			return err
+		}
+	}
+
+	linkedit := signed.Linkedit()
+	if nil == linkedit{
+		return errors.New("image has no __LINKEDIT segment to hold the signature")
+	}
+	if linkedit.FileOffset + linkedit.FileSize < uint64(len(signed.data)){
+		return errors.New("__LINKEDIT is not at the end of the file, the signature cannot be appended")
+	}
+
+	//The signature starts 16 byte aligned after everything else, and its size is known before any hashing
+	codeLimit := alignUp(uint64(len(signed.data)), 16)
+	blobs := [][]byte{nil, emptyRequirements()}
+	slots := []uint32{CSSLOT_CODEDIRECTORY, CSSLOT_REQUIREMENTS}
+	if 0 != len(entitlements){
+		blobs = append(blobs, wrapBlob(CSMAGIC_EMBEDDED_ENTITLEMENTS, entitlements))
+		slots = append(slots, CSSLOT_ENTITLEMENTS)
+	}
+	numCodeSlots := (codeLimit + (1 << CS_PAGE_SHIFT) - 1) >> CS_PAGE_SHIFT
+	numSpecialSlots := uint64(slots[len(slots)-1])
+	directorySize := codeDirectorySize + uint64(len(identifier)) + 1 + (numSpecialSlots + numCodeSlots) * sha256.Size
+
+	signatureSize := uint64(12 + 8 * len(blobs)) + directorySize
+	for _, blob := range blobs[1:]{
+		signatureSize += uint64(len(blob))
+	}
+
+	signature, err := NewLoadCommand(LinkeditDataCommand{CommandHeader{LC_CODE_SIGNATURE, 0}, uint32(codeLimit), uint32(signatureSize)})
+	if nil != err{
+		return err
+	}
+	signed.LoadCommands = append(signed.LoadCommands, signature)
+	linkedit = signed.Linkedit()
+	linkedit.FileSize = codeLimit + signatureSize - linkedit.FileOffset
+	linkedit.VmSize = alignUp(linkedit.FileSize, signed.PageSize())
+
+	padded := make([]byte, codeLimit)
+	copy(padded, signed.data)
+	signed.data = padded
+	image, err := signed.Bytes()
+	if nil != err{
+		return err
+	}
+
+	//Every blob in a special slot is hashed into the CodeDirectory
+	specialHashes := make([][]byte, numSpecialSlots + 1)
+	for i, blob := range blobs[1:]{
+		hash := sha256.Sum256(blob)
+		specialHashes[slots[i+1]] = hash[:]
+	}
+	blobs[0] = signed.codeDirectory(image, identifier, specialHashes)
+
+	superBlob := make([]byte, 12 + 8 * len(blobs))
+	binary.BigEndian.PutUint32(superBlob[0:4], CSMAGIC_EMBEDDED_SIGNATURE)
+	binary.BigEndian.PutUint32(superBlob[4:8], uint32(signatureSize))
+	binary.BigEndian.PutUint32(superBlob[8:12], uint32(len(blobs)))
+	for i, blob := range blobs{
+		binary.BigEndian.PutUint32(superBlob[12 + 8 * i:], slots[i])
+		binary.BigEndian.PutUint32(superBlob[16 + 8 * i:], uint32(len(superBlob)))
+		superBlob = append(superBlob, blob...)
+	}
+	if uint64(len(superBlob)) != signatureSize{
+		return fmt.Errorf("signature is 0x%x bytes, expected 0x%x", len(superBlob), signatureSize)
+	}
+
+	signed, err = ParseBytes(append(image, superBlob...))
+	if nil != err{
+		return err
+	}
+	*m = signed
+	return nil
+}
+
+func (c CodeSignature) HasFlag(flag uint32)bool{
+	return flag == c.Flags & flag
+}
+
+func (c CodeSignature) FlagNames()[]string{
+	var names []string
+	for _, entry := range codeSigningFlagNames{
+		if c.HasFlag(entry.flag){
+			names = append(names, entry.name)
+		}
+	}
+	return names
+}
+
+/*
+	//////////////////////////////////////// PRIVATE CLASS METHODS ////////////////////////////////////////
+*/
+
+//Returns where the data the load commands point into __LINKEDIT ends, leaving out the command at skip.
+func (m FileHeader) linkeditContentEnd(skip int)uint64{
+	var end uint64
+	extend := func(offset uint32, size uint64){
+		if 0 != size && uint64(offset) + size > end{
+			end = uint64(offset) + size
+		}
+	}
+	for i := range m.LoadCommands{
+		if i == skip{
+			continue
+		}
+		switch c := m.LoadCommands[i].Decoded.(type){
+		case SymtabCommand:
+			extend(c.SymbolOffset, uint64(c.NumSymbols) * NLIST_64_SIZE)
+			extend(c.StringOffset, uint64(c.StringSize))
+		case DysymtabCommand:
+			extend(c.IndirectSymOffset, uint64(c.NumIndirectSyms) * 4)
+			extend(c.ExtRefSymOffset, uint64(c.NumExtRefSyms) * 4)
+			extend(c.ExtRelOffset, uint64(c.NumExtRel) * 8)
+			extend(c.LocRelOffset, uint64(c.NumLocRel) * 8)
+		case DyldInfoCommand:
+			extend(c.RebaseOffset, uint64(c.RebaseSize))
+			extend(c.BindOffset, uint64(c.BindSize))
+			extend(c.WeakBindOffset, uint64(c.WeakBindSize))
+			extend(c.LazyBindOffset, uint64(c.LazyBindSize))
+			extend(c.ExportOffset, uint64(c.ExportSize))
+		case LinkeditDataCommand:
+			extend(c.DataOffset, uint64(c.DataSize))
+		}
+	}
+	return end
+}
+
+func (c *CodeSignature) parseCodeDirectory(blob []byte)error{
+	if len(blob) < 44 || CSMAGIC_CODEDIRECTORY != binary.BigEndian.Uint32(blob[0:4]){
+		return errors.New("code signature slot 0 is not a CodeDirectory")
+	}
+	c.Version = binary.BigEndian.Uint32(blob[8:12])
+	c.Flags = binary.BigEndian.Uint32(blob[12:16])
+	c.NumCodeSlots = binary.BigEndian.Uint32(blob[28:32])
+	c.CodeLimit = uint64(binary.BigEndian.Uint32(blob[32:36]))
+	c.HashType = blob[37]
+	c.PageSize = 1 << blob[39]
+
+	if identOffset := binary.BigEndian.Uint32(blob[20:24]); identOffset < uint32(len(blob)){
+		c.Identifier = cString(blob[identOffset:])
+	}
+	if c.Version >= CS_SUPPORTSTEAMID && len(blob) >= 52{
+		if teamOffset := binary.BigEndian.Uint32(blob[48:52]); 0 != teamOffset && teamOffset < uint32(len(blob)){
+			c.TeamID = cString(blob[teamOffset:])
+		}
+	}
+	return nil
+}
+
+//Builds a version 0x20400 CodeDirectory over image, which must already contain the final load commands.
+func (m FileHeader) codeDirectory(image []byte, identifier string, specialHashes [][]byte)[]byte{
+	codeLimit := uint64(len(image))
+	pageSize := uint64(1) << CS_PAGE_SHIFT
+	numCodeSlots := (codeLimit + pageSize - 1) / pageSize
+	numSpecialSlots := uint64(len(specialHashes) - 1)
+
+	identOffset := uint64(codeDirectorySize)
+	hashOffset := identOffset + uint64(len(identifier)) + 1 + numSpecialSlots * sha256.Size
+	directory := make([]byte, hashOffset + numCodeSlots * sha256.Size)
+
+	var execSegBase, execSegLimit, execSegFlags uint64
+	for i := range m.LoadCommands{
+		if LC_SEGMENT_64 == m.LoadCommands[i].Command && "__TEXT" == m.LoadCommands[i].SegmentName{
+			execSegBase, execSegLimit = m.LoadCommands[i].FileOffset, m.LoadCommands[i].FileSize
+		}
+	}
+	if macho.TypeExec == m.Header.Type{
+		execSegFlags = CS_EXECSEG_MAIN_BINARY
+	}
+
+	binary.BigEndian.PutUint32(directory[0:4], CSMAGIC_CODEDIRECTORY)
+	binary.BigEndian.PutUint32(directory[4:8], uint32(len(directory)))
+	binary.BigEndian.PutUint32(directory[8:12], CS_SUPPORTSEXECSEG)
+	binary.BigEndian.PutUint32(directory[12:16], CS_ADHOC)
+	binary.BigEndian.PutUint32(directory[16:20], uint32(hashOffset))
+	binary.BigEndian.PutUint32(directory[20:24], uint32(identOffset))
+	binary.BigEndian.PutUint32(directory[24:28], uint32(numSpecialSlots))
+	binary.BigEndian.PutUint32(directory[28:32], uint32(numCodeSlots))
+	binary.BigEndian.PutUint32(directory[32:36], uint32(codeLimit))
+	directory[36] = sha256.Size
+	directory[37] = CS_HASHTYPE_SHA256
+	directory[38] = 0		//platform
+	directory[39] = CS_PAGE_SHIFT
+	binary.BigEndian.PutUint64(directory[64:72], execSegBase)
+	binary.BigEndian.PutUint64(directory[72:80], execSegLimit)
+	binary.BigEndian.PutUint64(directory[80:88], execSegFlags)
+	copy(directory[identOffset:], identifier)
+
+	//Special slots are stored backwards in front of hashOffset, slot n at hashOffset - n * hashSize
+	for slot := uint64(1); slot <= numSpecialSlots; slot++{
+		if nil != specialHashes[slot]{
+			copy(directory[hashOffset - slot * sha256.Size:], specialHashes[slot])
+		}
+	}
+
+	for page := uint64(0); page < numCodeSlots; page++{
+		end := (page + 1) * pageSize
+		if end > codeLimit{
+			end = codeLimit
+		}
+		hash := sha256.Sum256(image[page * pageSize:end])
+		copy(directory[hashOffset + page * sha256.Size:], hash[:])
+	}
+	return directory
+}
+
+/*
+	//////////////////////////////////////// PRIVATE METHODS ////////////////////////////////////////
+*/
+
+func wrapBlob(magic uint32, data []byte)[]byte{
+	blob := make([]byte, 8 + len(data))
+	binary.BigEndian.PutUint32(blob[0:4], magic)
+	binary.BigEndian.PutUint32(blob[4:8], uint32(len(blob)))
+	copy(blob[8:], data)
+	return blob
+}
+
+//A requirements set with no requirements in it, which is what codesign writes for ad-hoc signatures.
+func emptyRequirements()[]byte{
+	blob := make([]byte, 12)
+	binary.BigEndian.PutUint32(blob[0:4], CSMAGIC_REQUIREMENTS)
+	binary.BigEndian.PutUint32(blob[4:8], 12)
+	return blob
+}
+
+func alignUp(value uint64, alignment uint64)uint64{
+	return (value + alignment - 1) &^ (alignment - 1)
+}
@@ -0,0 +1,103 @@
+package machoHeader
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const ENTITLEMENTS = `<?xml version="1.0" encoding="UTF-8"?><plist version="1.0"><dict/></plist>`
+
+func TestAdHocSign(t *testing.T){
+	original := loadFixture(t)
+	m := loadFixture(t)
+	if err := m.AdHocSign("com.example.hello", []byte(ENTITLEMENTS)); nil != err{
+		t.Fatal(err)
+	}
+
+	signature, err := m.CodeSignature()
+	if nil != err || nil == signature{
+		t.Fatalf("CodeSignature = %v, %v", signature, err)
+	}
+	if "com.example.hello" != signature.Identifier || !signature.HasFlag(CS_ADHOC) || signature.HasCMS{
+		t.Errorf("signature %+v", signature)
+	}
+	if ENTITLEMENTS != string(signature.Entitlements){
+		t.Errorf("entitlements %q", signature.Entitlements)
+	}
+	if alignUp(uint64(len(original.data)), 16) != signature.CodeLimit{
+		t.Errorf("code limit 0x%x for a 0x%x byte file", signature.CodeLimit, len(original.data))
+	}
+
+	//every page up to the code limit is hashed into the CodeDirectory
+	location := m.FindCommands(LC_CODE_SIGNATURE)[0].(LinkeditDataCommand)
+	superBlob := m.data[location.DataOffset:]
+	directory := superBlob[binary.BigEndian.Uint32(superBlob[16:20]):]
+	hashOffset := binary.BigEndian.Uint32(directory[16:20])
+	for page := uint64(0); page < uint64(signature.NumCodeSlots); page++{
+		end := (page + 1) << CS_PAGE_SHIFT
+		if end > signature.CodeLimit{
+			end = signature.CodeLimit
+		}
+		hash := sha256.Sum256(m.data[page << CS_PAGE_SHIFT : end])
+		stored := directory[uint64(hashOffset) + page * sha256.Size:][:sha256.Size]
+		if !bytes.Equal(hash[:], stored){
+			t.Errorf("page %d hash does not match", page)
+		}
+	}
+
+	//signing again replaces the signature rather than adding a second one
+	signed := append([]byte(nil), m.data...)
+	if err := m.AdHocSign("com.example.hello", []byte(ENTITLEMENTS)); nil != err{
+		t.Fatal(err)
+	}
+	if !bytes.Equal(signed, m.data){
+		t.Error("signing a signed image again changed it")
+	}
+
+	//removing the signature gives the unsigned file back, without the alignment padding
+	if err := m.RemoveSignature(); nil != err{
+		t.Fatal(err)
+	}
+	unsigned, err := m.Bytes()
+	if nil != err{
+		t.Fatal(err)
+	}
+	if !bytes.Equal(original.data, unsigned){
+		t.Errorf("sign and remove gave 0x%x bytes, the original is 0x%x", len(unsigned), len(original.data))
+	}
+	if err := m.RemoveSignature(); nil == err{
+		t.Error("removed a signature from an unsigned image")
+	}
+}
+
+//A failed signing leaves the FileHeader as it was, not half way through being signed.
+func TestAdHocSignFailure(t *testing.T){
+	m := loadFixture(t)
+
+	//fill the space after the load commands so LC_CODE_SIGNATURE no longer fits
+	used, available := m.CommandSpace()
+	path := strings.Repeat("x", int(available - used - 8 - 12 - 1))
+	if err := m.AddRpath(path); nil != err{
+		t.Fatal(err)
+	}
+	if _, err := m.Bytes(); nil != err{
+		t.Fatal(err)
+	}
+	before, _ := m.Bytes()
+	commands, size := len(m.LoadCommands), m.Linkedit().FileSize
+
+	if err := m.AdHocSign("com.example.hello", nil); !errors.Is(err, ErrNoHeaderSpace){
+		t.Fatalf("AdHocSign error = %v, want ErrNoHeaderSpace", err)
+	}
+	after, err := m.Bytes()
+	if nil != err{
+		t.Fatal(err)
+	}
+	if commands != len(m.LoadCommands) || size != m.Linkedit().FileSize || m.IsSigned() || !bytes.Equal(before, after){
+		t.Errorf("failed AdHocSign changed the FileHeader: %d commands, __LINKEDIT 0x%x", len(m.LoadCommands), m.Linkedit().FileSize)
+	}
+}
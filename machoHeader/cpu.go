@@ -0,0 +1,236 @@
+package machoHeader
+
+import (
+	"debug/macho"
+	"fmt"
+	"strings"
+)
+
+//taken from Library/Developer/CommandLineTools/SDKs/MacOSX10.15.sdk/usr/include/mach/machine.h
+const (
+	CPU_ARCH_MASK			= 0xff000000	/* mask for architecture bits */
+	CPU_ARCH_ABI64			= 0x01000000	/* 64 bit ABI */
+	CPU_ARCH_ABI64_32		= 0x02000000	/* ABI for 64-bit hardware with 32-bit types; LP32 */
+
+	CPU_TYPE_X86			= macho.Cpu(7)
+	CPU_TYPE_I386			= CPU_TYPE_X86
+	CPU_TYPE_X86_64			= CPU_TYPE_X86 | CPU_ARCH_ABI64
+	CPU_TYPE_ARM			= macho.Cpu(12)
+	CPU_TYPE_ARM64			= CPU_TYPE_ARM | CPU_ARCH_ABI64
+	CPU_TYPE_ARM64_32		= CPU_TYPE_ARM | CPU_ARCH_ABI64_32
+	CPU_TYPE_POWERPC		= macho.Cpu(18)
+	CPU_TYPE_POWERPC64		= CPU_TYPE_POWERPC | CPU_ARCH_ABI64
+)
+
+//The top byte of the cpu subtype holds capability bits rather than part of the subtype.
+const (
+	CPU_SUBTYPE_MASK				= 0xff000000	/* mask for feature flags */
+	CPU_SUBTYPE_LIB64				= 0x80000000	/* 64 bit libraries */
+	CPU_SUBTYPE_PTRAUTH_ABI			= 0x80000000	/* pointer authentication with versioned ABI */
+	CPU_SUBTYPE_ARM64_PTR_AUTH_MASK	= 0x0f000000
+)
+
+const (
+	CPU_SUBTYPE_I386_ALL			= 3
+	CPU_SUBTYPE_386					= 3
+	CPU_SUBTYPE_486					= 4
+	CPU_SUBTYPE_486SX				= 4 + (8 << 4)
+	CPU_SUBTYPE_586					= 5
+	CPU_SUBTYPE_PENTPRO				= 6 + (1 << 4)
+	CPU_SUBTYPE_PENTII_M3			= 6 + (3 << 4)
+	CPU_SUBTYPE_PENTII_M5			= 6 + (5 << 4)
+	CPU_SUBTYPE_CELERON				= 7 + (6 << 4)
+	CPU_SUBTYPE_CELERON_MOBILE		= 7 + (7 << 4)
+	CPU_SUBTYPE_PENTIUM_3			= 8
+	CPU_SUBTYPE_PENTIUM_3_M			= 8 + (1 << 4)
+	CPU_SUBTYPE_PENTIUM_3_XEON		= 8 + (2 << 4)
+	CPU_SUBTYPE_PENTIUM_M			= 9
+	CPU_SUBTYPE_PENTIUM_4			= 10
+	CPU_SUBTYPE_PENTIUM_4_M			= 10 + (1 << 4)
+	CPU_SUBTYPE_ITANIUM				= 11
+	CPU_SUBTYPE_ITANIUM_2			= 11 + (1 << 4)
+	CPU_SUBTYPE_XEON				= 12
+	CPU_SUBTYPE_XEON_MP				= 12 + (1 << 4)
+
+	CPU_SUBTYPE_X86_ARCH1			= 4
+
+	CPU_SUBTYPE_ARM_ALL				= 0
+	CPU_SUBTYPE_ARM_V4T				= 5
+	CPU_SUBTYPE_ARM_V6				= 6
+	CPU_SUBTYPE_ARM_V5TEJ			= 7
+	CPU_SUBTYPE_ARM_XSCALE			= 8
+	CPU_SUBTYPE_ARM_V7				= 9
+	CPU_SUBTYPE_ARM_V7F				= 10
+	CPU_SUBTYPE_ARM_V7S				= 11
+	CPU_SUBTYPE_ARM_V7K				= 12
+	CPU_SUBTYPE_ARM_V8				= 13
+	CPU_SUBTYPE_ARM_V6M				= 14
+	CPU_SUBTYPE_ARM_V7M				= 15
+	CPU_SUBTYPE_ARM_V7EM			= 16
+	CPU_SUBTYPE_ARM_V8M				= 17
+
+	CPU_SUBTYPE_ARM64E				= 2
+
+	CPU_SUBTYPE_ARM64_32_ALL		= 0
+	CPU_SUBTYPE_ARM64_32_V8			= 1
+
+	CPU_SUBTYPE_POWERPC_ALL			= 0
+	CPU_SUBTYPE_POWERPC_601			= 1
+	CPU_SUBTYPE_POWERPC_602			= 2
+	CPU_SUBTYPE_POWERPC_603			= 3
+	CPU_SUBTYPE_POWERPC_603E		= 4
+	CPU_SUBTYPE_POWERPC_603EV		= 5
+	CPU_SUBTYPE_POWERPC_604			= 6
+	CPU_SUBTYPE_POWERPC_604E		= 7
+	CPU_SUBTYPE_POWERPC_620			= 8
+	CPU_SUBTYPE_POWERPC_750			= 9
+	CPU_SUBTYPE_POWERPC_7400		= 10
+	CPU_SUBTYPE_POWERPC_7450		= 11
+	CPU_SUBTYPE_POWERPC_970			= 100
+)
+
+var cpuTypeNames = map[macho.Cpu]string{
+	CPU_TYPE_I386:			"CPU_TYPE_I386",
+	CPU_TYPE_X86_64:		"CPU_TYPE_X86_64",
+	CPU_TYPE_ARM:			"CPU_TYPE_ARM",
+	CPU_TYPE_ARM64:			"CPU_TYPE_ARM64",
+	CPU_TYPE_ARM64_32:		"CPU_TYPE_ARM64_32",
+	CPU_TYPE_POWERPC:		"CPU_TYPE_POWERPC",
+	CPU_TYPE_POWERPC64:		"CPU_TYPE_POWERPC64",
+}
+
+var powerPCSubtypeNames = map[uint32]string{
+	CPU_SUBTYPE_POWERPC_ALL:	"CPU_SUBTYPE_POWERPC_ALL",
+	CPU_SUBTYPE_POWERPC_601:	"CPU_SUBTYPE_POWERPC_601",
+	CPU_SUBTYPE_POWERPC_602:	"CPU_SUBTYPE_POWERPC_602",
+	CPU_SUBTYPE_POWERPC_603:	"CPU_SUBTYPE_POWERPC_603",
+	CPU_SUBTYPE_POWERPC_603E:	"CPU_SUBTYPE_POWERPC_603e",
+	CPU_SUBTYPE_POWERPC_603EV:	"CPU_SUBTYPE_POWERPC_603ev",
+	CPU_SUBTYPE_POWERPC_604:	"CPU_SUBTYPE_POWERPC_604",
+	CPU_SUBTYPE_POWERPC_604E:	"CPU_SUBTYPE_POWERPC_604e",
+	CPU_SUBTYPE_POWERPC_620:	"CPU_SUBTYPE_POWERPC_620",
+	CPU_SUBTYPE_POWERPC_750:	"CPU_SUBTYPE_POWERPC_750",
+	CPU_SUBTYPE_POWERPC_7400:	"CPU_SUBTYPE_POWERPC_7400",
+	CPU_SUBTYPE_POWERPC_7450:	"CPU_SUBTYPE_POWERPC_7450",
+	CPU_SUBTYPE_POWERPC_970:	"CPU_SUBTYPE_POWERPC_970",
+}
+
+//Subtype values are only meaningful relative to their cpu type, so there is one table per type.
+var cpuSubtypeNames = map[macho.Cpu]map[uint32]string{
+	CPU_TYPE_I386: {
+		CPU_SUBTYPE_I386_ALL:			"CPU_SUBTYPE_I386_ALL",
+		CPU_SUBTYPE_486:				"CPU_SUBTYPE_486",
+		CPU_SUBTYPE_486SX:				"CPU_SUBTYPE_486SX",
+		CPU_SUBTYPE_586:				"CPU_SUBTYPE_586",
+		CPU_SUBTYPE_PENTPRO:			"CPU_SUBTYPE_PENTPRO",
+		CPU_SUBTYPE_PENTII_M3:			"CPU_SUBTYPE_PENTII_M3",
+		CPU_SUBTYPE_PENTII_M5:			"CPU_SUBTYPE_PENTII_M5",
+		CPU_SUBTYPE_CELERON:			"CPU_SUBTYPE_CELERON",
+		CPU_SUBTYPE_CELERON_MOBILE:		"CPU_SUBTYPE_CELERON_MOBILE",
+		CPU_SUBTYPE_PENTIUM_3:			"CPU_SUBTYPE_PENTIUM_3",
+		CPU_SUBTYPE_PENTIUM_3_M:		"CPU_SUBTYPE_PENTIUM_3_M",
+		CPU_SUBTYPE_PENTIUM_3_XEON:		"CPU_SUBTYPE_PENTIUM_3_XEON",
+		CPU_SUBTYPE_PENTIUM_M:			"CPU_SUBTYPE_PENTIUM_M",
+		CPU_SUBTYPE_PENTIUM_4:			"CPU_SUBTYPE_PENTIUM_4",
+		CPU_SUBTYPE_PENTIUM_4_M:		"CPU_SUBTYPE_PENTIUM_4_M",
+		CPU_SUBTYPE_ITANIUM:			"CPU_SUBTYPE_ITANIUM",
+		CPU_SUBTYPE_ITANIUM_2:			"CPU_SUBTYPE_ITANIUM_2",
+		CPU_SUBTYPE_XEON:				"CPU_SUBTYPE_XEON",
+		CPU_SUBTYPE_XEON_MP:			"CPU_SUBTYPE_XEON_MP",
+	},
+	CPU_TYPE_X86_64: {
+		CPU_SUBTYPE_X86_64_ALL:			"CPU_SUBTYPE_X86_64_ALL",
+		CPU_SUBTYPE_X86_ARCH1:			"CPU_SUBTYPE_X86_ARCH1",
+		CPU_SUBTYPE_X86_64_H:			"CPU_SUBTYPE_X86_64_H",
+	},
+	CPU_TYPE_ARM: {
+		CPU_SUBTYPE_ARM_ALL:			"CPU_SUBTYPE_ARM_ALL",
+		CPU_SUBTYPE_ARM_V4T:			"CPU_SUBTYPE_ARM_V4T",
+		CPU_SUBTYPE_ARM_V6:				"CPU_SUBTYPE_ARM_V6",
+		CPU_SUBTYPE_ARM_V5TEJ:			"CPU_SUBTYPE_ARM_V5TEJ",
+		CPU_SUBTYPE_ARM_XSCALE:			"CPU_SUBTYPE_ARM_XSCALE",
+		CPU_SUBTYPE_ARM_V7:				"CPU_SUBTYPE_ARM_V7",
+		CPU_SUBTYPE_ARM_V7F:			"CPU_SUBTYPE_ARM_V7F",
+		CPU_SUBTYPE_ARM_V7S:			"CPU_SUBTYPE_ARM_V7S",
+		CPU_SUBTYPE_ARM_V7K:			"CPU_SUBTYPE_ARM_V7K",
+		CPU_SUBTYPE_ARM_V8:				"CPU_SUBTYPE_ARM_V8",
+		CPU_SUBTYPE_ARM_V6M:			"CPU_SUBTYPE_ARM_V6M",
+		CPU_SUBTYPE_ARM_V7M:			"CPU_SUBTYPE_ARM_V7M",
+		CPU_SUBTYPE_ARM_V7EM:			"CPU_SUBTYPE_ARM_V7EM",
+		CPU_SUBTYPE_ARM_V8M:			"CPU_SUBTYPE_ARM_V8M",
+	},
+	CPU_TYPE_ARM64: {
+		CPU_SUBTYPE_ARM64_ALL:			"CPU_SUBTYPE_ARM64_ALL",
+		CPU_SUBTYPE_ARM64_V8:			"CPU_SUBTYPE_ARM64_V8",
+		CPU_SUBTYPE_ARM64E:				"CPU_SUBTYPE_ARM64E",
+	},
+	CPU_TYPE_ARM64_32: {
+		CPU_SUBTYPE_ARM64_32_ALL:		"CPU_SUBTYPE_ARM64_32_ALL",
+		CPU_SUBTYPE_ARM64_32_V8:		"CPU_SUBTYPE_ARM64_32_V8",
+	},
+	CPU_TYPE_POWERPC:		powerPCSubtypeNames,
+	CPU_TYPE_POWERPC64:		powerPCSubtypeNames,
+}
+
+/*
+	//////////////////////////////////////// PUBLIC METHODS ////////////////////////////////////////
+*/
+
+//Returns the machine.h name of the cpu type, or the raw value if the type is not known.
+func CPUTypeName(cpu macho.Cpu)string{
+	if name, ok := cpuTypeNames[cpu]; ok{
+		return name
+	}
+	return fmt.Sprintf("UNKNOWN_CPU_TYPE(0x%x)", uint32(cpu))
+}
+
+//Returns the machine.h name of the subtype with its capability bits appended, e.g.
+//"CPU_SUBTYPE_ARM64E|PTRAUTH_ABI(v0)". Unknown subtypes are returned as their raw value.
+func CPUSubtypeName(cpu macho.Cpu, subtype uint32)string{
+	base := subtype &^ CPU_SUBTYPE_MASK
+
+	name, ok := cpuSubtypeNames[cpu][base]
+	if !ok{
+		name = fmt.Sprintf("UNKNOWN_CPU_SUBTYPE(0x%x)", base)
+	}
+
+	capabilities := CPUSubtypeCapabilities(cpu, subtype)
+	if 0 == len(capabilities){
+		return name
+	}
+	return name + "|" + strings.Join(capabilities, "|")
+}
+
+//Decodes the capability bits held in the top byte of the subtype.
+func CPUSubtypeCapabilities(cpu macho.Cpu, subtype uint32)[]string{
+	var capabilities []string
+	remaining := subtype & CPU_SUBTYPE_MASK
+
+	if CPU_TYPE_ARM64 == cpu && CPU_SUBTYPE_ARM64E == subtype &^ CPU_SUBTYPE_MASK{
+		if CPU_SUBTYPE_PTRAUTH_ABI == subtype & CPU_SUBTYPE_PTRAUTH_ABI{
+			version, _ := PtrAuthVersion(cpu, subtype)
+			capabilities = append(capabilities, fmt.Sprintf("PTRAUTH_ABI(v%d)", version))
+		}
+		remaining &^= CPU_SUBTYPE_PTRAUTH_ABI | CPU_SUBTYPE_ARM64_PTR_AUTH_MASK
+	} else if CPU_SUBTYPE_LIB64 == subtype & CPU_SUBTYPE_LIB64{
+		capabilities = append(capabilities, "CPU_SUBTYPE_LIB64")
+		remaining &^= CPU_SUBTYPE_LIB64
+	}
+
+	if 0 != remaining{
+		capabilities = append(capabilities, fmt.Sprintf("0x%x", remaining))
+	}
+	return capabilities
+}
+
+//Returns the pointer authentication ABI version of an arm64e subtype. ok is false for anything that is not
+//arm64e with a versioned ABI.
+func PtrAuthVersion(cpu macho.Cpu, subtype uint32)(version uint32, ok bool){
+	if CPU_TYPE_ARM64 != cpu || CPU_SUBTYPE_ARM64E != subtype &^ CPU_SUBTYPE_MASK{
+		return 0, false
+	}
+	if CPU_SUBTYPE_PTRAUTH_ABI != subtype & CPU_SUBTYPE_PTRAUTH_ABI{
+		return 0, false
+	}
+	return (subtype & CPU_SUBTYPE_ARM64_PTR_AUTH_MASK) >> 24, true
+}
@@ -0,0 +1,99 @@
+package machoHeader
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+//taken from Library/Developer/CommandLineTools/SDKs/MacOSX10.15.sdk/usr/include/mach-o/loader.h
+const (
+	DICE_KIND_DATA				= 0x0001
+	DICE_KIND_JUMP_TABLE8		= 0x0002
+	DICE_KIND_JUMP_TABLE16		= 0x0003
+	DICE_KIND_JUMP_TABLE32		= 0x0004
+	DICE_KIND_ABS_JUMP_TABLE32	= 0x0005
+
+	DATA_IN_CODE_ENTRY_SIZE = 8
+)
+
+var diceKindNames = map[uint16]string{
+	DICE_KIND_DATA:				"DICE_KIND_DATA",
+	DICE_KIND_JUMP_TABLE8:		"DICE_KIND_JUMP_TABLE8",
+	DICE_KIND_JUMP_TABLE16:		"DICE_KIND_JUMP_TABLE16",
+	DICE_KIND_JUMP_TABLE32:		"DICE_KIND_JUMP_TABLE32",
+	DICE_KIND_ABS_JUMP_TABLE32:	"DICE_KIND_ABS_JUMP_TABLE32",
+}
+
+//One data_in_code_entry: a range of a code section holding data (constants, jump tables) rather than
+//instructions, which a disassembler has to skip.
+type DataInCode struct{
+	Offset uint32		`json:"offset"`		//file offset, from the mach header
+	Length uint16		`json:"length"`
+	Kind uint16			`json:"kind"`
+	Address uint64		`json:"address"`
+	Section string		`json:"section,omitempty"`	//"__TEXT,__text", empty if the range is in no section
+}
+
+/*
+	//////////////////////////////////////// PUBLIC METHODS ////////////////////////////////////////
+*/
+
+func (d DataInCode) KindName()string{
+	if name, ok := diceKindNames[d.Kind]; ok{
+		return name
+	}
+	return fmt.Sprintf("DICE_KIND_0x%x", d.Kind)
+}
+
+//True if addr falls inside the range.
+func (d DataInCode) Contains(addr uint64)bool{
+	return addr >= d.Address && addr - d.Address < uint64(d.Length)
+}
+
+func (d DataInCode) String()string{
+	return fmt.Sprintf("0x%x %s 0x%x bytes %s", d.Address, d.Section, d.Length, d.KindName())
+}
+
+/*
+	//////////////////////////////////////// PUBLIC CLASS METHODS ////////////////////////////////////////
+*/
+
+//Reads the LC_DATA_IN_CODE table, resolving each entry's file offset to its address and section. Images
+//without the command return an empty list. Entries whose offset is not mapped are left out and reported in
+//the error next to the rest, which is only nil when the table itself cannot be read.
+func (m FileHeader) DataInCode()([]DataInCode, error){
+	found := m.FindCommands(LC_DATA_IN_CODE)
+	if 0 == len(found){
+		return nil, nil
+	}
+	command, ok := found[0].(LinkeditDataCommand)
+	if !ok{
+		return nil, errors.New("LC_DATA_IN_CODE could not be decoded")
+	}
+	table, err := m.ReadBytes(uint64(command.DataOffset), uint64(command.DataSize))
+	if nil != err{
+		return nil, fmt.Errorf("data in code: %w", err)
+	}
+
+	var errs []error
+	entries := make([]DataInCode, 0, len(table) / DATA_IN_CODE_ENTRY_SIZE)
+	for i := 0; i + DATA_IN_CODE_ENTRY_SIZE <= len(table); i += DATA_IN_CODE_ENTRY_SIZE{
+		entry := DataInCode{
+			Offset:	binary.LittleEndian.Uint32(table[i:i+4]),
+			Length:	binary.LittleEndian.Uint16(table[i+4:i+6]),
+			Kind:	binary.LittleEndian.Uint16(table[i+6:i+8]),
+		}
+		address, err := m.OffsetToVA(uint64(entry.Offset))
+		if nil != err{
+			errs = append(errs, fmt.Errorf("data in code entry %d: %w", i / DATA_IN_CODE_ENTRY_SIZE, err))
+			continue
+		}
+		entry.Address = address
+		if section, err := m.SectionForVA(address); nil == err{
+			entry.Section = sectionLabel(*section)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, errors.Join(errs...)
+}
@@ -0,0 +1,55 @@
+package machoHeader
+
+import (
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func dataInCodeTable(entries ...DataInCode)[]byte{
+	table := make([]byte, DATA_IN_CODE_ENTRY_SIZE * len(entries))
+	for i, entry := range entries{
+		binary.LittleEndian.PutUint32(table[DATA_IN_CODE_ENTRY_SIZE*i:], entry.Offset)
+		binary.LittleEndian.PutUint16(table[DATA_IN_CODE_ENTRY_SIZE*i+4:], entry.Length)
+		binary.LittleEndian.PutUint16(table[DATA_IN_CODE_ENTRY_SIZE*i+6:], entry.Kind)
+	}
+	return table
+}
+
+//A jump table in __text, a constant outside any section and an entry past the end of the file, which is left
+//out and reported.
+func TestDataInCode(t *testing.T){
+	table := dataInCodeTable(
+		DataInCode{Offset: 0x1010, Length: 16, Kind: DICE_KIND_JUMP_TABLE32},
+		DataInCode{Offset: 0x800, Length: 4, Kind: DICE_KIND_DATA},
+		DataInCode{Offset: 0x10000, Length: 4, Kind: DICE_KIND_DATA},
+	)
+	m := buildImage(t, []imageSection{
+		{segment: "__TEXT", name: "__text", address: IMAGE_TEXT + 0x1000, data: make([]byte, 0x40)},
+		{segment: "__LINKEDIT", address: IMAGE_LINKEDIT, data: table},
+	}, linkeditCommand(LC_DATA_IN_CODE, IMAGE_LINKEDIT, len(table)))
+
+	entries, err := m.DataInCode()
+	if nil == err || !strings.Contains(err.Error(), "entry 2"){
+		t.Errorf("error = %v, want one for entry 2", err)
+	}
+	want := []DataInCode{
+		{Offset: 0x1010, Length: 16, Kind: DICE_KIND_JUMP_TABLE32, Address: IMAGE_TEXT + 0x1010, Section: "__TEXT,__text"},
+		{Offset: 0x800, Length: 4, Kind: DICE_KIND_DATA, Address: IMAGE_TEXT + 0x800},
+	}
+	if !reflect.DeepEqual(want, entries){
+		t.Errorf("entries %+v\nwant %+v", entries, want)
+	}
+	if !entries[0].Contains(IMAGE_TEXT + 0x101f) || entries[0].Contains(IMAGE_TEXT + 0x1020){
+		t.Errorf("%s covers the wrong range", entries[0])
+	}
+	if "DICE_KIND_JUMP_TABLE32" != entries[0].KindName() || "DICE_KIND_0x9" != (DataInCode{Kind: 9}).KindName(){
+		t.Error("kind names")
+	}
+
+	//the fixture's table is empty
+	if entries, err := loadFixture(t).DataInCode(); nil != err || 0 != len(entries){
+		t.Errorf("fixture entries %+v, error %v", entries, err)
+	}
+}
@@ -0,0 +1,301 @@
+package machoHeader
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//One semantic difference between two images. Old is empty for additions and New for removals.
+type Difference struct{
+	Kind string			`json:"kind"`		//"added", "removed" or "changed"
+	Area string			`json:"area"`		//header, load command, segment, section, dylib, symbol, export, signature or entitlement
+	Name string			`json:"name"`
+	Old string			`json:"old,omitempty"`
+	New string			`json:"new,omitempty"`
+}
+
+/*
+	//////////////////////////////////////// PUBLIC METHODS ////////////////////////////////////////
+*/
+
+//Compares two images area by area. Things are matched by name rather than position, so a new load command
+//or section shows up as one addition instead of shifting everything after it. Symbol and export addresses
+//are not compared since they move with every code change; their presence and kind are.
+func Diff(oldFile FileHeader, newFile FileHeader)[]Difference{
+	var d differ
+	d.header(oldFile, newFile)
+	d.loadCommands(oldFile, newFile)
+	d.segments(oldFile, newFile)
+	d.dylibs(oldFile, newFile)
+	d.symbols(oldFile, newFile)
+	d.exports(oldFile, newFile)
+	d.signature(oldFile, newFile)
+	return d.differences
+}
+
+func (d Difference) String()string{
+	switch d.Kind{
+	case "added":
+		return fmt.Sprintf("+ %s %s: %s", d.Area, d.Name, d.New)
+	case "removed":
+		return fmt.Sprintf("- %s %s: %s", d.Area, d.Name, d.Old)
+	default:
+		return fmt.Sprintf("~ %s %s: %s -> %s", d.Area, d.Name, d.Old, d.New)
+	}
+}
+
+/*
+	//////////////////////////////////////// PRIVATE METHODS ////////////////////////////////////////
+*/
+
+type differ struct{
+	differences []Difference
+}
+
+//Compares two name -> description maps and records additions, removals and changes in name order.
+func (d *differ) compare(area string, oldValues map[string]string, newValues map[string]string){
+	var names []string
+	for name := range oldValues{
+		names = append(names, name)
+	}
+	for name := range newValues{
+		if _, ok := oldValues[name]; !ok{
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names{
+		oldValue, inOld := oldValues[name]
+		newValue, inNew := newValues[name]
+		switch{
+		case !inOld:
+			d.differences = append(d.differences, Difference{"added", area, name, "", newValue})
+		case !inNew:
+			d.differences = append(d.differences, Difference{"removed", area, name, oldValue, ""})
+		case oldValue != newValue:
+			d.differences = append(d.differences, Difference{"changed", area, name, oldValue, newValue})
+		}
+	}
+}
+
+func (d *differ) header(oldFile FileHeader, newFile FileHeader){
+	fields := func(m FileHeader)map[string]string{
+		return map[string]string{
+			"cpu":			ArchName(m.Header.Cpu, m.Header.SubCpu),
+			"filetype":		m.Header.Type.String(),
+			"ncmds":		fmt.Sprint(m.Header.Ncmd),
+			"sizeofcmds":	fmt.Sprint(m.Header.Cmdsz),
+			"flags":		fmt.Sprintf("0x%x %s", m.Header.Flags, strings.Join(headerFlagList(m.Header.Flags), " ")),
+		}
+	}
+	d.compare("header", fields(oldFile), fields(newFile))
+}
+
+//Load commands are keyed by name plus what identifies them (segment name, dylib or rpath), falling back to
+//their occurrence number for commands which can repeat.
+func (d *differ) loadCommands(oldFile FileHeader, newFile FileHeader){
+	keys := func(m FileHeader)([]string, map[string]string){
+		var order []string
+		values := map[string]string{}
+		seen := map[string]int{}
+		for i := range m.LoadCommands{
+			l := &m.LoadCommands[i]
+			key := CommandName(l.Command)
+			switch c := l.Value().(type){
+			case *LoadCommand:
+				key += " " + c.SegmentName
+			case DylibCommand:
+				key += " " + c.Name
+			case RpathCommand:
+				key += " " + c.Path
+			default:
+				seen[key]++
+				if seen[key] > 1{
+					key += fmt.Sprintf(" #%d", seen[key])
+				}
+			}
+			order = append(order, key)
+			values[key] = l.Value().String()
+		}
+		return order, values
+	}
+	oldOrder, oldValues := keys(oldFile)
+	newOrder, newValues := keys(newFile)
+	d.compare("load command", oldValues, newValues)
+
+	//only commands present in both count towards the order
+	common := func(order []string, other map[string]string)string{
+		var kept []string
+		for _, key := range order{
+			if _, ok := other[key]; ok{
+				kept = append(kept, key)
+			}
+		}
+		return strings.Join(kept, ", ")
+	}
+	if before, after := common(oldOrder, newValues), common(newOrder, oldValues); before != after{
+		d.differences = append(d.differences, Difference{"changed", "load command", "order", before, after})
+	}
+}
+
+func (d *differ) segments(oldFile FileHeader, newFile FileHeader){
+	segments := func(m FileHeader)(map[string]string, map[string]string){
+		segmentValues := map[string]string{}
+		sectionValues := map[string]string{}
+		for i := range m.LoadCommands{
+			l := &m.LoadCommands[i]
+			if LC_SEGMENT_64 != l.Command{
+				continue
+			}
+			segmentValues[l.SegmentName] = fmt.Sprintf("vmaddr 0x%x vmsize 0x%x fileoff 0x%x filesize 0x%x maxprot %s initprot %s flags 0x%x nsects %d",
+				l.VmAddress, l.VmSize, l.FileOffset, l.FileSize, l.MaxProtection(), l.InitProtection(), l.Flags, l.NumOfSections)
+			for _, section := range l.Sections{
+				sectionValues[section.SegmentName + "," + section.SectionName] = fmt.Sprintf("addr 0x%x size 0x%x offset 0x%x align 2^%d %s",
+					section.Address, section.Size, section.Offset, section.Alignment, section.Type())
+			}
+		}
+		return segmentValues, sectionValues
+	}
+	oldSegments, oldSections := segments(oldFile)
+	newSegments, newSections := segments(newFile)
+	d.compare("segment", oldSegments, newSegments)
+	d.compare("section", oldSections, newSections)
+}
+
+func (d *differ) dylibs(oldFile FileHeader, newFile FileHeader){
+	dylibs := func(m FileHeader)map[string]string{
+		values := map[string]string{}
+		for _, dylib := range m.Dylibs(){
+			values[dylib.Name] = fmt.Sprintf("%s current %s compatibility %s", CommandName(dylib.Command),
+				FormatVersion(dylib.CurrentVersion), FormatVersion(dylib.CompatibilityVersion))
+		}
+		return values
+	}
+	d.compare("dylib", dylibs(oldFile), dylibs(newFile))
+}
+
+func (d *differ) symbols(oldFile FileHeader, newFile FileHeader){
+	symbols := func(m FileHeader)map[string]string{
+		values := map[string]string{}
+		list, _ := m.Symbols()
+		for _, symbol := range list{
+			if symbol.IsDebug() || "" == symbol.Name{
+				continue
+			}
+			kind := "local"
+			switch{
+			case symbol.IsUndefined() && symbol.IsExternal():
+				kind = "undefined"
+			case symbol.IsExternal():
+				kind = "external"
+			}
+			values[symbol.Name] = kind
+		}
+		return values
+	}
+	d.compare("symbol", symbols(oldFile), symbols(newFile))
+}
+
+func (d *differ) exports(oldFile FileHeader, newFile FileHeader){
+	exports := func(m FileHeader)map[string]string{
+		values := map[string]string{}
+		list, _ := m.Exports()
+		for _, export := range list{
+			//the address is left out, see Diff
+			export.Address, export.Resolver = 0, 0
+			values[export.Name] = strings.TrimPrefix(export.String(), "0x0 ")
+		}
+		return values
+	}
+	d.compare("export", exports(oldFile), exports(newFile))
+}
+
+func (d *differ) signature(oldFile FileHeader, newFile FileHeader){
+	signature := func(m FileHeader)(map[string]string, map[string]string){
+		values := map[string]string{}
+		signature, err := m.CodeSignature()
+		if nil != err{
+			values["error"] = err.Error()
+		}
+		if nil == signature{
+			return values, nil
+		}
+		values["identifier"] = signature.Identifier
+		values["team id"] = signature.TeamID
+		values["flags"] = strings.Join(signature.FlagNames(), " ")
+		values["type"] = "ad-hoc"
+		if signature.HasCMS{
+			values["type"] = "certificate"
+		}
+		entitlements, err := entitlementValues(signature.Entitlements)
+		if nil != err{
+			values["entitlements"] = err.Error()
+		}
+		return values, entitlements
+	}
+	oldValues, oldEntitlements := signature(oldFile)
+	newValues, newEntitlements := signature(newFile)
+	d.compare("signature", oldValues, newValues)
+	d.compare("entitlement", oldEntitlements, newEntitlements)
+}
+
+func headerFlagList(flags uint32)[]string{
+	var names []string
+	for name, flag := range headerFlagNames{
+		if 0 != flags & flag{
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+//Reads the top level dictionary of an entitlements plist into key -> value text.
+func entitlementValues(plist []byte)(map[string]string, error){
+	values := map[string]string{}
+	if 0 == len(plist){
+		return values, nil
+	}
+	decoder := xml.NewDecoder(bytes.NewReader(plist))
+	depth := 0
+	key := ""
+	var value []string
+	for{
+		token, err := decoder.Token()
+		if nil != err{
+			if 0 == depth{
+				return values, nil
+			}
+			return values, fmt.Errorf("entitlements plist: %w", err)
+		}
+		switch t := token.(type){
+		case xml.StartElement:
+			depth++
+			//depth 1 is <plist>, 2 the top dictionary, 3 its keys and values
+			if 3 == depth && "key" != t.Name.Local{
+				value = nil
+			}
+			if depth >= 3 && "key" != t.Name.Local && ("true" == t.Name.Local || "false" == t.Name.Local){
+				value = append(value, t.Name.Local)
+			}
+		case xml.CharData:
+			if text := strings.TrimSpace(string(t)); "" != text && depth >= 3{
+				if 3 == depth && "" == key{
+					key = text
+				} else {
+					value = append(value, text)
+				}
+			}
+		case xml.EndElement:
+			if 3 == depth && "key" != t.Name.Local && "" != key{
+				values[key] = strings.Join(value, ", ")
+				key = ""
+			}
+			depth--
+		}
+	}
+}
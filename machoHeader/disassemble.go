@@ -0,0 +1,246 @@
+package machoHeader
+
+import (
+	"cycle1/demangle"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrUnsupportedArch = errors.New("disassembly is not supported for this architecture")
+
+type Instruction struct{
+	Address uint64			`json:"address"`
+	Bytes string			`json:"bytes"`					//hex
+	Text string				`json:"text"`
+	Label string			`json:"label,omitempty"`		//name of the function starting here
+	Comment string			`json:"comment,omitempty"`
+	Reference *Reference	`json:"reference,omitempty"`	//what the instruction branches to or loads
+	Data bool				`json:"data,omitempty"`			//a data-in-code range shown as .byte/.short/.long
+}
+
+/*
+	//////////////////////////////////////// PUBLIC METHODS ////////////////////////////////////////
+*/
+
+//otool style: address, bytes, instruction and the comment after a ;
+func (i Instruction) String()string{
+	s := fmt.Sprintf("%016x\t%-24s\t%s", i.Address, i.Bytes, i.Text)
+	if "" != i.Comment{
+		s += "\t; " + i.Comment
+	}
+	return s
+}
+
+//The instruction with its label and the symbol it references demangled.
+func (i Instruction) Demangled()Instruction{
+	i.Label = demangle.Demangle(i.Label)
+	if nil != i.Reference{
+		reference := i.Reference.Demangled()
+		i.Reference = &reference
+		i.Comment = reference.String()
+	}
+	return i
+}
+
+/*
+	//////////////////////////////////////// PUBLIC CLASS METHODS ////////////////////////////////////////
+*/
+
+//Disassembles the bytes from start up to end, which must be inside one section.
+func (m FileHeader) Disassemble(start uint64, end uint64)([]Instruction, error){
+	d, err := m.newDisassembly()
+	if nil != err{
+		return nil, err
+	}
+	return d.disassemble(start, end)
+}
+
+//Disassembles a whole section, e.g. ("__TEXT", "__text").
+func (m FileHeader) DisassembleSection(segmentName string, sectionName string)([]Instruction, error){
+	section := m.Section(segmentName, sectionName)
+	if nil == section{
+		return nil, fmt.Errorf("there is no %s,%s section", segmentName, sectionName)
+	}
+	if 0 == section.Size{
+		return nil, nil
+	}
+	return m.Disassemble(section.Address, section.Address + section.Size)
+}
+
+//Disassembles the function named name (with or without its leading underscore, or demangled), or the function containing
+//the address if name is a number such as "0x100003f20". Functions come from LC_FUNCTION_STARTS, see
+//Functions, or if it has no such function from the symbol table, each symbol running up to the next one.
+func (m FileHeader) DisassembleFunction(name string)([]Instruction, error){
+	functions, err := m.Functions()
+	if nil != err{
+		return nil, err
+	}
+	function := findFunction(functions, name)
+	if nil == function{
+		function = findFunction(m.symbolRanges(), name)
+	}
+	if nil == function || 0 == function.Size{
+		return nil, fmt.Errorf("no function or symbol %s", name)
+	}
+	return m.Disassemble(function.Address, function.Address + function.Size)
+}
+
+/*
+	//////////////////////////////////////// PRIVATE CLASS METHODS ////////////////////////////////////////
+*/
+
+//Defined symbols in sections with instructions as functions, each ending at the next symbol or the end of
+//its section.
+func (m FileHeader) symbolRanges()[]Function{
+	symbols, _ := m.Symbols()
+	var ranges []Function
+	for _, symbol := range symbols{
+		if !symbol.IsDefined() || "" == symbol.Name{
+			continue
+		}
+		if section, err := m.SectionForVA(symbol.Value); nil == err && section.Attributes().Has(S_ATTR_SOME_INSTRUCTIONS){
+			ranges = append(ranges, Function{Address: symbol.Value, Size: section.Address + section.Size - symbol.Value, Name: symbol.Name})
+		}
+	}
+	sort.Slice(ranges, func(i, j int)bool{
+		return ranges[i].Address < ranges[j].Address
+	})
+	for i := 0; i + 1 < len(ranges); i++{
+		if end := ranges[i+1].Address; end < ranges[i].Address + ranges[i].Size{
+			ranges[i].Size = end - ranges[i].Address
+		}
+	}
+	return ranges
+}
+
+//State shared by the architecture specific disassemblers.
+type disassembly struct{
+	s *symbolizer
+	dataInCode []DataInCode
+}
+
+func (m FileHeader) newDisassembly()(*disassembly, error){
+	//an entry outside the mapped file cannot cover code, so only an unreadable table stops the disassembly
+	dataInCode, err := m.DataInCode()
+	if nil == dataInCode && nil != err{
+		return nil, err
+	}
+	return &disassembly{s: m.newSymbolizer(), dataInCode: dataInCode}, nil
+}
+
+func (d *disassembly) disassemble(start uint64, end uint64)([]Instruction, error){
+	m := d.s.m
+	section, err := m.SectionForVA(start)
+	if nil != err{
+		return nil, err
+	}
+	if end <= start || end - section.Address > section.Size{
+		return nil, fmt.Errorf("0x%x-0x%x is not inside %s", start, end, sectionLabel(*section))
+	}
+	data, err := m.SectionData(*section)
+	if nil != err{
+		return nil, err
+	}
+	code := data[start - section.Address : end - section.Address]
+
+	switch m.Header.Cpu{
+	case CPU_TYPE_ARM64:
+		return d.arm64(code, start), nil
+	case CPU_TYPE_X86_64:
+		return d.x86_64(code, start), nil
+	}
+	return nil, fmt.Errorf("%s: %w", ArchName(m.Header.Cpu, m.Header.SubCpu), ErrUnsupportedArch)
+}
+
+//Returns the data-in-code range starting at or covering addr, or nil.
+func (d *disassembly) dataAt(addr uint64)*DataInCode{
+	for i := range d.dataInCode{
+		if d.dataInCode[i].Contains(addr){
+			return &d.dataInCode[i]
+		}
+	}
+	return nil
+}
+
+//Shows the data-in-code range covering code[0] as directives sized by its kind: jump table entries as
+//.byte, .short or .long, anything else as .long words and trailing .bytes.
+func (d *disassembly) data(entry *DataInCode, code []byte, addr uint64)[]Instruction{
+	size := map[uint16]int{DICE_KIND_JUMP_TABLE8: 1, DICE_KIND_JUMP_TABLE16: 2}[entry.Kind]
+	if 0 == size{
+		size = 4
+	}
+	remaining := int(entry.Address + uint64(entry.Length) - addr)
+	if remaining > len(code){
+		remaining = len(code)
+	}
+
+	var data []Instruction
+	for i := 0; i < remaining; {
+		n := size
+		if i + n > remaining{
+			n = 1
+		}
+		var text string
+		switch n{
+		case 1:
+			text = fmt.Sprintf(".byte 0x%x", code[i])
+		case 2:
+			text = fmt.Sprintf(".short 0x%x", binary.LittleEndian.Uint16(code[i:]))
+		default:
+			text = fmt.Sprintf(".long 0x%x", binary.LittleEndian.Uint32(code[i:]))
+		}
+		instruction := Instruction{Address: addr + uint64(i), Bytes: hex.EncodeToString(code[i : i+n]), Text: text, Data: true}
+		if 0 == i && addr == entry.Address{
+			instruction.Comment = entry.KindName()
+		}
+		data = append(data, instruction)
+		i += n
+	}
+	return data
+}
+
+//Names the instruction if a function starts at it, returning true if so.
+func (d *disassembly) label(instruction *Instruction)bool{
+	if name, ok := d.s.symbols[instruction.Address]; ok{
+		instruction.Label = name
+	} else if function := FunctionAt(d.s.functions, instruction.Address); nil != function && function.Address == instruction.Address{
+		instruction.Label = fmt.Sprintf("func_%x", function.Address)
+	}
+	return "" != instruction.Label
+}
+
+//Records what target is as the instruction's reference and comment.
+func (d *disassembly) refer(instruction *Instruction, target uint64){
+	if reference := d.s.resolve(target); "" != reference.Kind{
+		instruction.Reference = &reference
+		instruction.Comment = reference.String()
+	}
+}
+
+//Looks a function up by name, with or without the leading underscore or demangled, or by an address inside it.
+func findFunction(functions []Function, name string)*Function{
+	if address, err := strconv.ParseUint(name, 0, 64); nil == err{
+		return FunctionAt(functions, address)
+	}
+	for i := range functions{
+		if nameMatches(name, functions[i].Name){
+			return &functions[i]
+		}
+	}
+	return nil
+}
+
+//True if query names the symbol: as it is, without its leading underscore, or in its demangled form.
+func nameMatches(query string, name string)bool{
+	return query == name || "_" + query == name || ("" != name && query == demangle.Demangle(name))
+}
+
+//Replaces the text of a PC relative operand with the absolute target, so "bl .+0x40" reads "bl 0x100003f60".
+func absoluteOperand(text string, relative string, target uint64)string{
+	return strings.Replace(text, relative, fmt.Sprintf("0x%x", target), 1)
+}
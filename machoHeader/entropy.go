@@ -0,0 +1,262 @@
+package machoHeader
+
+import (
+	"debug/macho"
+	"fmt"
+	"math"
+)
+
+const (
+	ENTROPY_WINDOW		= 4096		//default sliding window size in bytes, windows overlap by half
+	HIGH_ENTROPY		= 7.2		//bits per byte above which data is most likely compressed or encrypted
+)
+
+//What EntropyReport flags
+const (
+	ANOMALY_HIGH_ENTROPY_CODE		= "high_entropy_code"			//__TEXT or an executable section is compressed or encrypted
+	ANOMALY_HIGH_ENTROPY_WINDOW		= "high_entropy_window"			//only part of an executable section is
+	ANOMALY_ZERO_VM_SIZE			= "zero_vm_size"				//a segment has bytes in the file but is not mapped
+	ANOMALY_SECTION_OUTSIDE			= "section_outside_segment"
+	ANOMALY_TRUNCATED				= "truncated"					//a segment or section runs past the end of the file
+	ANOMALY_UNUSUAL_SEGMENT			= "unusual_segment_name"
+	ANOMALY_WRITABLE_EXECUTABLE		= "writable_executable"
+)
+
+//How much each anomaly adds to the suspiciousness score, which is capped at 100
+var anomalyScores = map[string]int{
+	ANOMALY_HIGH_ENTROPY_CODE:		40,
+	ANOMALY_HIGH_ENTROPY_WINDOW:	15,
+	ANOMALY_ZERO_VM_SIZE:			25,
+	ANOMALY_SECTION_OUTSIDE:		25,
+	ANOMALY_TRUNCATED:				20,
+	ANOMALY_UNUSUAL_SEGMENT:		10,
+	ANOMALY_WRITABLE_EXECUTABLE:	20,
+}
+
+//Segment names ld64, the kernel linker and the toolchains emit
+var knownSegments = map[string]bool{
+	"__PAGEZERO": true, "__TEXT": true, "__TEXT_EXEC": true, "__DATA": true, "__DATA_CONST": true, "__DATA_DIRTY": true,
+	"__AUTH": true, "__AUTH_CONST": true, "__OBJC": true, "__OBJC_CONST": true, "__IMPORT": true, "__LINKEDIT": true,
+	"__RESTRICT": true, "__LLVM": true, "__CTF": true, "__DWARF": true, "__PRELINK_TEXT": true, "__PRELINK_INFO": true,
+	"__PRELINK_DATA": true, "__KLD": true, "__KLDDATA": true, "__LAST": true, "__BOOTDATA": true, "__CONST": true,
+	"__PPLTEXT": true, "__PPLDATA": true, "__PPLDATA_CONST": true, "__HIB": true, "__UNIXSTACK": true,
+}
+
+//Entropy of a segment or section, over all of it and over each window.
+type EntropyRegion struct{
+	Name string					`json:"name"`			//"__TEXT" or "__TEXT,__text"
+	Segment string				`json:"segment,omitempty"`	//for sections, the segment command listing it
+	Offset uint64				`json:"offset"`
+	Size uint64					`json:"size"`
+	Entropy float64				`json:"entropy"`		//bits per byte, 0 to 8
+	MaxWindow float64			`json:"max_window"`
+	MaxWindowOffset uint64		`json:"max_window_offset"`
+	Windows []float64			`json:"windows,omitempty"`
+}
+
+type Anomaly struct{
+	Kind string					`json:"kind"`
+	Description string			`json:"description"`
+	Score int					`json:"score"`
+}
+
+//Result of EntropyReport. Suspiciousness adds up the anomalies' scores, 0 for an ordinary toolchain build and
+//100 for something which looks packed or hand crafted.
+type EntropyReport struct{
+	File string					`json:"file,omitempty"`
+	Window int					`json:"window"`
+	Segments []EntropyRegion	`json:"segments"`
+	Sections []EntropyRegion	`json:"sections"`
+	Anomalies []Anomaly			`json:"anomalies"`
+	Suspiciousness int			`json:"suspiciousness"`
+}
+
+/*
+	//////////////////////////////////////// PUBLIC METHODS ////////////////////////////////////////
+*/
+
+//Shannon entropy of data in bits per byte.
+func Entropy(data []byte)float64{
+	if 0 == len(data){
+		return 0
+	}
+	var counts [256]int
+	for _, b := range data{
+		counts[b]++
+	}
+	entropy := 0.0
+	for _, count := range counts{
+		if 0 != count{
+			p := float64(count) / float64(len(data))
+			entropy -= p * math.Log2(p)
+		}
+	}
+	return entropy
+}
+
+//Entropy of each window of data, sliding by half a window. Data shorter than a window is one window.
+func WindowEntropy(data []byte, window int)[]float64{
+	if window <= 0 || len(data) <= window{
+		return []float64{Entropy(data)}
+	}
+	step := windowStep(window)
+	var windows []float64
+	for start := 0; ; start += step{
+		if start + window >= len(data){
+			windows = append(windows, Entropy(data[start:]))
+			break
+		}
+		windows = append(windows, Entropy(data[start : start + window]))
+	}
+	return windows
+}
+
+func (a Anomaly) String()string{
+	return fmt.Sprintf("%s (+%d)", a.Description, a.Score)
+}
+
+/*
+	//////////////////////////////////////// PUBLIC CLASS METHODS ////////////////////////////////////////
+*/
+
+//Measures the entropy of every segment and section, in windows of window bytes (ENTROPY_WINDOW if 0), and
+//looks for what packers and hand edited binaries leave behind: compressed or encrypted code, segments with
+//file contents that are never mapped, sections outside their segment, contents past the end of the file,
+//writable and executable segments and segment names no toolchain produces.
+func (m FileHeader) EntropyReport(window int)EntropyReport{
+	if window <= 0{
+		window = ENTROPY_WINDOW
+	}
+	return m.entropyReport(window, true)
+}
+
+/*
+	//////////////////////////////////////// PRIVATE CLASS METHODS ////////////////////////////////////////
+*/
+
+//Builds EntropyReport. Without measureAll only the regions which can raise an anomaly, __TEXT and the
+//executable sections, are measured and listed, which finds the same anomalies for less work.
+func (m FileHeader) entropyReport(window int, measureAll bool)EntropyReport{
+	report := EntropyReport{Window: window, Segments: []EntropyRegion{}, Sections: []EntropyRegion{}, Anomalies: []Anomaly{}}
+	add := func(kind string, format string, args ...interface{}){
+		report.Anomalies = append(report.Anomalies, Anomaly{Kind: kind, Description: fmt.Sprintf(format, args...), Score: anomalyScores[kind]})
+	}
+
+	//App Store binaries are FairPlay encrypted, so their code is expected to look random
+	encrypted := false
+	for _, c := range append(m.FindCommands(LC_ENCRYPTION_INFO), m.FindCommands(LC_ENCRYPTION_INFO_64)...){
+		if info, ok := c.(EncryptionInfoCommand); ok && 0 != info.CryptID{
+			encrypted = true
+		}
+	}
+
+	for i := range m.LoadCommands{
+		segment := &m.LoadCommands[i]
+		if LC_SEGMENT_64 != segment.Command{
+			continue
+		}
+		name := segment.SegmentName
+		//object files have a single unnamed segment
+		if !knownSegments[name] && !("" == name && macho.TypeObj == m.Header.Type){
+			add(ANOMALY_UNUSUAL_SEGMENT, "segment %q is not one the linker produces", name)
+		}
+		if 0 != segment.FileSize && 0 == segment.VmSize{
+			add(ANOMALY_ZERO_VM_SIZE, "segment %s has 0x%x bytes in the file but no VM size", name, segment.FileSize)
+		}
+		if segment.IsWritableExecutable(){
+			add(ANOMALY_WRITABLE_EXECUTABLE, "segment %s is writable and executable", name)
+		}
+
+		data, err := m.ReadBytes(segment.FileOffset, segment.FileSize)
+		if nil != err{
+			add(ANOMALY_TRUNCATED, "segment %s: %v", name, err)
+		} else if measureAll || "__TEXT" == name{
+			//only the entropy of the whole of __TEXT is checked, its windows are just listed
+			segmentWindow := window
+			if !measureAll{
+				segmentWindow = 0
+			}
+			region := entropyRegion(name, segment.FileOffset, data, segmentWindow)
+			report.Segments = append(report.Segments, region)
+			if "__TEXT" == name && !encrypted && region.Entropy > HIGH_ENTROPY{
+				add(ANOMALY_HIGH_ENTROPY_CODE, "segment __TEXT has entropy %.2f", region.Entropy)
+			}
+		}
+
+		for _, section := range segment.Sections{
+			label := sectionLabel(section)
+			if section.Address < segment.VmAddress || section.Address + section.Size > segment.VmAddress + segment.VmSize{
+				add(ANOMALY_SECTION_OUTSIDE, "section %s at 0x%x-0x%x is outside segment %s at 0x%x-0x%x", label, section.Address, section.Address + section.Size, name, segment.VmAddress, segment.VmAddress + segment.VmSize)
+			} else if !section.isZeroFill() && 0 != section.Size && (uint64(section.Offset) < segment.FileOffset || uint64(section.Offset) + section.Size > segment.FileOffset + segment.FileSize){
+				add(ANOMALY_SECTION_OUTSIDE, "section %s at file offset 0x%x-0x%x is outside segment %s", label, section.Offset, uint64(section.Offset) + section.Size, name)
+			}
+			if section.isZeroFill(){
+				continue
+			}
+
+			data, err := m.SectionData(section)
+			if nil != err{
+				add(ANOMALY_TRUNCATED, "section %s: %v", label, err)
+				continue
+			}
+			code := !encrypted && section.Attributes().Has(S_ATTR_SOME_INSTRUCTIONS)
+			if !measureAll && !code{
+				continue
+			}
+			region := entropyRegion(label, uint64(section.Offset), data, window)
+			region.Segment = name
+			report.Sections = append(report.Sections, region)
+			if !code{
+				continue
+			}
+			//__TEXT as a whole is diluted by the header and padding, so its code is checked on its own too
+			if region.Entropy > HIGH_ENTROPY{
+				add(ANOMALY_HIGH_ENTROPY_CODE, "section %s has entropy %.2f", label, region.Entropy)
+			} else if region.MaxWindow > HIGH_ENTROPY{
+				add(ANOMALY_HIGH_ENTROPY_WINDOW, "section %s has entropy %.2f at file offset 0x%x", label, region.MaxWindow, region.MaxWindowOffset)
+			}
+		}
+	}
+
+	report.Suspiciousness = suspiciousness(report.Anomalies)
+	return report
+}
+
+/*
+	//////////////////////////////////////// PRIVATE METHODS ////////////////////////////////////////
+*/
+
+//Adds up the anomalies' scores, capped at 100.
+func suspiciousness(anomalies []Anomaly)int{
+	score := 0
+	for _, anomaly := range anomalies{
+		score += anomaly.Score
+	}
+	if score > 100{
+		score = 100
+	}
+	return score
+}
+
+//Measures data as a whole and, unless window is 0, in windows.
+func entropyRegion(name string, offset uint64, data []byte, window int)EntropyRegion{
+	region := EntropyRegion{Name: name, Offset: offset, Size: uint64(len(data)), Entropy: Entropy(data)}
+	if 0 == window{
+		return region
+	}
+	region.Windows = WindowEntropy(data, window)
+	for i, entropy := range region.Windows{
+		if entropy > region.MaxWindow{
+			region.MaxWindow = entropy
+			region.MaxWindowOffset = offset + uint64(i * windowStep(window))
+		}
+	}
+	return region
+}
+
+func windowStep(window int)int{
+	if window < 2{
+		return 1
+	}
+	return window / 2
+}
@@ -0,0 +1,192 @@
+package machoHeader
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//taken from Library/Developer/CommandLineTools/SDKs/MacOSX10.15.sdk/usr/include/mach-o/loader.h
+const (
+	EXPORT_SYMBOL_FLAGS_KIND_MASK			= 0x03
+	EXPORT_SYMBOL_FLAGS_KIND_REGULAR		= 0x00
+	EXPORT_SYMBOL_FLAGS_KIND_THREAD_LOCAL	= 0x01
+	EXPORT_SYMBOL_FLAGS_KIND_ABSOLUTE		= 0x02
+	EXPORT_SYMBOL_FLAGS_WEAK_DEFINITION		= 0x04
+	EXPORT_SYMBOL_FLAGS_REEXPORT			= 0x08
+	EXPORT_SYMBOL_FLAGS_STUB_AND_RESOLVER	= 0x10
+	EXPORT_SYMBOL_FLAGS_STATIC_RESOLVER		= 0x20
+)
+
+//One symbol from the export trie. Address is relative to the start of the image (the __TEXT vmaddr).
+type Export struct{
+	Name string
+	Flags uint64
+	Address uint64
+	Resolver uint64			//stub and resolver exports only
+	Ordinal uint64			//re-exports only, the dylib the symbol comes from
+	ImportName string		//re-exports only, empty when the name is unchanged
+}
+
+/*
+	//////////////////////////////////////// PUBLIC METHODS ////////////////////////////////////////
+*/
+
+func (e Export) IsReexport()bool{
+	return 0 != e.Flags & EXPORT_SYMBOL_FLAGS_REEXPORT
+}
+
+func (e Export) IsWeak()bool{
+	return 0 != e.Flags & EXPORT_SYMBOL_FLAGS_WEAK_DEFINITION
+}
+
+func (e Export) String()string{
+	var attributes []string
+	switch e.Flags & EXPORT_SYMBOL_FLAGS_KIND_MASK{
+	case EXPORT_SYMBOL_FLAGS_KIND_THREAD_LOCAL:
+		attributes = append(attributes, "thread local")
+	case EXPORT_SYMBOL_FLAGS_KIND_ABSOLUTE:
+		attributes = append(attributes, "absolute")
+	}
+	if e.IsWeak(){
+		attributes = append(attributes, "weak")
+	}
+	if 0 != e.Flags & EXPORT_SYMBOL_FLAGS_STUB_AND_RESOLVER{
+		attributes = append(attributes, fmt.Sprintf("resolver 0x%x", e.Resolver))
+	}
+
+	s := fmt.Sprintf("0x%x %s", e.Address, e.Name)
+	if e.IsReexport(){
+		s = fmt.Sprintf("%s re-exported from ordinal %d", e.Name, e.Ordinal)
+		if "" != e.ImportName{
+			s += " as " + e.ImportName
+		}
+	}
+	if 0 != len(attributes){
+		s += " [" + strings.Join(attributes, ", ") + "]"
+	}
+	return s
+}
+
+/*
+	//////////////////////////////////////// PUBLIC CLASS METHODS ////////////////////////////////////////
+*/
+
+//Walks the export trie from LC_DYLD_EXPORTS_TRIE, or from LC_DYLD_INFO(_ONLY) in older images. When an image
+//has both, the LC_DYLD_EXPORTS_TRIE one is used. The exports are returned sorted by name. An image with
+//neither command exports nothing.
+func (m FileHeader) Exports()([]Export, error){
+	var offset, size uint32
+	found := false
+	for _, c := range m.FindCommands(LC_DYLD_EXPORTS_TRIE){
+		if trie, ok := c.(LinkeditDataCommand); ok{
+			offset, size, found = trie.DataOffset, trie.DataSize, true
+			break
+		}
+	}
+	for _, c := range append(m.FindCommands(LC_DYLD_INFO), m.FindCommands(LC_DYLD_INFO_ONLY)...){
+		if info, ok := c.(DyldInfoCommand); ok && !found{
+			offset, size, found = info.ExportOffset, info.ExportSize, true
+		}
+	}
+	if 0 == size{
+		return nil, nil
+	}
+	trie, err := m.ReadBytes(uint64(offset), uint64(size))
+	if nil != err{
+		return nil, fmt.Errorf("export trie: %w", err)
+	}
+
+	var exports []Export
+	visited := map[uint64]bool{}
+	var walk func(node uint64, prefix string)error
+	walk = func(node uint64, prefix string)error{
+		if visited[node]{
+			return fmt.Errorf("export trie: node 0x%x is reached twice", node)
+		}
+		visited[node] = true
+
+		position := node
+		terminalSize, err := readULEB128(trie, &position)
+		if nil != err{
+			return err
+		}
+		children := position + terminalSize
+		if 0 != terminalSize{
+			export := Export{Name: prefix}
+			if export.Flags, err = readULEB128(trie, &position); nil != err{
+				return err
+			}
+			if export.IsReexport(){
+				if export.Ordinal, err = readULEB128(trie, &position); nil != err{
+					return err
+				}
+				if position < uint64(len(trie)){
+					export.ImportName = cString(trie[position:])
+				}
+			} else {
+				if export.Address, err = readULEB128(trie, &position); nil != err{
+					return err
+				}
+				if 0 != export.Flags & EXPORT_SYMBOL_FLAGS_STUB_AND_RESOLVER{
+					if export.Resolver, err = readULEB128(trie, &position); nil != err{
+						return err
+					}
+				}
+			}
+			exports = append(exports, export)
+		}
+
+		if children >= uint64(len(trie)){
+			return fmt.Errorf("export trie: node 0x%x runs past the end of the trie", node)
+		}
+		position = children
+		count := int(trie[position])
+		position++
+		for i := 0; i < count; i++{
+			if position >= uint64(len(trie)){
+				return fmt.Errorf("export trie: node 0x%x runs past the end of the trie", node)
+			}
+			edge := cString(trie[position:])
+			position += uint64(len(edge)) + 1
+			child, err := readULEB128(trie, &position)
+			if nil != err{
+				return err
+			}
+			if err = walk(child, prefix + edge); nil != err{
+				return err
+			}
+		}
+		return nil
+	}
+
+	err = walk(0, "")
+	sort.Slice(exports, func(i, j int)bool{ return exports[i].Name < exports[j].Name })
+	return exports, err
+}
+
+/*
+	//////////////////////////////////////// PRIVATE METHODS ////////////////////////////////////////
+*/
+
+//Reads an unsigned LEB128 value at *position and moves *position past it.
+func readULEB128(data []byte, position *uint64)(uint64, error){
+	var value uint64
+	var shift uint
+	for{
+		if *position >= uint64(len(data)){
+			return 0, errors.New("ULEB128 runs past the end of the data")
+		}
+		b := data[*position]
+		*position++
+		if shift >= 64{
+			return 0, errors.New("ULEB128 is too large")
+		}
+		value |= uint64(b & 0x7f) << shift
+		shift += 7
+		if 0 == b & 0x80{
+			return value, nil
+		}
+	}
+}
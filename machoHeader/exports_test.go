@@ -0,0 +1,60 @@
+package machoHeader
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func exportNames(t *testing.T, m FileHeader)[]string{
+	t.Helper()
+	exports, err := m.Exports()
+	if nil != err{
+		t.Fatal(err)
+	}
+	var names []string
+	for _, export := range exports{
+		names = append(names, export.Name)
+	}
+	return names
+}
+
+//The fixture only has LC_DYLD_INFO_ONLY. This turns its LC_SOURCE_VERSION into an LC_DYLD_EXPORTS_TRIE for
+//the same trie and moves it in front, then points LC_DYLD_INFO_ONLY at an empty trie, so only a reader
+//which prefers LC_DYLD_EXPORTS_TRIE finds the exports.
+func TestExportsTriePreferred(t *testing.T){
+	m := loadFixture(t)
+	if names := exportNames(t, m); 2 != len(names) || "__mh_execute_header" != names[0] || "_main" != names[1]{
+		t.Fatalf("fixture exports %v", names)
+	}
+
+	data := append([]byte(nil), m.data...)
+	var commands, trie, info []byte
+	for _, c := range m.LoadCommands{
+		raw := append([]byte(nil), m.data[c.Offset : c.Offset + uint64(c.CommandSize)]...)
+		switch c.Command{
+		case LC_SOURCE_VERSION:
+			trie = raw
+		case LC_DYLD_INFO_ONLY:
+			info = raw
+		default:
+			commands = append(commands, raw...)
+		}
+	}
+	export := m.FindCommands(LC_DYLD_INFO_ONLY)[0].(DyldInfoCommand)
+	binary.LittleEndian.PutUint32(trie[0:], LC_DYLD_EXPORTS_TRIE)
+	binary.LittleEndian.PutUint32(trie[8:], export.ExportOffset)
+	binary.LittleEndian.PutUint32(trie[12:], export.ExportSize)
+	//two zero bytes are a root node with no terminal and no children, in the padding after the load commands
+	binary.LittleEndian.PutUint32(info[40:], 0x600)
+	binary.LittleEndian.PutUint32(info[44:], 2)
+
+	reordered := append(append(append([]byte(nil), trie...), info...), commands...)
+	copy(data[MACH_HEADER_64_SIZE:], reordered)
+	patched, err := ParseBytes(data)
+	if nil != err{
+		t.Fatal(err)
+	}
+	if names := exportNames(t, patched); 2 != len(names) || "_main" != names[1]{
+		t.Errorf("exports %v, want the ones from LC_DYLD_EXPORTS_TRIE", names)
+	}
+}
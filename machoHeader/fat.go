@@ -0,0 +1,301 @@
+package machoHeader
+
+import (
+	"debug/macho"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+//taken from Library/Developer/CommandLineTools/SDKs/MacOSX10.15.sdk/usr/include/mach-o/fat.h
+//The fat header and fat_arch structures are always big endian.
+const (
+	FAT_MAGIC		= 0xcafebabe
+	FAT_MAGIC_64	= 0xcafebabf
+
+	FAT_HEADER_SIZE		= 8
+	FAT_ARCH_SIZE		= 20
+	FAT_ARCH_64_SIZE	= 32
+
+	MAX_FAT_ALIGN		= 15		//MAXSECTALIGN, the largest alignment lipo accepts
+)
+
+type FatArch struct{
+	Cpu macho.Cpu
+	SubCpu uint32
+	Offset uint64
+	Size uint64
+	Align uint32		//power of 2
+}
+
+type FatFile struct{
+	Magic uint32
+	Arches []FatArch
+	data []byte
+}
+
+//Names used by lipo and the -arch flag of the compilers
+var archNames = []struct{
+	name string
+	cpu macho.Cpu
+	subCpu uint32
+}{
+	{"i386",		CPU_TYPE_I386,		CPU_SUBTYPE_I386_ALL},
+	{"x86_64",		CPU_TYPE_X86_64,	CPU_SUBTYPE_X86_64_ALL},
+	{"x86_64h",		CPU_TYPE_X86_64,	CPU_SUBTYPE_X86_64_H},
+	{"armv6",		CPU_TYPE_ARM,		CPU_SUBTYPE_ARM_V6},
+	{"armv7",		CPU_TYPE_ARM,		CPU_SUBTYPE_ARM_V7},
+	{"armv7s",		CPU_TYPE_ARM,		CPU_SUBTYPE_ARM_V7S},
+	{"armv7k",		CPU_TYPE_ARM,		CPU_SUBTYPE_ARM_V7K},
+	{"arm64",		CPU_TYPE_ARM64,		CPU_SUBTYPE_ARM64_ALL},
+	{"arm64v8",		CPU_TYPE_ARM64,		CPU_SUBTYPE_ARM64_V8},
+	{"arm64e",		CPU_TYPE_ARM64,		CPU_SUBTYPE_ARM64E},
+	{"arm64_32",	CPU_TYPE_ARM64_32,	CPU_SUBTYPE_ARM64_32_V8},
+	{"ppc",			CPU_TYPE_POWERPC,	CPU_SUBTYPE_POWERPC_ALL},
+	{"ppc64",		CPU_TYPE_POWERPC64,	CPU_SUBTYPE_POWERPC_ALL},
+}
+
+/*
+	//////////////////////////////////////// PUBLIC METHODS ////////////////////////////////////////
+*/
+
+func IsFat(data []byte)bool{
+	if len(data) < 4{
+		return false
+	}
+	magic := binary.BigEndian.Uint32(data[0:4])
+	return FAT_MAGIC == magic || FAT_MAGIC_64 == magic
+}
+
+//Parses the fat header of a universal binary.
+func LoadFat(data []byte)(FatFile, error){
+	var fat FatFile
+	fat.data = data
+
+	if !IsFat(data) || len(data) < FAT_HEADER_SIZE{
+		return fat, errors.New("not a universal binary")
+	}
+	fat.Magic = binary.BigEndian.Uint32(data[0:4])
+	count := binary.BigEndian.Uint32(data[4:8])
+
+	archSize := FAT_ARCH_SIZE
+	if FAT_MAGIC_64 == fat.Magic{
+		archSize = FAT_ARCH_64_SIZE
+	}
+	if uint64(FAT_HEADER_SIZE) + uint64(count) * uint64(archSize) > uint64(len(data)){
+		return fat, fmt.Errorf("%d fat_arch entries do not fit in the file", count)
+	}
+
+	for i := 0; i < int(count); i++{
+		entry := data[FAT_HEADER_SIZE + i * archSize:]
+		arch := FatArch{Cpu: macho.Cpu(binary.BigEndian.Uint32(entry[0:4])), SubCpu: binary.BigEndian.Uint32(entry[4:8])}
+		if FAT_MAGIC_64 == fat.Magic{
+			arch.Offset = binary.BigEndian.Uint64(entry[8:16])
+			arch.Size = binary.BigEndian.Uint64(entry[16:24])
+			arch.Align = binary.BigEndian.Uint32(entry[24:28])
+		} else {
+			arch.Offset = uint64(binary.BigEndian.Uint32(entry[8:12]))
+			arch.Size = uint64(binary.BigEndian.Uint32(entry[12:16]))
+			arch.Align = binary.BigEndian.Uint32(entry[16:20])
+		}
+		//written so that a crafted offset and size cannot wrap around
+		if arch.Offset > uint64(len(data)) || arch.Size > uint64(len(data)) - arch.Offset{
+			return fat, fmt.Errorf("%s slice at 0x%x of size 0x%x is past the end of the file", ArchName(arch.Cpu, arch.SubCpu), arch.Offset, arch.Size)
+		}
+		if arch.Align > MAX_FAT_ALIGN{
+			return fat, fmt.Errorf("%s slice has alignment 2^%d, more than 2^%d", ArchName(arch.Cpu, arch.SubCpu), arch.Align, MAX_FAT_ALIGN)
+		}
+		fat.Arches = append(fat.Arches, arch)
+	}
+	return fat, nil
+}
+
+//Builds a universal binary out of thin images, like lipo -create. Each slice is aligned to the page size of
+//its cpu type and slices are ordered by alignment, which is the order lipo uses. Slices are written with
+//Bytes, so edits made to the headers are kept.
+func CreateFat(headers []FileHeader)([]byte, error){
+	if 0 == len(headers){
+		return nil, errors.New("no input files")
+	}
+
+	type slice struct{
+		arch FatArch
+		data []byte
+	}
+	var slices []slice
+	for i, header := range headers{
+		data, err := header.Bytes()
+		if nil != err{
+			return nil, fmt.Errorf("input %d: %w", i, err)
+		}
+		for _, other := range slices{
+			if other.arch.Cpu == header.Header.Cpu && other.arch.SubCpu &^ CPU_SUBTYPE_MASK == header.Header.SubCpu &^ CPU_SUBTYPE_MASK{
+				return nil, fmt.Errorf("more than one input for %s", ArchName(header.Header.Cpu, header.Header.SubCpu))
+			}
+		}
+		arch := FatArch{Cpu: header.Header.Cpu, SubCpu: header.Header.SubCpu, Size: uint64(len(data)), Align: sliceAlign(header.Header.Cpu)}
+		slices = append(slices, slice{arch, data})
+	}
+	sort.SliceStable(slices, func(i, j int)bool{ return slices[i].arch.Align < slices[j].arch.Align })
+
+	arches := make([]FatArch, len(slices))
+	offset := uint64(FAT_HEADER_SIZE + FAT_ARCH_SIZE * len(slices))
+	for i := range slices{
+		offset = alignUp(offset, uint64(1) << slices[i].arch.Align)
+		slices[i].arch.Offset = offset
+		arches[i] = slices[i].arch
+		offset += slices[i].arch.Size
+	}
+	if offset > 0xffffffff{
+		return nil, errors.New("universal binary would be larger than 4GB")
+	}
+
+	out := make([]byte, offset)
+	writeFatHeader(out, FAT_MAGIC, arches)
+	for _, s := range slices{
+		copy(out[s.arch.Offset:], s.data)
+	}
+	return out, nil
+}
+
+//Returns the lipo name of an architecture, e.g. "arm64e", falling back to the machine.h names.
+func ArchName(cpu macho.Cpu, subCpu uint32)string{
+	for _, arch := range archNames{
+		if arch.cpu == cpu && arch.subCpu == subCpu &^ CPU_SUBTYPE_MASK{
+			return arch.name
+		}
+	}
+	return CPUTypeName(cpu) + "/" + CPUSubtypeName(cpu, subCpu)
+}
+
+func ParseArch(name string)(macho.Cpu, uint32, error){
+	for _, arch := range archNames{
+		if arch.name == name{
+			return arch.cpu, arch.subCpu, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("unknown architecture %s", name)
+}
+
+/*
+	//////////////////////////////////////// PUBLIC CLASS METHODS ////////////////////////////////////////
+*/
+
+//Returns the index of the slice for an architecture. arm64 does not match arm64e.
+func (f FatFile) Find(cpu macho.Cpu, subCpu uint32)int{
+	for i, arch := range f.Arches{
+		if arch.Cpu == cpu && arch.SubCpu &^ CPU_SUBTYPE_MASK == subCpu &^ CPU_SUBTYPE_MASK{
+			return i
+		}
+	}
+	return -1
+}
+
+//Returns the bytes of one slice, which is a complete thin Mach-O file.
+func (f FatFile) SliceData(index int)[]byte{
+	arch := f.Arches[index]
+	return f.data[arch.Offset : arch.Offset + arch.Size]
+}
+
+//Parses one slice.
+func (f FatFile) Slice(index int)FileHeader{
+	return LoadBytes(f.SliceData(index))
+}
+
+//Same as Slice, returning a malformed slice as an error.
+func (f FatFile) ParseSlice(index int)(FileHeader, error){
+	return ParseBytes(f.SliceData(index))
+}
+
+//Equivalent of lipo -thin.
+func (f FatFile) Thin(cpu macho.Cpu, subCpu uint32)([]byte, error){
+	index := f.Find(cpu, subCpu)
+	if -1 == index{
+		return nil, fmt.Errorf("universal binary does not contain %s", ArchName(cpu, subCpu))
+	}
+	out := make([]byte, f.Arches[index].Size)
+	copy(out, f.SliceData(index))
+	return out, nil
+}
+
+//Equivalent of lipo -remove. The remaining slices keep their alignment and are packed again, behind a header
+//of the same kind as the input's.
+func (f FatFile) Remove(cpu macho.Cpu, subCpu uint32)([]byte, error){
+	index := f.Find(cpu, subCpu)
+	if -1 == index{
+		return nil, fmt.Errorf("universal binary does not contain %s", ArchName(cpu, subCpu))
+	}
+	if 1 == len(f.Arches){
+		return nil, errors.New("cannot remove the only architecture")
+	}
+
+	archSize := FAT_ARCH_SIZE
+	if FAT_MAGIC_64 == f.Magic{
+		archSize = FAT_ARCH_64_SIZE
+	}
+	var arches []FatArch
+	offset := uint64(FAT_HEADER_SIZE + archSize * (len(f.Arches) - 1))
+	for i, arch := range f.Arches{
+		if i == index{
+			continue
+		}
+		offset = alignUp(offset, uint64(1) << arch.Align)
+		moved := arch
+		moved.Offset = offset
+		arches = append(arches, moved)
+		offset += arch.Size
+	}
+	if FAT_MAGIC == f.Magic && offset > 0xffffffff{
+		return nil, errors.New("universal binary would be larger than 4GB")
+	}
+
+	out := make([]byte, offset)
+	writeFatHeader(out, f.Magic, arches)
+	j := 0
+	for i := range f.Arches{
+		if i == index{
+			continue
+		}
+		copy(out[arches[j].Offset:], f.SliceData(i))
+		j++
+	}
+	return out, nil
+}
+
+/*
+	//////////////////////////////////////// PRIVATE METHODS ////////////////////////////////////////
+*/
+
+//lipo aligns each slice to the segment alignment of its architecture
+func sliceAlign(cpu macho.Cpu)uint32{
+	switch cpu{
+	case CPU_TYPE_ARM, CPU_TYPE_ARM64, CPU_TYPE_ARM64_32:
+		return 14
+	default:
+		return 12
+	}
+}
+
+//FAT_MAGIC_64 entries are fat_arch_64, with 64 bit offsets and sizes and a reserved word at the end.
+func writeFatHeader(out []byte, magic uint32, arches []FatArch){
+	binary.BigEndian.PutUint32(out[0:4], magic)
+	binary.BigEndian.PutUint32(out[4:8], uint32(len(arches)))
+	for i, arch := range arches{
+		if FAT_MAGIC_64 == magic{
+			entry := out[FAT_HEADER_SIZE + i * FAT_ARCH_64_SIZE:]
+			binary.BigEndian.PutUint32(entry[0:4], uint32(arch.Cpu))
+			binary.BigEndian.PutUint32(entry[4:8], arch.SubCpu)
+			binary.BigEndian.PutUint64(entry[8:16], arch.Offset)
+			binary.BigEndian.PutUint64(entry[16:24], arch.Size)
+			binary.BigEndian.PutUint32(entry[24:28], arch.Align)
+			continue
+		}
+		entry := out[FAT_HEADER_SIZE + i * FAT_ARCH_SIZE:]
+		binary.BigEndian.PutUint32(entry[0:4], uint32(arch.Cpu))
+		binary.BigEndian.PutUint32(entry[4:8], arch.SubCpu)
+		binary.BigEndian.PutUint32(entry[8:12], uint32(arch.Offset))
+		binary.BigEndian.PutUint32(entry[12:16], uint32(arch.Size))
+		binary.BigEndian.PutUint32(entry[16:20], arch.Align)
+	}
+}
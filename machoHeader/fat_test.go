@@ -0,0 +1,165 @@
+package machoHeader
+
+import (
+	"bytes"
+	"debug/macho"
+	"encoding/binary"
+	"testing"
+)
+
+//Returns the x86_64 fixture and a copy relabelled as arm64, which is enough for lipo to treat it as a
+//second architecture.
+func fatInputs(t *testing.T)(FileHeader, FileHeader){
+	t.Helper()
+	x86 := loadFixture(t)
+	data := append([]byte(nil), x86.data...)
+	binary.LittleEndian.PutUint32(data[4:], uint32(CPU_TYPE_ARM64))
+	binary.LittleEndian.PutUint32(data[8:], CPU_SUBTYPE_ARM64_ALL)
+	arm, err := ParseBytes(data)
+	if nil != err{
+		t.Fatal(err)
+	}
+	return x86, arm
+}
+
+func TestCreateThinRemove(t *testing.T){
+	x86, arm := fatInputs(t)
+	out, err := CreateFat([]FileHeader{arm, x86})
+	if nil != err{
+		t.Fatal(err)
+	}
+	fat, err := LoadFat(out)
+	if nil != err{
+		t.Fatal(err)
+	}
+	if FAT_MAGIC != fat.Magic || 2 != len(fat.Arches){
+		t.Fatalf("magic 0x%x with %d slices", fat.Magic, len(fat.Arches))
+	}
+	//slices are ordered by alignment, x86_64 at 2^12 before arm64 at 2^14
+	if CPU_TYPE_X86_64 != fat.Arches[0].Cpu || 12 != fat.Arches[0].Align || 0x1000 != fat.Arches[0].Offset{
+		t.Errorf("first slice %+v", fat.Arches[0])
+	}
+	if CPU_TYPE_ARM64 != fat.Arches[1].Cpu || 14 != fat.Arches[1].Align || 0 != fat.Arches[1].Offset % 0x4000{
+		t.Errorf("second slice %+v", fat.Arches[1])
+	}
+
+	for _, input := range []FileHeader{x86, arm}{
+		thin, err := fat.Thin(input.Header.Cpu, input.Header.SubCpu)
+		if nil != err{
+			t.Fatal(err)
+		}
+		if !bytes.Equal(input.data, thin){
+			t.Errorf("thin %s differs from the input", ArchName(input.Header.Cpu, input.Header.SubCpu))
+		}
+	}
+	if _, err := fat.Thin(CPU_TYPE_ARM64, CPU_SUBTYPE_ARM64E); nil == err{
+		t.Error("arm64e matched the arm64 slice")
+	}
+
+	removed, err := fat.Remove(CPU_TYPE_X86_64, CPU_SUBTYPE_X86_64_ALL)
+	if nil != err{
+		t.Fatal(err)
+	}
+	left, err := LoadFat(removed)
+	if nil != err{
+		t.Fatal(err)
+	}
+	if 1 != len(left.Arches) || CPU_TYPE_ARM64 != left.Arches[0].Cpu || 0x4000 != left.Arches[0].Offset{
+		t.Fatalf("after remove %+v", left.Arches)
+	}
+	if !bytes.Equal(arm.data, left.SliceData(0)){
+		t.Error("remaining slice differs from the input")
+	}
+	if _, err := left.Remove(CPU_TYPE_ARM64, CPU_SUBTYPE_ARM64_ALL); nil == err{
+		t.Error("removed the only architecture")
+	}
+
+	if _, err := CreateFat([]FileHeader{x86, x86}); nil == err{
+		t.Error("created a universal binary with two x86_64 slices")
+	}
+}
+
+//Slices are serialized from the headers, not copied from the bytes they were loaded from.
+func TestCreateFatEdited(t *testing.T){
+	x86, arm := fatInputs(t)
+	if err := arm.AddRpath("@loader_path/Frameworks"); nil != err{
+		t.Fatal(err)
+	}
+	out, err := CreateFat([]FileHeader{x86, arm})
+	if nil != err{
+		t.Fatal(err)
+	}
+	fat, err := LoadFat(out)
+	if nil != err{
+		t.Fatal(err)
+	}
+	slice, err := fat.ParseSlice(1)
+	if nil != err{
+		t.Fatal(err)
+	}
+	if rpaths := slice.Rpaths(); 1 != len(rpaths) || "@loader_path/Frameworks" != rpaths[0]{
+		t.Errorf("arm64 slice rpaths %v", rpaths)
+	}
+
+	if _, err := CreateFat([]FileHeader{x86, {}}); nil == err{
+		t.Error("created a universal binary from a FileHeader which was never loaded")
+	}
+}
+
+func TestFatMagic64(t *testing.T){
+	x86, arm := fatInputs(t)
+	arches := []FatArch{
+		{Cpu: CPU_TYPE_X86_64, SubCpu: CPU_SUBTYPE_X86_64_ALL, Offset: 0x1000, Size: uint64(len(x86.data)), Align: 12},
+		{Cpu: CPU_TYPE_ARM64, SubCpu: CPU_SUBTYPE_ARM64_ALL, Offset: 0x8000, Size: uint64(len(arm.data)), Align: 14},
+	}
+	out := make([]byte, 0x8000 + len(arm.data))
+	writeFatHeader(out, FAT_MAGIC_64, arches)
+	copy(out[0x1000:], x86.data)
+	copy(out[0x8000:], arm.data)
+
+	fat, err := LoadFat(out)
+	if nil != err{
+		t.Fatal(err)
+	}
+	if FAT_MAGIC_64 != fat.Magic || 2 != len(fat.Arches) || arches[1] != fat.Arches[1]{
+		t.Fatalf("magic 0x%x arches %+v", fat.Magic, fat.Arches)
+	}
+	slice, err := fat.ParseSlice(1)
+	if nil != err || CPU_TYPE_ARM64 != slice.Header.Cpu{
+		t.Errorf("slice 1: %v, cpu %v", err, slice.Header.Cpu)
+	}
+
+	removed, err := fat.Remove(CPU_TYPE_ARM64, CPU_SUBTYPE_ARM64_ALL)
+	if nil != err{
+		t.Fatal(err)
+	}
+	left, err := LoadFat(removed)
+	if nil != err{
+		t.Fatal(err)
+	}
+	if FAT_MAGIC_64 != left.Magic || 1 != len(left.Arches) || !bytes.Equal(x86.data, left.SliceData(0)){
+		t.Errorf("after remove magic 0x%x arches %+v", left.Magic, left.Arches)
+	}
+}
+
+func TestLoadFatMalformed(t *testing.T){
+	entry := func(magic uint32, arch FatArch)[]byte{
+		out := make([]byte, 0x2000)
+		writeFatHeader(out, magic, []FatArch{arch})
+		return out
+	}
+	cases := map[string][]byte{
+		"truncated header":		{0xca, 0xfe, 0xba, 0xbe, 0, 0},
+		"too many arches":		{0xca, 0xfe, 0xba, 0xbe, 0, 0, 0, 200},
+		"past the end":			entry(FAT_MAGIC, FatArch{Cpu: macho.CpuAmd64, Offset: 0x1000, Size: 0x1001, Align: 12}),
+		"offset past the end":	entry(FAT_MAGIC, FatArch{Cpu: macho.CpuAmd64, Offset: 0x3000, Size: 0, Align: 12}),
+		"wrapping size":		entry(FAT_MAGIC_64, FatArch{Cpu: macho.CpuAmd64, Offset: 0x1000, Size: 0xfffffffffffff000, Align: 12}),
+		"wrapping offset":		entry(FAT_MAGIC_64, FatArch{Cpu: macho.CpuAmd64, Offset: 0xffffffffffffff00, Size: 0x1000, Align: 12}),
+		"alignment":			entry(FAT_MAGIC, FatArch{Cpu: macho.CpuAmd64, Offset: 0x1000, Size: 0x1000, Align: 16}),
+	}
+	for name, data := range cases{
+		if _, err := LoadFat(data); nil == err{
+			t.Errorf("%s: LoadFat accepted a malformed header", name)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package machoHeader
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+//taken from Library/Developer/CommandLineTools/SDKs/MacOSX12.0.sdk/usr/include/mach-o/fixup-chains.h
+//With LC_DYLD_CHAINED_FIXUPS the pointers stored in the file are not addresses: each one carries the rebase
+//target or bind ordinal plus the offset to the next fixup on its page, in one of these formats.
+const (
+	DYLD_CHAINED_PTR_ARM64E					= 1
+	DYLD_CHAINED_PTR_64						= 2
+	DYLD_CHAINED_PTR_32						= 3
+	DYLD_CHAINED_PTR_32_CACHE				= 4
+	DYLD_CHAINED_PTR_32_FIRMWARE			= 5
+	DYLD_CHAINED_PTR_64_OFFSET				= 6
+	DYLD_CHAINED_PTR_ARM64E_KERNEL			= 7
+	DYLD_CHAINED_PTR_64_KERNEL_CACHE		= 8
+	DYLD_CHAINED_PTR_ARM64E_USERLAND		= 9
+	DYLD_CHAINED_PTR_ARM64E_FIRMWARE		= 10
+	DYLD_CHAINED_PTR_X86_64_KERNEL_CACHE	= 11
+	DYLD_CHAINED_PTR_ARM64E_USERLAND24		= 12
+
+	DYLD_CHAINED_IMPORT				= 1
+	DYLD_CHAINED_IMPORT_ADDEND		= 2
+	DYLD_CHAINED_IMPORT_ADDEND64	= 3
+)
+
+//taken from Library/Developer/CommandLineTools/SDKs/MacOSX10.15.sdk/usr/include/mach-o/loader.h
+const (
+	BIND_OPCODE_MASK								= 0xF0
+	BIND_IMMEDIATE_MASK								= 0x0F
+	BIND_OPCODE_DONE								= 0x00
+	BIND_OPCODE_SET_DYLIB_ORDINAL_IMM				= 0x10
+	BIND_OPCODE_SET_DYLIB_ORDINAL_ULEB				= 0x20
+	BIND_OPCODE_SET_DYLIB_SPECIAL_IMM				= 0x30
+	BIND_OPCODE_SET_SYMBOL_TRAILING_FLAGS_IMM		= 0x40
+	BIND_OPCODE_SET_TYPE_IMM						= 0x50
+	BIND_OPCODE_SET_ADDEND_SLEB						= 0x60
+	BIND_OPCODE_SET_SEGMENT_AND_OFFSET_ULEB			= 0x70
+	BIND_OPCODE_ADD_ADDR_ULEB						= 0x80
+	BIND_OPCODE_DO_BIND								= 0x90
+	BIND_OPCODE_DO_BIND_ADD_ADDR_ULEB				= 0xA0
+	BIND_OPCODE_DO_BIND_ADD_ADDR_IMM_SCALED			= 0xB0
+	BIND_OPCODE_DO_BIND_ULEB_TIMES_SKIPPING_ULEB	= 0xC0
+	BIND_OPCODE_THREADED							= 0xD0
+)
+
+//The chained fixups state UntagPointer needs, see PointerFixups.
+type PointerFixups struct{
+	Format uint16		//0 when pointers in the file are plain addresses
+	Base uint64			//__TEXT vmaddr, which rebase offsets count from
+	Imports []string	//by bind ordinal
+}
+
+/*
+	//////////////////////////////////////// PUBLIC CLASS METHODS ////////////////////////////////////////
+*/
+
+//Returns the pointer format used by the image's chained fixups, or 0 if it uses rebase and bind opcodes
+//(or has no fixups), in which case pointers in the file are plain addresses.
+func (m FileHeader) ChainedPointerFormat()uint16{
+	data := m.chainedFixups()
+	if len(data) < 28{
+		return 0
+	}
+	starts := uint64(binary.LittleEndian.Uint32(data[4:8]))
+	if starts + 4 > uint64(len(data)){
+		return 0
+	}
+	count := uint64(binary.LittleEndian.Uint32(data[starts:]))
+	for i := uint64(0); i < count && starts + 8 + 4 * i <= uint64(len(data)); i++{
+		segment := uint64(binary.LittleEndian.Uint32(data[starts + 4 + 4 * i:]))
+		//segments without fixups have no starts
+		if 0 != segment && starts + segment + 8 <= uint64(len(data)){
+			return binary.LittleEndian.Uint16(data[starts + segment + 6:])
+		}
+	}
+	return 0
+}
+
+//Returns the names of the symbols chained binds refer to, indexed by bind ordinal.
+func (m FileHeader) ChainedImports()([]string, error){
+	data := m.chainedFixups()
+	if 0 == len(data){
+		return nil, nil
+	}
+	if len(data) < 28{
+		return nil, fmt.Errorf("chained fixups header is only %d bytes", len(data))
+	}
+	importsOffset := uint64(binary.LittleEndian.Uint32(data[8:12]))
+	symbolsOffset := uint64(binary.LittleEndian.Uint32(data[12:16]))
+	count := uint64(binary.LittleEndian.Uint32(data[16:20]))
+	format := binary.LittleEndian.Uint32(data[20:24])
+
+	entrySize := map[uint32]uint64{DYLD_CHAINED_IMPORT: 4, DYLD_CHAINED_IMPORT_ADDEND: 8, DYLD_CHAINED_IMPORT_ADDEND64: 16}[format]
+	if 0 == entrySize{
+		return nil, fmt.Errorf("unknown chained imports format %d", format)
+	}
+	if importsOffset + count * entrySize > uint64(len(data)){
+		return nil, fmt.Errorf("%d chained imports do not fit in LC_DYLD_CHAINED_FIXUPS", count)
+	}
+
+	names := make([]string, count)
+	for i := range names{
+		entry := data[importsOffset + uint64(i) * entrySize:]
+		var nameOffset uint64
+		if DYLD_CHAINED_IMPORT_ADDEND64 == format{
+			nameOffset = binary.LittleEndian.Uint64(entry) >> 32
+		} else {
+			nameOffset = uint64(binary.LittleEndian.Uint32(entry) >> 9)
+		}
+		if symbolsOffset + nameOffset < uint64(len(data)){
+			names[i] = cString(data[symbolsOffset + nameOffset:])
+		}
+	}
+	return names, nil
+}
+
+//Turns a pointer read from the file into the address it points to. For binds the address is 0 and symbol
+//names the import instead, when it can be found. This parses the chained fixups on every call, code untagging
+//many pointers should get PointerFixups once and use its Untag.
+func (m FileHeader) UntagPointer(raw uint64)(target uint64, symbol string){
+	return m.PointerFixups().Untag(raw)
+}
+
+//Reads what untagging pointers needs from LC_DYLD_CHAINED_FIXUPS: the pointer format, the image base and the
+//imports binds refer to.
+func (m FileHeader) PointerFixups()*PointerFixups{
+	fixups := &PointerFixups{Format: m.ChainedPointerFormat()}
+	if 0 == fixups.Format{
+		return fixups
+	}
+	if text := m.Segment("__TEXT"); nil != text{
+		fixups.Base = text.VmAddress
+	}
+	fixups.Imports, _ = m.ChainedImports()
+	return fixups
+}
+
+//See UntagPointer.
+func (f *PointerFixups) Untag(raw uint64)(target uint64, symbol string){
+	if 0 == f.Format || 0 == raw{
+		return raw, ""
+	}
+
+	bindSymbol := func(ordinal uint64)(uint64, string){
+		if ordinal < uint64(len(f.Imports)){
+			return 0, f.Imports[ordinal]
+		}
+		return 0, fmt.Sprintf("bind ordinal %d", ordinal)
+	}
+
+	switch f.Format{
+	case DYLD_CHAINED_PTR_ARM64E, DYLD_CHAINED_PTR_ARM64E_USERLAND, DYLD_CHAINED_PTR_ARM64E_USERLAND24:
+		auth := 0 != raw >> 63
+		bind := 0 != (raw >> 62) & 1
+		switch{
+		case bind && DYLD_CHAINED_PTR_ARM64E_USERLAND24 == f.Format:
+			return bindSymbol(raw & 0xffffff)
+		case bind:
+			return bindSymbol(raw & 0xffff)
+		case auth:
+			//auth rebases are always an offset from the image base
+			return f.Base + (raw & 0xffffffff), ""
+		}
+		target = raw & (1 << 43 - 1) | ((raw >> 43) & 0xff) << 56
+		if DYLD_CHAINED_PTR_ARM64E != f.Format{
+			target += f.Base
+		}
+		return target, ""
+	case DYLD_CHAINED_PTR_64, DYLD_CHAINED_PTR_64_OFFSET:
+		if 0 != raw >> 63{
+			return bindSymbol(raw & 0xffffff)
+		}
+		target = raw & (1 << 36 - 1) | ((raw >> 36) & 0xff) << 56
+		if DYLD_CHAINED_PTR_64_OFFSET == f.Format{
+			target += f.Base
+		}
+		return target, ""
+	}
+	//kernel and firmware formats are not used by user space images
+	return raw, ""
+}
+
+//Runs the bind, weak bind and lazy bind opcodes of LC_DYLD_INFO(_ONLY) and returns the symbol bound at each
+//address. Images using chained fixups have no bind opcodes, see UntagPointer instead.
+func (m FileHeader) Binds()(map[uint64]string, error){
+	binds := map[uint64]string{}
+	var info *DyldInfoCommand
+	for i := range m.LoadCommands{
+		if c, ok := m.LoadCommands[i].Decoded.(DyldInfoCommand); ok{
+			info = &c
+		}
+	}
+	if nil == info{
+		return binds, nil
+	}
+
+	var segments []*LoadCommand
+	for i := range m.LoadCommands{
+		if LC_SEGMENT_64 == m.LoadCommands[i].Command{
+			segments = append(segments, &m.LoadCommands[i])
+		}
+	}
+
+	tables := []struct{
+		offset, size uint32
+		lazy bool
+	}{
+		{info.BindOffset, info.BindSize, false},
+		{info.WeakBindOffset, info.WeakBindSize, false},
+		{info.LazyBindOffset, info.LazyBindSize, true},
+	}
+	for _, table := range tables{
+		if 0 == table.size{
+			continue
+		}
+		opcodes, err := m.ReadBytes(uint64(table.offset), uint64(table.size))
+		if nil != err{
+			return binds, fmt.Errorf("bind opcodes: %w", err)
+		}
+		if err = runBindOpcodes(opcodes, segments, table.lazy, binds); nil != err{
+			return binds, err
+		}
+	}
+	return binds, nil
+}
+
+/*
+	//////////////////////////////////////// PRIVATE METHODS ////////////////////////////////////////
+*/
+
+//Lazy bind info has a BIND_OPCODE_DONE after every symbol, the other tables end at the first one.
+func runBindOpcodes(opcodes []byte, segments []*LoadCommand, lazy bool, binds map[uint64]string)error{
+	var position, address uint64
+	symbol := ""
+	bind := func(){
+		binds[address] = symbol
+		address += 8
+	}
+	for position < uint64(len(opcodes)){
+		opcode := opcodes[position] & BIND_OPCODE_MASK
+		immediate := opcodes[position] & BIND_IMMEDIATE_MASK
+		position++
+
+		var value uint64
+		var err error
+		switch opcode{
+		case BIND_OPCODE_DONE:
+			if !lazy{
+				return nil
+			}
+		case BIND_OPCODE_SET_DYLIB_ORDINAL_IMM, BIND_OPCODE_SET_DYLIB_SPECIAL_IMM, BIND_OPCODE_SET_TYPE_IMM:
+		case BIND_OPCODE_SET_DYLIB_ORDINAL_ULEB, BIND_OPCODE_SET_ADDEND_SLEB:
+			//an SLEB has the same continuation bits as a ULEB, only the value is not needed
+			_, err = readULEB128(opcodes, &position)
+		case BIND_OPCODE_SET_SYMBOL_TRAILING_FLAGS_IMM:
+			symbol = cString(opcodes[position:])
+			position += uint64(len(symbol)) + 1
+		case BIND_OPCODE_SET_SEGMENT_AND_OFFSET_ULEB:
+			if int(immediate) >= len(segments){
+				return fmt.Errorf("bind opcodes: segment %d does not exist", immediate)
+			}
+			value, err = readULEB128(opcodes, &position)
+			address = segments[immediate].VmAddress + value
+		case BIND_OPCODE_ADD_ADDR_ULEB:
+			value, err = readULEB128(opcodes, &position)
+			address += value
+		case BIND_OPCODE_DO_BIND:
+			bind()
+		case BIND_OPCODE_DO_BIND_ADD_ADDR_ULEB:
+			value, err = readULEB128(opcodes, &position)
+			bind()
+			address += value
+		case BIND_OPCODE_DO_BIND_ADD_ADDR_IMM_SCALED:
+			bind()
+			address += uint64(immediate) * 8
+		case BIND_OPCODE_DO_BIND_ULEB_TIMES_SKIPPING_ULEB:
+			var count, skip uint64
+			if count, err = readULEB128(opcodes, &position); nil == err{
+				skip, err = readULEB128(opcodes, &position)
+			}
+			for i := uint64(0); i < count && nil == err; i++{
+				bind()
+				address += skip
+			}
+		default:
+			//BIND_OPCODE_THREADED was only used briefly before chained fixups
+			return fmt.Errorf("bind opcodes: unsupported opcode 0x%x", opcode)
+		}
+		if nil != err{
+			return fmt.Errorf("bind opcodes: %w", err)
+		}
+	}
+	return nil
+}
+
+/*
+	//////////////////////////////////////// PRIVATE CLASS METHODS ////////////////////////////////////////
+*/
+
+func (m FileHeader) chainedFixups()[]byte{
+	for i := range m.LoadCommands{
+		if c, ok := m.LoadCommands[i].Decoded.(LinkeditDataCommand); ok && LC_DYLD_CHAINED_FIXUPS == c.Command{
+			data, _ := m.ReadBytes(uint64(c.DataOffset), uint64(c.DataSize))
+			return data
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package machoHeader
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+//One function from LC_FUNCTION_STARTS. The linker records every function start, including those of static
+//functions whose symbols were stripped, so this is the function list of a stripped binary.
+type Function struct{
+	Address uint64		`json:"address"`
+	Size uint64			`json:"size"`			//up to the next start, or the end of the section for the last one
+	Name string			`json:"name,omitempty"`	//from the symbol table, empty when stripped
+}
+
+/*
+	//////////////////////////////////////// PUBLIC METHODS ////////////////////////////////////////
+*/
+
+//Returns the function containing addr, or nil.
+func FunctionAt(functions []Function, addr uint64)*Function{
+	i := sort.Search(len(functions), func(i int)bool{
+		return functions[i].Address > addr
+	})
+	if 0 == i || addr - functions[i-1].Address >= functions[i-1].Size{
+		return nil
+	}
+	return &functions[i-1]
+}
+
+/*
+	//////////////////////////////////////// PUBLIC CLASS METHODS ////////////////////////////////////////
+*/
+
+//Decodes LC_FUNCTION_STARTS into the functions of the image, sorted by address. The command holds ULEB128
+//deltas, the first one from the __TEXT vmaddr, terminated by a 0. Names come from defined symbols at the
+//same address, preferring external ones. Images without the command return an empty list.
+func (m FileHeader) Functions()([]Function, error){
+	found := m.FindCommands(LC_FUNCTION_STARTS)
+	if 0 == len(found){
+		return nil, nil
+	}
+	command, ok := found[0].(LinkeditDataCommand)
+	if !ok{
+		return nil, errors.New("LC_FUNCTION_STARTS could not be decoded")
+	}
+	text := m.Segment("__TEXT")
+	if nil == text{
+		return nil, errors.New("function starts: there is no __TEXT segment")
+	}
+	data, err := m.ReadBytes(uint64(command.DataOffset), uint64(command.DataSize))
+	if nil != err{
+		return nil, fmt.Errorf("function starts: %w", err)
+	}
+
+	var functions []Function
+	address := text.VmAddress
+	for position := uint64(0); position < uint64(len(data)); {
+		delta, err := readULEB128(data, &position)
+		if nil != err{
+			return functions, fmt.Errorf("function starts: %w", err)
+		}
+		if 0 == delta{
+			break
+		}
+		address += delta
+		functions = append(functions, Function{Address: address})
+	}
+
+	names := m.functionNames()
+	for i := range functions{
+		function := &functions[i]
+		function.Name = names[function.Address]
+		end := uint64(0)
+		if section, err := m.SectionForVA(function.Address); nil == err{
+			end = section.Address + section.Size
+		}
+		if i + 1 < len(functions) && (0 == end || functions[i+1].Address < end){
+			end = functions[i+1].Address
+		}
+		if end > function.Address{
+			function.Size = end - function.Address
+		}
+	}
+	return functions, nil
+}
+
+/*
+	//////////////////////////////////////// PRIVATE CLASS METHODS ////////////////////////////////////////
+*/
+
+//Maps the address of every defined symbol to its name, external symbols winning over local ones.
+func (m FileHeader) functionNames()map[uint64]string{
+	names := map[uint64]string{}
+	symbols, _ := m.Symbols()
+	for _, symbol := range symbols{
+		if !symbol.IsDefined() || "" == symbol.Name{
+			continue
+		}
+		if _, seen := names[symbol.Value]; !seen || symbol.IsExternal(){
+			names[symbol.Value] = symbol.Name
+		}
+	}
+	return names
+}
@@ -0,0 +1,114 @@
+package machoHeader
+
+import (
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+//An nlist_64 table and its string table, names in the order given.
+func symbolTable(symbols ...Symbol)([]byte, []byte){
+	table := make([]byte, NLIST_64_SIZE * len(symbols))
+	names := []byte{0}
+	for i, symbol := range symbols{
+		entry := table[NLIST_64_SIZE*i:]
+		binary.LittleEndian.PutUint32(entry[0:], uint32(len(names)))
+		entry[4], entry[5] = symbol.Type, symbol.Sect
+		binary.LittleEndian.PutUint16(entry[6:], symbol.Desc)
+		binary.LittleEndian.PutUint64(entry[8:], symbol.Value)
+		names = append(append(names, symbol.Name...), 0)
+	}
+	return table, names
+}
+
+//Three functions over two sections. The first address has a local and an external symbol, the second one an
+//external and a local, the third none. __text ends 0x40 bytes before __text_cold starts.
+func functionsImage(t *testing.T, starts []byte)FileHeader{
+	t.Helper()
+	symbols, names := symbolTable(
+		Symbol{Name: "_helper", Type: N_SECT, Sect: 1, Value: IMAGE_TEXT + 0x1000},
+		Symbol{Name: "_api", Type: N_SECT | N_EXT, Sect: 1, Value: IMAGE_TEXT + 0x1000},
+		Symbol{Name: "_run", Type: N_SECT | N_EXT, Sect: 1, Value: IMAGE_TEXT + 0x1010},
+		Symbol{Name: "_run.cold", Type: N_SECT, Sect: 1, Value: IMAGE_TEXT + 0x1010},
+		//undefined symbols never name a function, whatever their value
+		Symbol{Name: "_printf", Type: N_UNDF | N_EXT, Value: IMAGE_TEXT + 0x1100},
+	)
+	symtab := SymtabCommand{CommandHeader{LC_SYMTAB, 0}, 0x8100, 5, 0x8200, uint32(len(names))}
+	return buildImage(t, []imageSection{
+		{segment: "__TEXT", name: "__text", address: IMAGE_TEXT + 0x1000, data: make([]byte, 0xc0)},
+		{segment: "__TEXT", name: "__text_cold", address: IMAGE_TEXT + 0x1100, data: make([]byte, 0x20)},
+		{segment: "__LINKEDIT", address: IMAGE_LINKEDIT, data: starts},
+		{segment: "__LINKEDIT", address: IMAGE_LINKEDIT + 0x100, data: symbols},
+		{segment: "__LINKEDIT", address: IMAGE_LINKEDIT + 0x200, data: names},
+	}, linkeditCommand(LC_FUNCTION_STARTS, IMAGE_LINKEDIT, len(starts)), encodeCommand(t, symtab))
+}
+
+func TestFunctions(t *testing.T){
+	//deltas 0x1000, 0x10 and 0xf0, two of them taking two ULEB128 bytes, then the terminator and a delta after it
+	m := functionsImage(t, []byte{0x80, 0x20, 0x10, 0xf0, 0x01, 0x00, 0x08, 0x00})
+	functions, err := m.Functions()
+	if nil != err{
+		t.Fatal(err)
+	}
+	want := []Function{
+		{Address: IMAGE_TEXT + 0x1000, Size: 0x10, Name: "_api"},
+		//ends with __text, not at the next start
+		{Address: IMAGE_TEXT + 0x1010, Size: 0xb0, Name: "_run"},
+		{Address: IMAGE_TEXT + 0x1100, Size: 0x20},
+	}
+	if !reflect.DeepEqual(want, functions){
+		t.Fatalf("functions %+v\nwant %+v", functions, want)
+	}
+
+	lookups := []struct{
+		address uint64
+		function int		//index into want, -1 for none
+	}{
+		{IMAGE_TEXT + 0xfff, -1},
+		{IMAGE_TEXT + 0x1000, 0},
+		{IMAGE_TEXT + 0x100f, 0},
+		{IMAGE_TEXT + 0x1010, 1},
+		{IMAGE_TEXT + 0x10bf, 1},
+		{IMAGE_TEXT + 0x10c0, -1},
+		{IMAGE_TEXT + 0x111f, 2},
+		{IMAGE_TEXT + 0x1120, -1},
+	}
+	for _, lookup := range lookups{
+		function := FunctionAt(functions, lookup.address)
+		switch{
+		case -1 == lookup.function && nil != function:
+			t.Errorf("0x%x is in %+v, want no function", lookup.address, *function)
+		case -1 != lookup.function && (nil == function || want[lookup.function].Address != function.Address):
+			t.Errorf("0x%x is in %+v, want %+v", lookup.address, function, want[lookup.function])
+		}
+	}
+	if nil != FunctionAt(nil, IMAGE_TEXT){
+		t.Error("found a function in an empty list")
+	}
+}
+
+//A ULEB128 cut off by the end of the data returns the functions before it and an error.
+func TestFunctionsTruncated(t *testing.T){
+	functions, err := functionsImage(t, []byte{0x80, 0x20, 0x90}).Functions()
+	if nil == err || !strings.Contains(err.Error(), "function starts"){
+		t.Errorf("error = %v", err)
+	}
+	if 1 != len(functions) || IMAGE_TEXT + 0x1000 != functions[0].Address{
+		t.Errorf("functions %+v", functions)
+	}
+}
+
+//The fixture starts one function, main, which runs to the end of __text.
+func TestFixtureFunctions(t *testing.T){
+	m := loadFixture(t)
+	functions, err := m.Functions()
+	if nil != err{
+		t.Fatal(err)
+	}
+	text := m.Section("__TEXT", "__text")
+	want := []Function{{Address: 0x100000f50, Size: text.Address + text.Size - 0x100000f50, Name: "_main"}}
+	if !reflect.DeepEqual(want, functions){
+		t.Errorf("functions %+v, want %+v", functions, want)
+	}
+}
@@ -0,0 +1,158 @@
+package machoHeader
+
+import (
+	"fmt"
+)
+
+//Every load command which names a dylib the image links against
+var dylibLoadCommands = []uint32{LC_LOAD_DYLIB, LC_LOAD_WEAK_DYLIB, LC_REEXPORT_DYLIB, LC_LAZY_LOAD_DYLIB, LC_LOAD_UPWARD_DYLIB}
+
+/*
+	//////////////////////////////////////// PUBLIC CLASS METHODS ////////////////////////////////////////
+*/
+
+//Returns every dylib the image loads, in load order. LC_ID_DYLIB is not included.
+func (m FileHeader) Dylibs()[]DylibCommand{
+	var dylibs []DylibCommand
+	for i := range m.LoadCommands{
+		if dylib, ok := m.LoadCommands[i].Decoded.(DylibCommand); ok && isDylibLoad(dylib.Command){
+			dylibs = append(dylibs, dylib)
+		}
+	}
+	return dylibs
+}
+
+func (m FileHeader) Rpaths()[]string{
+	var paths []string
+	for i := range m.LoadCommands{
+		if rpath, ok := m.LoadCommands[i].Decoded.(RpathCommand); ok{
+			paths = append(paths, rpath.Path)
+		}
+	}
+	return paths
+}
+
+//True if the image carries an LC_CODE_SIGNATURE. Any edit made through the writer invalidates it.
+func (m FileHeader) IsSigned()bool{
+	return 0 != len(m.FindCommands(LC_CODE_SIGNATURE))
+}
+
+//Appends a load command to the end of the command list. Like every editor the list is copied rather than
+//changed in place, so copies of m made before the edit keep their commands.
+func (m *FileHeader) AddCommand(c Command)error{
+	l, err := NewLoadCommand(c)
+	if nil != err{
+		return err
+	}
+	commands := len(m.LoadCommands)
+	m.LoadCommands = append(m.LoadCommands[:commands:commands], l)
+	return nil
+}
+
+func (m *FileHeader) RemoveCommand(index int){
+	commands := make([]LoadCommand, 0, len(m.LoadCommands) - 1)
+	m.LoadCommands = append(append(commands, m.LoadCommands[:index]...), m.LoadCommands[index+1:]...)
+}
+
+//Equivalent of install_name_tool -id. The image must be a dylib.
+func (m *FileHeader) SetID(name string)error{
+	for i := range m.LoadCommands{
+		if dylib, ok := m.LoadCommands[i].Decoded.(DylibCommand); ok && LC_ID_DYLIB == dylib.Command{
+			if err := m.LoadCommands[i].DecodeError; nil != err{
+				return fmt.Errorf("LC_ID_DYLIB cannot be rewritten: %w", err)
+			}
+			dylib.Name = name
+			*m = m.clone()
+			return m.LoadCommands[i].Update(dylib)
+		}
+	}
+	return fmt.Errorf("no LC_ID_DYLIB, the image is not a dylib")
+}
+
+//Equivalent of install_name_tool -change. Every load of oldName is rewritten, or none if one of them could
+//not be decoded.
+func (m *FileHeader) ChangeInstallName(oldName string, newName string)error{
+	var loads []int
+	for i := range m.LoadCommands{
+		dylib, ok := m.LoadCommands[i].Decoded.(DylibCommand)
+		if !ok || !isDylibLoad(dylib.Command) || oldName != dylib.Name{
+			continue
+		}
+		if err := m.LoadCommands[i].DecodeError; nil != err{
+			return fmt.Errorf("load command %d cannot be rewritten: %w", i, err)
+		}
+		loads = append(loads, i)
+	}
+	if 0 == len(loads){
+		return fmt.Errorf("no LC_LOAD_DYLIB for %s", oldName)
+	}
+	*m = m.clone()
+	for _, i := range loads{
+		dylib := m.LoadCommands[i].Decoded.(DylibCommand)
+		dylib.Name = newName
+		if err := m.LoadCommands[i].Update(dylib); nil != err{
+			return err
+		}
+	}
+	return nil
+}
+
+//Equivalent of install_name_tool -add_rpath.
+func (m *FileHeader) AddRpath(path string)error{
+	if -1 != m.rpathIndex(path){
+		return fmt.Errorf("LC_RPATH %s already exists", path)
+	}
+	return m.AddCommand(RpathCommand{CommandHeader{LC_RPATH, 0}, path})
+}
+
+//Equivalent of install_name_tool -delete_rpath.
+func (m *FileHeader) DeleteRpath(path string)error{
+	index := m.rpathIndex(path)
+	if -1 == index{
+		return fmt.Errorf("no LC_RPATH %s", path)
+	}
+	m.RemoveCommand(index)
+	return nil
+}
+
+//Equivalent of install_name_tool -rpath.
+func (m *FileHeader) ChangeRpath(oldPath string, newPath string)error{
+	index := m.rpathIndex(oldPath)
+	if -1 == index{
+		return fmt.Errorf("no LC_RPATH %s", oldPath)
+	}
+	if -1 != m.rpathIndex(newPath){
+		return fmt.Errorf("LC_RPATH %s already exists", newPath)
+	}
+	if err := m.LoadCommands[index].DecodeError; nil != err{
+		return fmt.Errorf("LC_RPATH %s cannot be rewritten: %w", oldPath, err)
+	}
+	*m = m.clone()
+	return m.LoadCommands[index].Update(RpathCommand{CommandHeader{LC_RPATH, 0}, newPath})
+}
+
+/*
+	//////////////////////////////////////// PRIVATE METHODS ////////////////////////////////////////
+*/
+
+func isDylibLoad(command uint32)bool{
+	for _, load := range dylibLoadCommands{
+		if load == command{
+			return true
+		}
+	}
+	return false
+}
+
+/*
+	//////////////////////////////////////// PRIVATE CLASS METHODS ////////////////////////////////////////
+*/
+
+func (m FileHeader) rpathIndex(path string)int{
+	for i := range m.LoadCommands{
+		if rpath, ok := m.LoadCommands[i].Decoded.(RpathCommand); ok && path == rpath.Path{
+			return i
+		}
+	}
+	return -1
+}
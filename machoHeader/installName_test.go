@@ -0,0 +1,130 @@
+package machoHeader
+
+import (
+	"reflect"
+	"testing"
+)
+
+//Writes m out and parses the result, so checks see the commands as a loader would.
+func reparse(t *testing.T, m FileHeader)FileHeader{
+	t.Helper()
+	data, err := m.Bytes()
+	if nil != err{
+		t.Fatal(err)
+	}
+	parsed, err := ParseBytes(data)
+	if nil != err{
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func TestChangeInstallName(t *testing.T){
+	m := loadFixture(t)
+	if err := m.ChangeInstallName("/usr/lib/libSystem.B.dylib", "@rpath/libSystem.dylib"); nil != err{
+		t.Fatal(err)
+	}
+	dylibs := reparse(t, m).Dylibs()
+	if 1 != len(dylibs) || "@rpath/libSystem.dylib" != dylibs[0].Name || LC_LOAD_DYLIB != dylibs[0].Command{
+		t.Errorf("dylibs %+v", dylibs)
+	}
+	if err := m.ChangeInstallName("/usr/lib/libSystem.B.dylib", "/usr/lib/libc.dylib"); nil == err{
+		t.Error("changed a dylib which is no longer loaded")
+	}
+}
+
+func TestRpaths(t *testing.T){
+	m := loadFixture(t)
+	for _, path := range []string{"@executable_path/../Frameworks", "@loader_path/lib"}{
+		if err := m.AddRpath(path); nil != err{
+			t.Fatal(err)
+		}
+	}
+	if err := m.AddRpath("@loader_path/lib"); nil == err{
+		t.Error("added a duplicate LC_RPATH")
+	}
+	if rpaths := reparse(t, m).Rpaths(); !reflect.DeepEqual([]string{"@executable_path/../Frameworks", "@loader_path/lib"}, rpaths){
+		t.Errorf("after AddRpath %v", rpaths)
+	}
+
+	if err := m.ChangeRpath("@loader_path/lib", "@executable_path/../Frameworks"); nil == err{
+		t.Error("renamed an LC_RPATH onto an existing one")
+	}
+	if err := m.ChangeRpath("@executable_path/../Frameworks", "@executable_path/../PlugIns/Frameworks"); nil != err{
+		t.Fatal(err)
+	}
+	if rpaths := reparse(t, m).Rpaths(); !reflect.DeepEqual([]string{"@executable_path/../PlugIns/Frameworks", "@loader_path/lib"}, rpaths){
+		t.Errorf("after ChangeRpath %v", rpaths)
+	}
+
+	if err := m.DeleteRpath("@executable_path/../PlugIns/Frameworks"); nil != err{
+		t.Fatal(err)
+	}
+	if err := m.DeleteRpath("@executable_path/../PlugIns/Frameworks"); nil == err{
+		t.Error("deleted an LC_RPATH twice")
+	}
+	parsed := reparse(t, m)
+	if rpaths := parsed.Rpaths(); !reflect.DeepEqual([]string{"@loader_path/lib"}, rpaths){
+		t.Errorf("after DeleteRpath %v", rpaths)
+	}
+	if fixture := loadFixture(t); len(fixture.LoadCommands) + 1 != len(parsed.LoadCommands){
+		t.Errorf("%d load commands, the fixture has %d", len(parsed.LoadCommands), len(fixture.LoadCommands))
+	}
+}
+
+func TestSetID(t *testing.T){
+	executable := loadFixture(t)
+	if err := executable.SetID("@rpath/libfoo.dylib"); nil == err{
+		t.Error("set the install name of an executable")
+	}
+
+	id := DylibCommand{CommandHeader{LC_ID_DYLIB, 0}, "/usr/local/lib/libfoo.dylib", 2, 0x10000, 0x10000}
+	m := buildImage(t, nil, encodeCommand(t, id))
+	if err := m.SetID("@rpath/libfoo.1.dylib"); nil != err{
+		t.Fatal(err)
+	}
+	ids := reparse(t, m).FindCommands(LC_ID_DYLIB)
+	if 1 != len(ids){
+		t.Fatalf("%d LC_ID_DYLIB", len(ids))
+	}
+	id.Name = "@rpath/libfoo.1.dylib"
+	if dylib := ids[0].(DylibCommand); id.Name != dylib.Name || id.CurrentVersion != dylib.CurrentVersion || 2 != dylib.Timestamp{
+		t.Errorf("LC_ID_DYLIB %+v", dylib)
+	}
+}
+
+//Editing a FileHeader must not change copies of it taken earlier, which share its load command array.
+func TestEditsKeepCopies(t *testing.T){
+	m := loadFixture(t)
+	if err := m.AddRpath("@loader_path/a"); nil != err{
+		t.Fatal(err)
+	}
+	if err := m.AddRpath("@loader_path/b"); nil != err{
+		t.Fatal(err)
+	}
+	original := m
+	want, err := original.Bytes()
+	if nil != err{
+		t.Fatal(err)
+	}
+
+	if err := m.DeleteRpath("@loader_path/a"); nil != err{
+		t.Fatal(err)
+	}
+	if err := m.ChangeRpath("@loader_path/b", "@loader_path/c"); nil != err{
+		t.Fatal(err)
+	}
+	if err := m.ChangeInstallName("/usr/lib/libSystem.B.dylib", "/usr/lib/libc.dylib"); nil != err{
+		t.Fatal(err)
+	}
+	if err := m.AddRpath("@loader_path/d"); nil != err{
+		t.Fatal(err)
+	}
+
+	if rpaths := original.Rpaths(); !reflect.DeepEqual([]string{"@loader_path/a", "@loader_path/b"}, rpaths){
+		t.Errorf("the copy's rpaths became %v", rpaths)
+	}
+	if got, _ := original.Bytes(); !reflect.DeepEqual(want, got){
+		t.Error("editing m changed the bytes of its copy")
+	}
+}
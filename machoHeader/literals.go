@@ -0,0 +1,180 @@
+package machoHeader
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"unicode/utf16"
+)
+
+//Kinds of string literal, named after what the section holds
+const (
+	LITERAL_CSTRING			= "cstring"
+	LITERAL_SELECTOR		= "selector"
+	LITERAL_CLASS			= "class"
+	LITERAL_METHOD_TYPE		= "method type"
+	LITERAL_UTF16			= "utf16"
+	LITERAL_CFSTRING		= "cfstring"
+)
+
+//Size of a constant CFString on 64 bit: isa, flags, data pointer and length
+const CFSTRING_SIZE = 32
+
+//__kCFIsUnicode in the CFString info flags, set when the backing data is UTF-16
+const CFSTRING_UNICODE = 0x10
+
+type StringLiteral struct{
+	Address uint64		`json:"address"`
+	Section string		`json:"section"`	//"__TEXT,__cstring"
+	Kind string			`json:"kind"`
+	Value string		`json:"value"`
+}
+
+//Objective-C name sections are S_CSTRING_LITERALS, the section name says what the strings are
+var literalSectionKinds = map[string]string{
+	"__objc_methname":		LITERAL_SELECTOR,
+	"__objc_classname":		LITERAL_CLASS,
+	"__objc_methtype":		LITERAL_METHOD_TYPE,
+}
+
+/*
+	//////////////////////////////////////// PUBLIC CLASS METHODS ////////////////////////////////////////
+*/
+
+//Extracts the string literals of the image, section by section: C strings from S_CSTRING_LITERALS sections
+//(including the Objective-C selector, class and type names), UTF-16 strings from __ustring and constant
+//CFStrings from __cfstring, resolved to their backing bytes. Empty strings are skipped. A section or CFString
+//which cannot be read does not stop the others, the literals found are returned along with every error.
+func (m FileHeader) Strings()([]StringLiteral, error){
+	var literals []StringLiteral
+	var errs []error
+	for i := range m.LoadCommands{
+		for _, section := range m.LoadCommands[i].Sections{
+			var found []StringLiteral
+			var err error
+			_, objcName := literalSectionKinds[section.SectionName]
+			switch{
+			case "__ustring" == section.SectionName:
+				found, err = m.utf16Strings(section)
+			case "__cfstring" == section.SectionName:
+				found, err = m.cfStrings(section)
+			case S_CSTRING_LITERALS == section.Type() || objcName:
+				found, err = m.cStrings(section)
+			default:
+				continue
+			}
+			if nil != err{
+				errs = append(errs, err)
+			}
+			literals = append(literals, found...)
+		}
+	}
+	return literals, errors.Join(errs...)
+}
+
+/*
+	//////////////////////////////////////// PRIVATE CLASS METHODS ////////////////////////////////////////
+*/
+
+func (m FileHeader) cStrings(section SectionHeader)([]StringLiteral, error){
+	data, err := m.SectionData(section)
+	if nil != err{
+		return nil, err
+	}
+	kind, ok := literalSectionKinds[section.SectionName]
+	if !ok{
+		kind = LITERAL_CSTRING
+	}
+
+	var literals []StringLiteral
+	for start := 0; start < len(data); {
+		value := cString(data[start:])
+		if "" != value{
+			literals = append(literals, StringLiteral{section.Address + uint64(start), sectionLabel(section), kind, value})
+		}
+		start += len(value) + 1
+	}
+	return literals, nil
+}
+
+func (m FileHeader) utf16Strings(section SectionHeader)([]StringLiteral, error){
+	data, err := m.SectionData(section)
+	if nil != err{
+		return nil, err
+	}
+
+	var literals []StringLiteral
+	start := 0
+	var units []uint16
+	for i := 0; i + 1 < len(data); i += 2{
+		unit := binary.LittleEndian.Uint16(data[i:])
+		if 0 != unit{
+			units = append(units, unit)
+			continue
+		}
+		if 0 != len(units){
+			literals = append(literals, StringLiteral{section.Address + uint64(start), sectionLabel(section), LITERAL_UTF16, string(utf16.Decode(units))})
+		}
+		units = nil
+		start = i + 2
+	}
+	return literals, nil
+}
+
+func (m FileHeader) cfStrings(section SectionHeader)([]StringLiteral, error){
+	data, err := m.SectionData(section)
+	if nil != err{
+		return nil, err
+	}
+
+	//The chained fixups are read once for the whole section. An entry whose data pointer does not resolve,
+	//such as the ones object files leave to relocations, is reported and the rest are still collected.
+	fixups := m.PointerFixups()
+	var literals []StringLiteral
+	var errs []error
+	for i := 0; i + CFSTRING_SIZE <= len(data); i += CFSTRING_SIZE{
+		address := section.Address + uint64(i)
+		flags := binary.LittleEndian.Uint32(data[i+8:])
+		target, _ := fixups.Untag(binary.LittleEndian.Uint64(data[i+16:]))
+		length := binary.LittleEndian.Uint64(data[i+24:])
+
+		offset, err := m.VAToOffset(target)
+		if nil != err{
+			errs = append(errs, fmt.Errorf("CFString at 0x%x: %w", address, err))
+			continue
+		}
+		//bounded before it is doubled for UTF-16, so a crafted length cannot wrap around
+		if offset > uint64(len(m.data)) || length > uint64(len(m.data)) - offset{
+			errs = append(errs, fmt.Errorf("CFString at 0x%x: length %d runs past the end of the file", address, length))
+			continue
+		}
+		size := length
+		if 0 != flags & CFSTRING_UNICODE{
+			size *= 2
+		}
+		backing, err := m.ReadBytes(offset, size)
+		if nil != err{
+			errs = append(errs, fmt.Errorf("CFString at 0x%x: %w", address, err))
+			continue
+		}
+
+		value := string(backing)
+		if 0 != flags & CFSTRING_UNICODE{
+			units := make([]uint16, length)
+			for j := range units{
+				units[j] = binary.LittleEndian.Uint16(backing[2*j:])
+			}
+			value = string(utf16.Decode(units))
+		}
+		literals = append(literals, StringLiteral{address, sectionLabel(section), LITERAL_CFSTRING, value})
+	}
+	return literals, errors.Join(errs...)
+}
+
+/*
+	//////////////////////////////////////// PRIVATE METHODS ////////////////////////////////////////
+*/
+
+func sectionLabel(section SectionHeader)string{
+	return section.SegmentName + "," + section.SectionName
+}
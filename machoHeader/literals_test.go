@@ -0,0 +1,52 @@
+package machoHeader
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+//Builds an image holding "hi" as ASCII at IMAGE_TEXT+0x800 and as UTF-16 at IMAGE_TEXT+0x810, and a
+//__DATA,__cfstring section at IMAGE_DATA with the given 32 byte CFStrings.
+func cfStringImage(t *testing.T, cfStrings [][4]uint64)FileHeader{
+	t.Helper()
+	section := make([]byte, CFSTRING_SIZE * len(cfStrings))
+	for i, cfString := range cfStrings{
+		for j, field := range cfString{
+			binary.LittleEndian.PutUint64(section[CFSTRING_SIZE * i + 8 * j:], field)
+		}
+	}
+	return buildImage(t, []imageSection{
+		{segment: "__TEXT", address: IMAGE_TEXT + 0x800, data: []byte("hi")},
+		{segment: "__TEXT", address: IMAGE_TEXT + 0x810, data: []byte{'h', 0, 'i', 0}},
+		{segment: "__DATA", name: "__cfstring", address: IMAGE_DATA, data: section},
+	})
+}
+
+func TestCFStrings(t *testing.T){
+	const ASCII, UNICODE = 0x7c8, 0x7d0
+	m := cfStringImage(t, [][4]uint64{
+		{0, ASCII, IMAGE_TEXT + 0x800, 2},
+		{0, UNICODE, IMAGE_TEXT + 0x810, 2},
+		//a UTF-16 length whose byte size wraps around to 0
+		{0, UNICODE, IMAGE_TEXT + 0x810, 1 << 63},
+		{0, ASCII, IMAGE_TEXT + 0x800, 1 << 63},
+		{0, ASCII, IMAGE_TEXT + 0x800, 0x10000},
+		//not mapped, as object files leave it to a relocation
+		{0, ASCII, 0, 2},
+		{0, ASCII, IMAGE_TEXT + 0x800, 2},
+	})
+
+	literals, err := m.Strings()
+	if nil == err{
+		t.Error("no error for the CFStrings which do not resolve")
+	}
+	want := []uint64{IMAGE_DATA, IMAGE_DATA + 0x20, IMAGE_DATA + 0xc0}
+	if len(want) != len(literals){
+		t.Fatalf("literals %+v, want %d", literals, len(want))
+	}
+	for i, literal := range literals{
+		if want[i] != literal.Address || "hi" != literal.Value || LITERAL_CFSTRING != literal.Kind{
+			t.Errorf("literal %d = %+v", i, literal)
+		}
+	}
+}
@@ -0,0 +1,600 @@
+package machoHeader
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+//Load commands which were missing from the table copied out of loader.h
+const (
+	LC_LOAD_WEAK_DYLIB			= (0x18 | LC_REQ_DYLD)	/* load a dynamically linked shared library that is allowed to be missing */
+	LC_FILESET_ENTRY			= (0x35 | LC_REQ_DYLD)	/* used with fileset_entry_command */
+)
+
+//Every decoded load command implements Command. Type returns the LC_ constant, Size the cmdsize field and
+//String a one line description suitable for printing.
+type Command interface{
+	Type() uint32
+	Size() uint32
+	String() string
+}
+
+//Decodes a single load command. data holds the whole command, starting at the cmd field, so offsets from
+//loader.h (such as lc_str offsets) can be used as they are.
+type CommandDecoder func(data []byte)(Command, error)
+
+type commandInfo struct{
+	name string
+	minSize uint32
+	decode CommandDecoder
+}
+
+var commandRegistry = map[uint32]commandInfo{}
+
+//Every typed command embeds CommandHeader, which supplies Type, Size and a String which just prints the name.
+type CommandHeader struct{
+	Command uint32
+	CommandSize uint32
+}
+
+//Any command that is registered without a decoder, or that failed to decode, is kept as a RawCommand.
+type RawCommand struct{
+	CommandHeader
+}
+
+//taken from Library/Developer/CommandLineTools/SDKs/MacOSX10.15.sdk/usr/include/mach-o/loader.h
+type SymtabCommand struct{
+	CommandHeader
+	SymbolOffset uint32
+	NumSymbols uint32
+	StringOffset uint32
+	StringSize uint32
+}
+
+type DysymtabCommand struct{
+	CommandHeader
+	ILocalSym uint32
+	NLocalSym uint32
+	IExtDefSym uint32
+	NExtDefSym uint32
+	IUndefSym uint32
+	NUndefSym uint32
+	TocOffset uint32
+	NumToc uint32
+	ModTabOffset uint32
+	NumModTab uint32
+	ExtRefSymOffset uint32
+	NumExtRefSyms uint32
+	IndirectSymOffset uint32
+	NumIndirectSyms uint32
+	ExtRelOffset uint32
+	NumExtRel uint32
+	LocRelOffset uint32
+	NumLocRel uint32
+}
+
+//Used by LC_ID_DYLIB, LC_LOAD_DYLIB, LC_LOAD_WEAK_DYLIB, LC_REEXPORT_DYLIB, LC_LAZY_LOAD_DYLIB and LC_LOAD_UPWARD_DYLIB
+type DylibCommand struct{
+	CommandHeader
+	Name string
+	Timestamp uint32
+	CurrentVersion uint32
+	CompatibilityVersion uint32
+}
+
+//Used by LC_LOAD_DYLINKER, LC_ID_DYLINKER and LC_DYLD_ENVIRONMENT
+type DylinkerCommand struct{
+	CommandHeader
+	Name string
+}
+
+//Used by LC_SUB_FRAMEWORK, LC_SUB_UMBRELLA, LC_SUB_CLIENT and LC_SUB_LIBRARY
+type SubCommand struct{
+	CommandHeader
+	Name string
+}
+
+type RpathCommand struct{
+	CommandHeader
+	Path string
+}
+
+type UUIDCommand struct{
+	CommandHeader
+	UUID [16]byte
+}
+
+//Used by every command which points at a blob in __LINKEDIT: LC_CODE_SIGNATURE, LC_FUNCTION_STARTS,
+//LC_DATA_IN_CODE, LC_DYLD_EXPORTS_TRIE, LC_DYLD_CHAINED_FIXUPS and friends.
+type LinkeditDataCommand struct{
+	CommandHeader
+	DataOffset uint32
+	DataSize uint32
+}
+
+//Used by LC_ENCRYPTION_INFO and LC_ENCRYPTION_INFO_64, the 64 bit version just adds padding.
+type EncryptionInfoCommand struct{
+	CommandHeader
+	CryptOffset uint32
+	CryptSize uint32
+	CryptID uint32
+}
+
+//Used by LC_DYLD_INFO and LC_DYLD_INFO_ONLY
+type DyldInfoCommand struct{
+	CommandHeader
+	RebaseOffset uint32
+	RebaseSize uint32
+	BindOffset uint32
+	BindSize uint32
+	WeakBindOffset uint32
+	WeakBindSize uint32
+	LazyBindOffset uint32
+	LazyBindSize uint32
+	ExportOffset uint32
+	ExportSize uint32
+}
+
+//Used by LC_VERSION_MIN_MACOSX, LC_VERSION_MIN_IPHONEOS, LC_VERSION_MIN_TVOS and LC_VERSION_MIN_WATCHOS
+type VersionMinCommand struct{
+	CommandHeader
+	Version uint32
+	SDK uint32
+}
+
+type BuildToolVersion struct{
+	Tool uint32
+	Version uint32
+}
+
+type BuildVersionCommand struct{
+	CommandHeader
+	Platform uint32
+	MinOS uint32
+	SDK uint32
+	Tools []BuildToolVersion
+}
+
+//LC_MAIN
+type EntryPointCommand struct{
+	CommandHeader
+	EntryOffset uint64
+	StackSize uint64
+}
+
+type SourceVersionCommand struct{
+	CommandHeader
+	Version uint64
+}
+
+type LinkerOptionCommand struct{
+	CommandHeader
+	Options []string
+}
+
+type NoteCommand struct{
+	CommandHeader
+	DataOwner string
+	Offset uint64
+	DataSize uint64
+}
+
+//Known platforms for LC_BUILD_VERSION
+var platformNames = map[uint32]string{
+	1:	"macOS",
+	2:	"iOS",
+	3:	"tvOS",
+	4:	"watchOS",
+	5:	"bridgeOS",
+	6:	"macCatalyst",
+	7:	"iOS Simulator",
+	8:	"tvOS Simulator",
+	9:	"watchOS Simulator",
+	10:	"DriverKit",
+	11:	"visionOS",
+	12:	"visionOS Simulator",
+}
+
+var toolNames = map[uint32]string{
+	1:	"clang",
+	2:	"swift",
+	3:	"ld",
+	4:	"lld",
+}
+
+func init(){
+	RegisterCommand(LC_SEGMENT,					"LC_SEGMENT",					56,	nil)
+	RegisterCommand(LC_SYMTAB,					"LC_SYMTAB",					24,	decodeSymtab)
+	RegisterCommand(LC_SYMSEG,					"LC_SYMSEG",					16,	nil)
+	RegisterCommand(LC_THREAD,					"LC_THREAD",					8,	nil)
+	RegisterCommand(LC_UNIXTHREAD,				"LC_UNIXTHREAD",				8,	nil)
+	RegisterCommand(LC_LOADFVMLIB,				"LC_LOADFVMLIB",				20,	nil)
+	RegisterCommand(LC_IDFVMLIB,				"LC_IDFVMLIB",					20,	nil)
+	RegisterCommand(LC_IDENT,					"LC_IDENT",						8,	nil)
+	RegisterCommand(LC_FVMFILE,					"LC_FVMFILE",					16,	nil)
+	RegisterCommand(LC_PREPAGE,					"LC_PREPAGE",					8,	nil)
+	RegisterCommand(LC_DYSYMTAB,				"LC_DYSYMTAB",					80,	decodeDysymtab)
+	RegisterCommand(LC_LOAD_DYLIB,				"LC_LOAD_DYLIB",				24,	decodeDylib)
+	RegisterCommand(LC_ID_DYLIB,				"LC_ID_DYLIB",					24,	decodeDylib)
+	RegisterCommand(LC_LOAD_DYLINKER,			"LC_LOAD_DYLINKER",				12,	decodeDylinker)
+	RegisterCommand(LC_ID_DYLINKER,				"LC_ID_DYLINKER",				12,	decodeDylinker)
+	RegisterCommand(LC_PREBOUND_DYLIB,			"LC_PREBOUND_DYLIB",			20,	nil)
+	RegisterCommand(LC_ROUTINES,				"LC_ROUTINES",					40,	nil)
+	RegisterCommand(LC_SUB_FRAMEWORK,			"LC_SUB_FRAMEWORK",				12,	decodeSub)
+	RegisterCommand(LC_SUB_UMBRELLA,			"LC_SUB_UMBRELLA",				12,	decodeSub)
+	RegisterCommand(LC_SUB_CLIENT,				"LC_SUB_CLIENT",				12,	decodeSub)
+	RegisterCommand(LC_SUB_LIBRARY,				"LC_SUB_LIBRARY",				12,	decodeSub)
+	RegisterCommand(LC_TWOLEVEL_HINTS,			"LC_TWOLEVEL_HINTS",			16,	nil)
+	RegisterCommand(LC_PREBIND_CKSUM,			"LC_PREBIND_CKSUM",				12,	nil)
+	RegisterCommand(LC_LOAD_WEAK_DYLIB,			"LC_LOAD_WEAK_DYLIB",			24,	decodeDylib)
+	RegisterCommand(LC_SEGMENT_64,				"LC_SEGMENT_64",				MACH_HEADER_SIZE,	decodeSegment64)
+	RegisterCommand(LC_ROUTINES_64,				"LC_ROUTINES_64",				72,	nil)
+	RegisterCommand(LC_UUID,					"LC_UUID",						24,	decodeUUID)
+	RegisterCommand(LC_RPATH,					"LC_RPATH",						12,	decodeRpath)
+	RegisterCommand(LC_CODE_SIGNATURE,			"LC_CODE_SIGNATURE",			16,	decodeLinkeditData)
+	RegisterCommand(LC_SEGMENT_SPLIT_INFO,		"LC_SEGMENT_SPLIT_INFO",		16,	decodeLinkeditData)
+	RegisterCommand(LC_REEXPORT_DYLIB,			"LC_REEXPORT_DYLIB",			24,	decodeDylib)
+	RegisterCommand(LC_LAZY_LOAD_DYLIB,			"LC_LAZY_LOAD_DYLIB",			24,	decodeDylib)
+	RegisterCommand(LC_ENCRYPTION_INFO,			"LC_ENCRYPTION_INFO",			20,	decodeEncryptionInfo)
+	RegisterCommand(LC_DYLD_INFO,				"LC_DYLD_INFO",					48,	decodeDyldInfo)
+	RegisterCommand(LC_DYLD_INFO_ONLY,			"LC_DYLD_INFO_ONLY",			48,	decodeDyldInfo)
+	RegisterCommand(LC_LOAD_UPWARD_DYLIB,		"LC_LOAD_UPWARD_DYLIB",			24,	decodeDylib)
+	RegisterCommand(LC_VERSION_MIN_MACOSX,		"LC_VERSION_MIN_MACOSX",		16,	decodeVersionMin)
+	RegisterCommand(LC_VERSION_MIN_IPHONEOS,	"LC_VERSION_MIN_IPHONEOS",		16,	decodeVersionMin)
+	RegisterCommand(LC_FUNCTION_STARTS,			"LC_FUNCTION_STARTS",			16,	decodeLinkeditData)
+	RegisterCommand(LC_DYLD_ENVIRONMENT,		"LC_DYLD_ENVIRONMENT",			12,	decodeDylinker)
+	RegisterCommand(LC_MAIN,					"LC_MAIN",						24,	decodeEntryPoint)
+	RegisterCommand(LC_DATA_IN_CODE,			"LC_DATA_IN_CODE",				16,	decodeLinkeditData)
+	RegisterCommand(LC_SOURCE_VERSION,			"LC_SOURCE_VERSION",			16,	decodeSourceVersion)
+	RegisterCommand(LC_DYLIB_CODE_SIGN_DRS,		"LC_DYLIB_CODE_SIGN_DRS",		16,	decodeLinkeditData)
+	RegisterCommand(LC_ENCRYPTION_INFO_64,		"LC_ENCRYPTION_INFO_64",		24,	decodeEncryptionInfo)
+	RegisterCommand(LC_LINKER_OPTION,			"LC_LINKER_OPTION",				12,	decodeLinkerOption)
+	RegisterCommand(LC_LINKER_OPTIMIZATION_HINT,"LC_LINKER_OPTIMIZATION_HINT",	16,	decodeLinkeditData)
+	RegisterCommand(LC_VERSION_MIN_TVOS,		"LC_VERSION_MIN_TVOS",			16,	decodeVersionMin)
+	RegisterCommand(LC_VERSION_MIN_WATCHOS,		"LC_VERSION_MIN_WATCHOS",		16,	decodeVersionMin)
+	RegisterCommand(LC_NOTE,					"LC_NOTE",						40,	decodeNote)
+	RegisterCommand(LC_BUILD_VERSION,			"LC_BUILD_VERSION",				24,	decodeBuildVersion)
+	RegisterCommand(LC_DYLD_EXPORTS_TRIE,		"LC_DYLD_EXPORTS_TRIE",			16,	decodeLinkeditData)
+	RegisterCommand(LC_DYLD_CHAINED_FIXUPS,		"LC_DYLD_CHAINED_FIXUPS",		16,	decodeLinkeditData)
+	RegisterCommand(LC_FILESET_ENTRY,			"LC_FILESET_ENTRY",				32,	nil)
+}
+
+/*
+	//////////////////////////////////////// PUBLIC METHODS ////////////////////////////////////////
+*/
+
+//Registers (or replaces) the name, minimum size and decoder for a load command. decode may be nil, in which
+//case the command is only named and kept as a RawCommand. Intended to be called from an init function so
+//private or newer commands can be decoded without changing this package.
+func RegisterCommand(command uint32, name string, minSize uint32, decode CommandDecoder){
+	commandRegistry[command] = commandInfo{name, minSize, decode}
+}
+
+//Returns the LC_ name for a command, or UNKNOWN LOAD COMMAND with the raw value.
+func CommandName(command uint32)string{
+	if info, ok := commandRegistry[command]; ok{
+		return info.name
+	}
+	return fmt.Sprintf("UNKNOWN LOAD COMMAND (0x%x)", command)
+}
+
+//Decodes one load command through the registry. Commands with no decoder come back as a RawCommand. On an
+//error the command is a RawCommand if it is too small for its type, or the typed command with whatever could
+//be read, such as a dylib whose name is outside the command and is left empty.
+func DecodeCommand(data []byte)(Command, error){
+	if len(data) < 8{
+		return nil, fmt.Errorf("load command is only %d bytes", len(data))
+	}
+	header := CommandHeader{binary.LittleEndian.Uint32(data[0:4]), binary.LittleEndian.Uint32(data[4:8])}
+
+	info, ok := commandRegistry[header.Command]
+	if !ok || nil == info.decode{
+		return RawCommand{header}, nil
+	}
+	if header.CommandSize < info.minSize || uint32(len(data)) < info.minSize{
+		return RawCommand{header}, fmt.Errorf("%s: cmdsize %d is smaller than the minimum of %d", info.name, header.CommandSize, info.minSize)
+	}
+	return info.decode(data)
+}
+
+func (h CommandHeader) Type()uint32{
+	return h.Command
+}
+
+func (h CommandHeader) Size()uint32{
+	return h.CommandSize
+}
+
+func (h CommandHeader) String()string{
+	return CommandName(h.Command)
+}
+
+func (c DylibCommand) String()string{
+	return fmt.Sprintf("%s %s (compatibility version %s, current version %s)", CommandName(c.Command), c.Name,
+		FormatVersion(c.CompatibilityVersion), FormatVersion(c.CurrentVersion))
+}
+
+func (c DylinkerCommand) String()string{
+	return CommandName(c.Command) + " " + c.Name
+}
+
+func (c SubCommand) String()string{
+	return CommandName(c.Command) + " " + c.Name
+}
+
+func (c RpathCommand) String()string{
+	return CommandName(c.Command) + " " + c.Path
+}
+
+func (c UUIDCommand) String()string{
+	u := c.UUID
+	return fmt.Sprintf("%s %X-%X-%X-%X-%X", CommandName(c.Command), u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
+
+func (c SymtabCommand) String()string{
+	return fmt.Sprintf("%s symoff 0x%x nsyms %d stroff 0x%x strsize 0x%x", CommandName(c.Command), c.SymbolOffset, c.NumSymbols, c.StringOffset, c.StringSize)
+}
+
+func (c DysymtabCommand) String()string{
+	return fmt.Sprintf("%s locals %d extdefs %d undefs %d indirect %d", CommandName(c.Command), c.NLocalSym, c.NExtDefSym, c.NUndefSym, c.NumIndirectSyms)
+}
+
+func (c LinkeditDataCommand) String()string{
+	return fmt.Sprintf("%s dataoff 0x%x datasize 0x%x", CommandName(c.Command), c.DataOffset, c.DataSize)
+}
+
+func (c EncryptionInfoCommand) String()string{
+	return fmt.Sprintf("%s cryptoff 0x%x cryptsize 0x%x cryptid %d", CommandName(c.Command), c.CryptOffset, c.CryptSize, c.CryptID)
+}
+
+func (c DyldInfoCommand) String()string{
+	return fmt.Sprintf("%s rebase 0x%x bind 0x%x weak 0x%x lazy 0x%x export 0x%x", CommandName(c.Command),
+		c.RebaseOffset, c.BindOffset, c.WeakBindOffset, c.LazyBindOffset, c.ExportOffset)
+}
+
+func (c VersionMinCommand) String()string{
+	return fmt.Sprintf("%s version %s sdk %s", CommandName(c.Command), FormatVersion(c.Version), FormatVersion(c.SDK))
+}
+
+func (c BuildVersionCommand) String()string{
+	var tools []string
+	for _, tool := range c.Tools{
+		name, ok := toolNames[tool.Tool]
+		if !ok{
+			name = fmt.Sprintf("tool(%d)", tool.Tool)
+		}
+		tools = append(tools, name + " " + FormatVersion(tool.Version))
+	}
+	description := fmt.Sprintf("%s platform %s minos %s sdk %s", CommandName(c.Command), PlatformName(c.Platform), FormatVersion(c.MinOS), FormatVersion(c.SDK))
+	if 0 != len(tools){
+		description += " tools " + strings.Join(tools, ", ")
+	}
+	return description
+}
+
+func (c EntryPointCommand) String()string{
+	return fmt.Sprintf("%s entryoff 0x%x stacksize 0x%x", CommandName(c.Command), c.EntryOffset, c.StackSize)
+}
+
+func (c SourceVersionCommand) String()string{
+	v := c.Version
+	return fmt.Sprintf("%s %d.%d.%d.%d.%d", CommandName(c.Command), v >> 40, (v >> 30) & 0x3ff, (v >> 20) & 0x3ff, (v >> 10) & 0x3ff, v & 0x3ff)
+}
+
+func (c LinkerOptionCommand) String()string{
+	return CommandName(c.Command) + " " + strings.Join(c.Options, " ")
+}
+
+func (c NoteCommand) String()string{
+	return fmt.Sprintf("%s %s offset 0x%x size 0x%x", CommandName(c.Command), c.DataOwner, c.Offset, c.DataSize)
+}
+
+//Versions are packed as xxxx.yy.zz in nibbles, e.g. 0x000a0f00 is 10.15.0
+func FormatVersion(version uint32)string{
+	return fmt.Sprintf("%d.%d.%d", version >> 16, (version >> 8) & 0xff, version & 0xff)
+}
+
+func PlatformName(platform uint32)string{
+	if name, ok := platformNames[platform]; ok{
+		return name
+	}
+	return fmt.Sprintf("platform(%d)", platform)
+}
+
+/*
+	//////////////////////////////////////// PUBLIC CLASS METHODS ////////////////////////////////////////
+*/
+
+//LoadCommand implements Command so segments can be handled like any other decoded command.
+func (l LoadCommand) Type()uint32{
+	return l.Command
+}
+
+func (l LoadCommand) Size()uint32{
+	return l.CommandSize
+}
+
+func (l LoadCommand) String()string{
+	return fmt.Sprintf("%s %s", CommandName(l.Command), l.SegmentName)
+}
+
+//Returns the decoded form of the command. Segments are decoded straight into the LoadCommand, so they
+//return themselves.
+func (l *LoadCommand) Value()Command{
+	if LC_SEGMENT_64 == l.Command{
+		return l
+	}
+	if nil == l.Decoded{
+		return RawCommand{CommandHeader{l.Command, l.CommandSize}}
+	}
+	return l.Decoded
+}
+
+//Returns the body of the command, everything after Command and CommandSize. Useful for decoding commands the
+//registry does not know about.
+func (l LoadCommand) Payload()[]byte{
+	if len(l.Raw) < 8{
+		return nil
+	}
+	return l.Raw[8:]
+}
+
+//Returns the decoded value of every load command of the given type, in file order.
+func (m FileHeader) FindCommands(command uint32)[]Command{
+	var found []Command
+	for i := range m.LoadCommands{
+		if command == m.LoadCommands[i].Command{
+			found = append(found, m.LoadCommands[i].Value())
+		}
+	}
+	return found
+}
+
+/*
+	//////////////////////////////////////// PRIVATE METHODS ////////////////////////////////////////
+*/
+
+func readHeader(data []byte)CommandHeader{
+	return CommandHeader{binary.LittleEndian.Uint32(data[0:4]), binary.LittleEndian.Uint32(data[4:8])}
+}
+
+//lc_str is an offset from the start of the command to a NUL terminated string.
+func readLCString(data []byte, offset uint32)(string, error){
+	if int(offset) >= len(data){
+		return "", fmt.Errorf("%s: string offset %d is outside the command", CommandName(readHeader(data).Command), offset)
+	}
+	return cString(data[offset:]), nil
+}
+
+func cString(data []byte)string{
+	for i, b := range data{
+		if 0 == b{
+			return string(data[:i])
+		}
+	}
+	return string(data)
+}
+
+func decodeSegment64(data []byte)(Command, error){
+	segment := &LoadCommand{Command: readHeader(data).Command, CommandSize: readHeader(data).CommandSize}
+	parseSegment(segment, data[8:MACH_HEADER_SIZE])
+
+	needed := uint64(MACH_HEADER_SIZE) + uint64(segment.NumOfSections) * SECTION_HEADER_SIZE
+	if needed > uint64(len(data)){
+		return segment, fmt.Errorf("LC_SEGMENT_64 %s: %d sections do not fit in cmdsize %d", segment.SegmentName, segment.NumOfSections, segment.CommandSize)
+	}
+	if 0 != segment.NumOfSections{
+		segment.Sections = make([]SectionHeader, segment.NumOfSections)
+		for j := 0; j < int(segment.NumOfSections); j++{
+			start := MACH_HEADER_SIZE + j * SECTION_HEADER_SIZE
+			parseSection(&segment.Sections[j], data[start:start+SECTION_HEADER_SIZE])
+		}
+	}
+	return segment, nil
+}
+
+func decodeSymtab(data []byte)(Command, error){
+	return SymtabCommand{readHeader(data), u32(data, 8), u32(data, 12), u32(data, 16), u32(data, 20)}, nil
+}
+
+func decodeDysymtab(data []byte)(Command, error){
+	c := DysymtabCommand{CommandHeader: readHeader(data)}
+	fields := []*uint32{&c.ILocalSym, &c.NLocalSym, &c.IExtDefSym, &c.NExtDefSym, &c.IUndefSym, &c.NUndefSym,
+		&c.TocOffset, &c.NumToc, &c.ModTabOffset, &c.NumModTab, &c.ExtRefSymOffset, &c.NumExtRefSyms,
+		&c.IndirectSymOffset, &c.NumIndirectSyms, &c.ExtRelOffset, &c.NumExtRel, &c.LocRelOffset, &c.NumLocRel}
+	for i, field := range fields{
+		*field = u32(data, 8 + 4 * i)
+	}
+	return c, nil
+}
+
+func decodeDylib(data []byte)(Command, error){
+	name, err := readLCString(data, u32(data, 8))
+	return DylibCommand{readHeader(data), name, u32(data, 12), u32(data, 16), u32(data, 20)}, err
+}
+
+func decodeDylinker(data []byte)(Command, error){
+	name, err := readLCString(data, u32(data, 8))
+	return DylinkerCommand{readHeader(data), name}, err
+}
+
+func decodeSub(data []byte)(Command, error){
+	name, err := readLCString(data, u32(data, 8))
+	return SubCommand{readHeader(data), name}, err
+}
+
+func decodeRpath(data []byte)(Command, error){
+	path, err := readLCString(data, u32(data, 8))
+	return RpathCommand{readHeader(data), path}, err
+}
+
+func decodeUUID(data []byte)(Command, error){
+	c := UUIDCommand{CommandHeader: readHeader(data)}
+	copy(c.UUID[:], data[8:24])
+	return c, nil
+}
+
+func decodeLinkeditData(data []byte)(Command, error){
+	return LinkeditDataCommand{readHeader(data), u32(data, 8), u32(data, 12)}, nil
+}
+
+func decodeEncryptionInfo(data []byte)(Command, error){
+	return EncryptionInfoCommand{readHeader(data), u32(data, 8), u32(data, 12), u32(data, 16)}, nil
+}
+
+func decodeDyldInfo(data []byte)(Command, error){
+	return DyldInfoCommand{readHeader(data), u32(data, 8), u32(data, 12), u32(data, 16), u32(data, 20),
+		u32(data, 24), u32(data, 28), u32(data, 32), u32(data, 36), u32(data, 40), u32(data, 44)}, nil
+}
+
+func decodeVersionMin(data []byte)(Command, error){
+	return VersionMinCommand{readHeader(data), u32(data, 8), u32(data, 12)}, nil
+}
+
+func decodeBuildVersion(data []byte)(Command, error){
+	c := BuildVersionCommand{CommandHeader: readHeader(data), Platform: u32(data, 8), MinOS: u32(data, 12), SDK: u32(data, 16)}
+	numTools := u32(data, 20)
+	if uint64(24) + uint64(numTools) * 8 > uint64(len(data)){
+		return c, fmt.Errorf("LC_BUILD_VERSION: %d tools do not fit in cmdsize %d", numTools, c.CommandSize)
+	}
+	for i := 0; i < int(numTools); i++{
+		c.Tools = append(c.Tools, BuildToolVersion{u32(data, 24 + 8 * i), u32(data, 28 + 8 * i)})
+	}
+	return c, nil
+}
+
+func decodeEntryPoint(data []byte)(Command, error){
+	return EntryPointCommand{readHeader(data), u64(data, 8), u64(data, 16)}, nil
+}
+
+func decodeSourceVersion(data []byte)(Command, error){
+	return SourceVersionCommand{readHeader(data), u64(data, 8)}, nil
+}
+
+func decodeLinkerOption(data []byte)(Command, error){
+	c := LinkerOptionCommand{CommandHeader: readHeader(data)}
+	count := u32(data, 8)
+	rest := data[12:]
+	for i := 0; i < int(count) && 0 != len(rest); i++{
+		option := cString(rest)
+		c.Options = append(c.Options, option)
+		if len(option) >= len(rest){
+			break
+		}
+		rest = rest[len(option)+1:]
+	}
+	return c, nil
+}
+
+func decodeNote(data []byte)(Command, error){
+	return NoteCommand{readHeader(data), trimName(data[8:24]), u64(data, 24), u64(data, 32)}, nil
+}
+
+func u32(data []byte, offset int)uint32{
+	return binary.LittleEndian.Uint32(data[offset:offset+4])
+}
+
+func u64(data []byte, offset int)uint64{
+	return binary.LittleEndian.Uint64(data[offset:offset+8])
+}
@@ -0,0 +1,47 @@
+package machoHeader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+//A dylib_command whose name offset points past the end of the command.
+func brokenDylibCommand(command uint32)[]byte{
+	encoded := make([]byte, 24)
+	binary.LittleEndian.PutUint32(encoded[0:], command)
+	binary.LittleEndian.PutUint32(encoded[4:], 24)
+	binary.LittleEndian.PutUint32(encoded[8:], 100)
+	binary.LittleEndian.PutUint32(encoded[16:], 0x10000)
+	return encoded
+}
+
+//Commands the decoder rejects keep the fields which could be read and the error, and the install name editors
+//refuse to rewrite them from those fields.
+func TestDecodeErrors(t *testing.T){
+	m := buildImage(t, nil, brokenDylibCommand(LC_ID_DYLIB), brokenDylibCommand(LC_LOAD_DYLIB))
+	for _, l := range m.LoadCommands[len(m.LoadCommands)-2:]{
+		dylib, ok := l.Decoded.(DylibCommand)
+		if nil == l.DecodeError || !ok || "" != dylib.Name || 0x10000 != dylib.CurrentVersion{
+			t.Errorf("%s decoded as %+v, error %v", CommandName(l.Command), l.Decoded, l.DecodeError)
+		}
+	}
+	if _, err := DecodeCommand(brokenDylibCommand(LC_LOAD_DYLIB)[:20]); nil == err{
+		t.Error("decoded a dylib command shorter than its fixed fields")
+	}
+
+	before, err := m.Bytes()
+	if nil != err{
+		t.Fatal(err)
+	}
+	if err := m.SetID("@rpath/libfoo.dylib"); nil == err{
+		t.Error("SetID rewrote an LC_ID_DYLIB whose name could not be read")
+	}
+	if err := m.ChangeInstallName("", "/usr/lib/libfoo.dylib"); nil == err{
+		t.Error("ChangeInstallName rewrote an LC_LOAD_DYLIB whose name could not be read")
+	}
+	after, _ := m.Bytes()
+	if !bytes.Equal(before, after){
+		t.Error("a refused edit changed the image")
+	}
+}
@@ -6,11 +6,13 @@ package machoHeader
  */
 
 import (
+	"bytes"
 	"cycle1/errorHandling"
 	"debug/macho"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 )
@@ -27,6 +29,44 @@ const (
 	CPU_SUBTYPE_ARMV7S 			= 11
 )
 
+//Flags for the mach header, same values translateFlags prints
+const (
+	MH_NOUNDEFS					= 0x1
+	MH_DYLDLINK					= 0x4
+	MH_PREBOUND					= 0x10
+	MH_SPLIT_SEGS				= 0x20
+	MH_TWOLEVEL					= 0x80
+	MH_FORCE_FLAT				= 0x100
+	MH_WEAK_DEFINES				= 0x8000
+	MH_BINDS_TO_WEAK			= 0x10000
+	MH_ALLOW_STACK_EXECUTION	= 0x20000
+	MH_NO_REEXPORTED_DYLIBS		= 0x100000
+	MH_PIE						= 0x200000
+	MH_HAS_TLV_DESCRIPTORS		= 0x800000
+	MH_NO_HEAP_EXECUTION		= 0x1000000
+	MH_APP_EXTENSION_SAFE		= 0x2000000
+	MH_HAS_OBJC					= 0x40000000
+)
+
+//Looks up header flags by name, for policies and rules
+var headerFlagNames = map[string]uint32{
+	"MH_NOUNDEFS":					MH_NOUNDEFS,
+	"MH_DYLDLINK":					MH_DYLDLINK,
+	"MH_PREBOUND":					MH_PREBOUND,
+	"MH_SPLIT_SEGS":				MH_SPLIT_SEGS,
+	"MH_TWOLEVEL":					MH_TWOLEVEL,
+	"MH_FORCE_FLAT":				MH_FORCE_FLAT,
+	"MH_WEAK_DEFINES":				MH_WEAK_DEFINES,
+	"MH_BINDS_TO_WEAK":				MH_BINDS_TO_WEAK,
+	"MH_ALLOW_STACK_EXECUTION":		MH_ALLOW_STACK_EXECUTION,
+	"MH_NO_REEXPORTED_DYLIBS":		MH_NO_REEXPORTED_DYLIBS,
+	"MH_PIE":						MH_PIE,
+	"MH_HAS_TLV_DESCRIPTORS":		MH_HAS_TLV_DESCRIPTORS,
+	"MH_NO_HEAP_EXECUTION":			MH_NO_HEAP_EXECUTION,
+	"MH_APP_EXTENSION_SAFE":		MH_APP_EXTENSION_SAFE,
+	"MH_HAS_OBJC":					MH_HAS_OBJC,
+}
+
 //Includes for the Command and CommandSize fields
 const (
 	MACH_HEADER_SIZE 			= 72
@@ -116,6 +156,10 @@ type LoadCommand struct{
 	NumOfSections uint32
 	Flags uint32
 	Sections []SectionHeader
+	Decoded Command		//typed value from the load command registry, nil for segments which are decoded into the fields above
+	DecodeError error	//set when the registered decoder rejected the command, see DecodeCommand
+	Offset uint64		//file offset of the command
+	Raw []byte			//every byte of the command, including Command and CommandSize
 }
 
 //taken from Library/Developer/CommandLineTools/SDKs/MacOSX10.15.sdk/usr/include/mach-o/loader.h
@@ -137,6 +181,7 @@ type SectionHeader struct{
 type FileHeader struct{
 	Header machoHeader
 	LoadCommands []LoadCommand
+	data []byte		//the whole file, kept so section contents can be read and the file written back out
 }
 
 /*
@@ -144,26 +189,62 @@ type FileHeader struct{
  */
 
 func LoadStruct(inputFilename string)FileHeader{
-	var myHeader FileHeader
+	data, err := os.ReadFile(inputFilename)
+	errorHandling.CheckErr(err)
 
-	inputFile, err := os.Open(inputFilename)
+	return LoadBytes(data)
+}
+
+//Same as LoadStruct, for a Mach-O image which is already in memory.
+func LoadBytes(data []byte)FileHeader{
+	myHeader, err := ParseBytes(data)
 	errorHandling.CheckErr(err)
 
+	return myHeader
+}
+
+//Same as LoadBytes, but a file which is not a well formed 64 bit Mach-O image is returned as an error instead
+//of stopping the program, for the commands which go through a list of files.
+func ParseBytes(data []byte)(FileHeader, error){
+	var myHeader FileHeader
+	myHeader.data = data
+
+	if IsFat(data){
+		return myHeader, errors.New("this is a universal binary, use the lipo command to extract a single architecture")
+	}
+	if len(data) < MACH_HEADER_64_SIZE{
+		return myHeader, errors.New("too short to be a Mach-O file")
+	}
+	if magic := binary.LittleEndian.Uint32(data[0:4]); macho.Magic64 != magic{
+		return myHeader, fmt.Errorf("bad magic 0x%x, not a 64 bit Mach-O file", magic)
+	}
+
+	inputFile := bytes.NewReader(data)
+
 	fromFile := make([]byte, binary.Size(myHeader.Header))
-	err = binary.Read(inputFile, binary.LittleEndian, fromFile)
-	errorHandling.CheckErr(err)
+	err := binary.Read(inputFile, binary.LittleEndian, fromFile)
+	if nil != err{
+		return myHeader, err
+	}
 
 	myHeader.populateHeader(fromFile)
 
+	//every load command is at least 8 bytes, so a count or size which does not fit the file is garbage
+	if uint64(myHeader.Header.Cmdsz) > uint64(len(data) - MACH_HEADER_64_SIZE) ||
+		uint64(myHeader.Header.Ncmd) * 8 > uint64(myHeader.Header.Cmdsz){
+		return myHeader, fmt.Errorf("%d load commands in 0x%x bytes do not fit in the file", myHeader.Header.Ncmd, myHeader.Header.Cmdsz)
+	}
+
 	//must read in the next 4 bytes as they are reserved
 	fromFile = make([]byte, 4)
 	err = binary.Read(inputFile, binary.LittleEndian, fromFile)
+	if nil != err{
+		return myHeader, err
+	}
 
-	myHeader.populateCommands(inputFile)
-
-	inputFile.Close()
+	err = myHeader.populateCommands(inputFile)
 
-	return myHeader
+	return myHeader, err
 }
 
 //TODO: Associate with the structure FileHeader.
@@ -177,6 +258,7 @@ func PrintSection(header SectionHeader){
 	fmt.Println("\tRelocation Offset: ", header.RelocOffset)
 	fmt.Println("\tNumber of Realocations: ", header.NumReloc)
 	fmt.Printf("\tFlags: 0x%x\n", header.Flags)
+	translateSectionFlags(header.Flags)
 	fmt.Println("\tReserved1: ", header.Special1)
 	fmt.Println("\tReserved2: ", header.Special2)
 	fmt.Println("\tReserved3: ", header.Special3)
@@ -192,129 +274,45 @@ func PrintSegment(command LoadCommand, indent int){
 	fmt.Printf("%s VMAddress: 0x%x\n", strings.Repeat("-",indent), command.VmAddress)
 	fmt.Printf("%s VMSize: 0x%x\n", strings.Repeat("-",indent),command.VmSize)
 	fmt.Println(strings.Repeat("-",indent),"FileOffset: ", command.FileOffset)
-	fmt.Println(strings.Repeat("-",indent),"Max VM Prot: ", command.MaxVMProtectionFlag)
-	fmt.Println(strings.Repeat("-",indent),"Init VM Prot: ", command.InitVMProtectionFlag)
+	fmt.Println(strings.Repeat("-",indent),"Max VM Prot: ", command.MaxProtection())
+	fmt.Println(strings.Repeat("-",indent),"Init VM Prot: ", command.InitProtection())
+	if command.IsWritableExecutable(){
+		fmt.Println(strings.Repeat("-",indent),"WARNING: segment is writable and executable")
+	}
 	fmt.Println(strings.Repeat("-",indent),"Num of Sections: ", command.NumOfSections)
-	fmt.Println(strings.Repeat("-",indent),"Flags: ", command.Flags)
+	fmt.Printf("%s Flags: 0x%x\n", strings.Repeat("-",indent), command.Flags)
+	translateSegmentFlags(command.Flags, indent)
 }
 
 /*
 	//////////////////////////////////////// PRIVATE METHODS ////////////////////////////////////////
 */
 
-func handleLC(command uint32, indent int){
-	if LC_SEGMENT == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_SEGMENT")
-	} else if LC_SYMTAB == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_SYMTAB")
-	} else if LC_SYMSEG == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_SYMSEG")
-	} else if LC_THREAD == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_THREAD")
-	} else if LC_UNIXTHREAD == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_UNIXTHREAD")
-	} else if LC_LOADFVMLIB == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_LOADFVMLIB")
-	} else if LC_IDFVMLIB == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_IDFVMLIB")
-	} else if LC_IDENT == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_IDENT")
-	} else if LC_FVMFILE == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_FVMFILE")
-	} else if LC_PREPAGE == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_PREPAGE")
-	} else if LC_DYSYMTAB == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_DYSYMTAB")
-	} else if LC_LOAD_DYLIB == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_LOAD_DYLIB")
-	} else if LC_ID_DYLIB == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_ID_DYLIB")
-	} else if LC_LOAD_DYLINKER == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_LOAD_DYLINKER")
-	} else if LC_ID_DYLINKER == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_ID_DYLINKER")
-	} else if LC_PREBOUND_DYLIB == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_PREBOUND_DYLIB")
-	} else if LC_ROUTINES == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_ROUTINES")
-	} else if LC_SUB_FRAMEWORK == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_SUB_FRAMEWORK")
-	} else if LC_SUB_UMBRELLA == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_SUB_UMBRELLA")
-	} else if LC_SUB_CLIENT == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_SUB_CLIENT")
-	} else if LC_SUB_LIBRARY == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_SUB_LIBRARY")
-	} else if LC_TWOLEVEL_HINTS == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_TWOLEVEL_HINTS")
-	} else if LC_PREBIND_CKSUM == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_PREBIND_CKSUM")
-	} else if LC_SEGMENT_64 == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_SEGMENT_64")
-	} else if LC_ROUTINES_64 == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_ROUTINES_64")
-	} else if LC_UUID == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_UUID")
-	} else if LC_RPATH == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_RPATH")
-	} else if LC_CODE_SIGNATURE == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_CODE_SIGNATURE")
-	} else if LC_SEGMENT_SPLIT_INFO == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_SEGMENT_SPLIT_INFO")
-	} else if LC_REEXPORT_DYLIB == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_REEXPORT_DYLIB")
-	} else if LC_LAZY_LOAD_DYLIB == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_LAZY_LOAD_DYLIB")
-	} else if LC_ENCRYPTION_INFO == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_ENCRYPTION_INFO")
-	} else if LC_DYLD_INFO == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_DYLD_INFO")
-	} else if LC_DYLD_INFO_ONLY == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_DYLD_INFO_ONLY")
-	} else if LC_LOAD_UPWARD_DYLIB == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_LOAD_UPWARD_DYLIB")
-	} else if LC_VERSION_MIN_MACOSX == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_VERSION_MIN_MACOSX")
-	} else if LC_VERSION_MIN_IPHONEOS == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_VERSION_MIN_IPHONEOS")
-	} else if LC_FUNCTION_STARTS == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_FUNCTION_STARTS")
-	} else if LC_DYLD_ENVIRONMENT == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_DYLD_ENVIRONMENT")
-	} else if LC_MAIN == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_MAIN")
-	} else if LC_DATA_IN_CODE == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_DATA_IN_CODE")
-	} else if LC_SOURCE_VERSION == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_SOURCE_VERSION")
-	} else if LC_DYLIB_CODE_SIGN_DRS == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_DYLIB_CODE_SIGN_DRS")
-	} else if LC_ENCRYPTION_INFO_64 == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_ENCRIPTION_INFO_64")
-	} else if LC_LINKER_OPTION == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_LINKER_OPTION")
-	} else if LC_LINKER_OPTIMIZATION_HINT == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_LINKER_OPTIMIZATION_HINT")
-	} else if LC_VERSION_MIN_TVOS == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_VERSION_MIN_TVOS")
-	} else if LC_VERSION_MIN_WATCHOS == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_VERSION_MIN_WATCHOS")
-	} else if LC_NOTE == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_NOTE")
-	} else if LC_BUILD_VERSION == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_BUILD_VERSION")
-	} else if LC_DYLD_EXPORTS_TRIE == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_DYLD_EXPORTS_TRIE")
-	} else if LC_DYLD_CHAINED_FIXUPS == command {
-		fmt.Println(strings.Repeat("-",indent),"LC_DYLD_CHAINED_FIXUPS")
-	} else {
-		fmt.Println(strings.Repeat("-",indent),"UNKNOWN LOAD COMMAND")
+//Names come from the load command registry in loadCommands.go. Commands with a typed decoder also print
+//their decoded contents.
+func handleLC(command LoadCommand, indent int){
+	fmt.Println(strings.Repeat("-",indent),command.Value())
+	if _, raw := command.Value().(RawCommand); raw{
+		payload := command.Payload()
+		more := ""
+		if len(payload) > 32{
+			payload, more = payload[:32], " ..."
+		}
+		fmt.Printf("%s offset 0x%x raw % x%s\n", strings.Repeat("-",indent), command.Offset, payload, more)
+	}
+	if nil != command.DecodeError{
+		fmt.Println(strings.Repeat("-",indent),"ERROR:",command.DecodeError)
 	}
 }
 
+//Segment and section names are fixed 16 byte fields padded out with NULs.
+func trimName(data []byte)string{
+	return strings.TrimRight(string(data), "\x00")
+}
+
 func parseSection(header *SectionHeader, data []byte){
-	header.SectionName	= string(data[0:16])
-	header.SegmentName	= string(data[16:32])
+	header.SectionName	= trimName(data[0:16])
+	header.SegmentName	= trimName(data[16:32])
 	header.Address		= binary.LittleEndian.Uint64(data[32:40])
 	header.Size			= binary.LittleEndian.Uint64(data[40:48])
 	header.Offset		= binary.LittleEndian.Uint32(data[48:52])
@@ -328,7 +326,7 @@ func parseSection(header *SectionHeader, data []byte){
 }
 
 func parseSegment(segment *LoadCommand, data []byte){
-	segment.SegmentName = string(data[0:16])
+	segment.SegmentName = trimName(data[0:16])
 	segment.VmAddress = binary.LittleEndian.Uint64(data[16:24])
 	segment.VmSize = binary.LittleEndian.Uint64(data[24:32])
 	segment.FileOffset = binary.LittleEndian.Uint64(data[32:40])
@@ -388,36 +386,10 @@ func translateFlags(arg uint32){
 	}
 }
 
-//Values and translation provided by Jonathan Levin's OSX Internals book 1, page 163
-func translateSubCPU(arg uint32)(string, error){
-
-	var retValue string
-	var localError error
-	problem := 0
-
-	if CPU_SUBTYPE_ARM64_ALL == arg{
-		retValue = "CPU_SUBTYPE_ARM64_ALL"
-	} else if CPU_SUBTYPE_ARM64_V8 == arg{
-		retValue = "CPU_SUBTYPE_ARM64_V8"
-	} else if CPU_SUBTYPE_X86_64_ALL == arg{
-		retValue = "CPU_SUBTYPE_X86_64_ALL"
-	} else if CPU_SUBTYPE_X86_64_H == arg{
-		retValue = "CPU_SUBTYPE_X86_64_H"
-	} else if CPU_SUBTYPE_ARMV7 == arg{
-		retValue = "CPU_SUBTYPE_ARMV7"
-	} else if CPU_SUBTYPE_ARMV7S == arg{
-		retValue = "CPU_SUBTYPE_ARMV7S"
-	} else {
-		problem = 1
-	}
-
-	if 0 != problem{
-		localError = errors.New("CPUSub type not recognized or supported")
-	} else {
-		localError = nil
-	}
-
-	return retValue,localError
+//Values and translation provided by Jonathan Levin's OSX Internals book 1, page 163, extended with the full
+//tables from mach/machine.h in cpu.go. Unknown values are named by their raw value instead of failing.
+func translateSubCPU(cpu macho.Cpu, arg uint32)string{
+	return CPUSubtypeName(cpu, arg)
 }
 
 
@@ -440,16 +412,20 @@ func (m FileHeader) PrintStruct(){
 			}
 		} else {
 			fmt.Println("Other Segment Type: ")
-			handleLC(m.LoadCommands[i].Command, 4)
+			handleLC(m.LoadCommands[i], 4)
 		}
 	}
 }
 
+//True if every bit of flag (one of the MH_ constants) is set in the header.
+func (m FileHeader) HasFlag(flag uint32)bool{
+	return flag == m.Header.Flags & flag
+}
+
 func (m FileHeader) PrintMachoHeader(){
 	fmt.Printf("Magic Number:%s%x\n",strings.Repeat("-",25-13), m.Header.Magic)
-	fmt.Printf("CPU:%s%s\n", strings.Repeat("-",25-4), m.Header.Cpu.String())
-	subCPU, err := translateSubCPU(m.Header.SubCpu)
-	errorHandling.CheckErr(err)
+	fmt.Printf("CPU:%s%s\n", strings.Repeat("-",25-4), CPUTypeName(m.Header.Cpu))
+	subCPU := translateSubCPU(m.Header.Cpu, m.Header.SubCpu)
 	fmt.Printf("SubCPU:%s%s\n", strings.Repeat("-",25-7), subCPU)			//
 	fmt.Printf("Type:%s%s\n", strings.Repeat("-",25-5), m.Header.Type.String())			//Type of mach-o
 	fmt.Printf("# of Load commands:%s0x%x\n", strings.Repeat("-",25-19), m.Header.Ncmd)				//number of load commands
@@ -473,46 +449,48 @@ func (m *FileHeader) populateHeader(h []byte){
 	m.Header.Flags = binary.LittleEndian.Uint32(h[24:28])
 }
 
-func (m *FileHeader) populateCommands(inputFile *os.File){
+func (m *FileHeader) populateCommands(inputFile io.Reader)error{
 
 	m.LoadCommands = make([]LoadCommand, m.Header.Ncmd)
 
+	//Commands start after the header and its 4 reserved bytes
+	offset := uint64(binary.Size(m.Header)) + 4
+	end := offset + uint64(m.Header.Cmdsz)
+
 	for i := 0; i < int(m.Header.Ncmd); i++{
 
-		//retrieve Command
-		temp := make([]byte, 4)
+		//retrieve Command and Command Size
+		temp := make([]byte, 8)
 		err := binary.Read(inputFile,binary.LittleEndian,temp)
-		errorHandling.CheckErr(err)
-		m.LoadCommands[i].Command = binary.LittleEndian.Uint32(temp)
-
-		//Retrieve Command Size
-		err = binary.Read(inputFile,binary.LittleEndian,temp)
-		errorHandling.CheckErr(err)
-		m.LoadCommands[i].CommandSize = binary.LittleEndian.Uint32(temp)
+		if nil != err{
+			return fmt.Errorf("load command %d: %w", i, err)
+		}
+		m.LoadCommands[i].Command = binary.LittleEndian.Uint32(temp[0:4])
+		m.LoadCommands[i].CommandSize = binary.LittleEndian.Uint32(temp[4:8])
+		if m.LoadCommands[i].CommandSize < 8 || offset + uint64(m.LoadCommands[i].CommandSize) > end{
+			return fmt.Errorf("load command %d has an invalid cmdsize of %d", i, m.LoadCommands[i].CommandSize)
+		}
 
 		//CommandSize counts the Command and CommandSize, which have already been read in.
-		if LC_SEGMENT_64 == m.LoadCommands[i].Command{
-			temp = make([]byte, MACH_HEADER_SIZE-8)
-			err = binary.Read(inputFile, binary.LittleEndian, temp)
-			errorHandling.CheckErr(err)
-
-			parseSegment(&m.LoadCommands[i], temp)
-			if 0 != m.LoadCommands[i].NumOfSections{
-				m.LoadCommands[i].Sections = make([]SectionHeader, m.LoadCommands[i].NumOfSections)
-				for j := 0; j < int(m.LoadCommands[i].NumOfSections); j++{
-					temp = make([]byte, SECTION_HEADER_SIZE)
-					binary.Read(inputFile, binary.LittleEndian, temp)
-					parseSection(&m.LoadCommands[i].Sections[j], temp)
-				}
-			}
+		body := make([]byte, m.LoadCommands[i].CommandSize-8)
+		err = binary.Read(inputFile,binary.LittleEndian,body)
+		if nil != err{
+			return fmt.Errorf("load command %d: %w", i, err)
+		}
 
+		raw := append(temp, body...)
+		decoded, err := DecodeCommand(raw)
+		if segment, ok := decoded.(*LoadCommand); ok{
+			m.LoadCommands[i] = *segment
 		} else {
-			temp = make([]byte, m.LoadCommands[i].CommandSize-8)
-			err = binary.Read(inputFile,binary.LittleEndian,temp)
-			errorHandling.CheckErr(err)
+			m.LoadCommands[i].Decoded = decoded
 		}
+		m.LoadCommands[i].DecodeError = err
+		m.LoadCommands[i].Offset = offset
+		m.LoadCommands[i].Raw = raw
+
+		offset += uint64(m.LoadCommands[i].CommandSize)
 	}
 
+	return nil
 }
-
-
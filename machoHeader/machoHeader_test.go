@@ -0,0 +1,184 @@
+package machoHeader
+
+import (
+	"debug/macho"
+	"encoding/binary"
+	"os"
+	"testing"
+)
+
+//test1.out in the repository root is a small x86_64 macOS executable: a main which prints "hello world\n"
+//through _printf, linked against libSystem.
+const FIXTURE = "../test1.out"
+
+func loadFixture(t *testing.T)FileHeader{
+	t.Helper()
+	data, err := os.ReadFile(FIXTURE)
+	if nil != err{
+		t.Fatal(err)
+	}
+	m, err := ParseBytes(data)
+	if nil != err{
+		t.Fatalf("ParseBytes(%s): %v", FIXTURE, err)
+	}
+	return m
+}
+
+//Layout of the images buildImage makes. Each segment maps 0x4000 bytes of the file, __TEXT from the start.
+const (
+	IMAGE_TEXT				= 0x100000000
+	IMAGE_DATA				= 0x100004000
+	IMAGE_LINKEDIT			= 0x100008000
+	IMAGE_SEGMENT_SIZE		= 0x4000
+)
+
+//Contents of an image made by buildImage, copied to the file offset address maps to. A name makes it a
+//section of segment, with size standing in for data in zero fill sections; without one it is raw bytes.
+type imageSection struct{
+	segment string
+	name string
+	address uint64
+	data []byte
+	size uint64
+	flags uint32
+}
+
+//Builds an x86_64 PIE executable with __TEXT, __DATA and __LINKEDIT segments holding sections, followed by
+//the already encoded load commands.
+func buildImage(t *testing.T, sections []imageSection, commands ...[]byte)FileHeader{
+	t.Helper()
+	segments := []struct{
+		name string
+		address uint64
+		protection uint32
+	}{{"__TEXT", IMAGE_TEXT, 5}, {"__DATA", IMAGE_DATA, 3}, {"__LINKEDIT", IMAGE_LINKEDIT, 1}}
+
+	data := make([]byte, IMAGE_SEGMENT_SIZE * len(segments))
+	var encoded []byte
+	for i, segment := range segments{
+		var headers []byte
+		for _, section := range sections{
+			if segment.name != section.segment{
+				continue
+			}
+			offset := section.address - IMAGE_TEXT
+			if "" == section.name{
+				copy(data[offset:], section.data)
+				continue
+			}
+			header := make([]byte, SECTION_HEADER_SIZE)
+			copy(header[0:16], section.name)
+			copy(header[16:32], section.segment)
+			binary.LittleEndian.PutUint64(header[32:], section.address)
+			size := uint64(len(section.data))
+			if nil == section.data{
+				size = section.size
+			} else {
+				binary.LittleEndian.PutUint32(header[48:], uint32(offset))
+				copy(data[offset:], section.data)
+			}
+			binary.LittleEndian.PutUint64(header[40:], size)
+			binary.LittleEndian.PutUint32(header[64:], section.flags)
+			headers = append(headers, header...)
+		}
+
+		command := make([]byte, MACH_HEADER_SIZE)
+		binary.LittleEndian.PutUint32(command[0:], LC_SEGMENT_64)
+		binary.LittleEndian.PutUint32(command[4:], uint32(MACH_HEADER_SIZE + len(headers)))
+		copy(command[8:24], segment.name)
+		binary.LittleEndian.PutUint64(command[24:], segment.address)
+		binary.LittleEndian.PutUint64(command[32:], IMAGE_SEGMENT_SIZE)
+		binary.LittleEndian.PutUint64(command[40:], uint64(i * IMAGE_SEGMENT_SIZE))
+		binary.LittleEndian.PutUint64(command[48:], IMAGE_SEGMENT_SIZE)
+		binary.LittleEndian.PutUint32(command[56:], segment.protection)
+		binary.LittleEndian.PutUint32(command[60:], segment.protection)
+		binary.LittleEndian.PutUint32(command[64:], uint32(len(headers) / SECTION_HEADER_SIZE))
+		encoded = append(append(encoded, command...), headers...)
+	}
+	for _, command := range commands{
+		encoded = append(encoded, command...)
+	}
+
+	binary.LittleEndian.PutUint32(data[0:], macho.Magic64)
+	binary.LittleEndian.PutUint32(data[4:], uint32(CPU_TYPE_X86_64))
+	binary.LittleEndian.PutUint32(data[8:], uint32(CPU_SUBTYPE_X86_64_ALL))
+	binary.LittleEndian.PutUint32(data[12:], uint32(macho.TypeExec))
+	binary.LittleEndian.PutUint32(data[16:], uint32(len(segments) + len(commands)))
+	binary.LittleEndian.PutUint32(data[20:], uint32(len(encoded)))
+	binary.LittleEndian.PutUint32(data[24:], MH_PIE)
+	copy(data[MACH_HEADER_64_SIZE:], encoded)
+
+	m, err := ParseBytes(data)
+	if nil != err{
+		t.Fatal(err)
+	}
+	return m
+}
+
+//Encodes a linkedit_data_command, such as LC_FUNCTION_STARTS or LC_DATA_IN_CODE, for data at the address
+//in __LINKEDIT.
+func linkeditCommand(command uint32, address uint64, size int)[]byte{
+	encoded := make([]byte, 16)
+	binary.LittleEndian.PutUint32(encoded[0:], command)
+	binary.LittleEndian.PutUint32(encoded[4:], 16)
+	binary.LittleEndian.PutUint32(encoded[8:], uint32(address - IMAGE_TEXT))
+	binary.LittleEndian.PutUint32(encoded[12:], uint32(size))
+	return encoded
+}
+
+func encodeCommand(t *testing.T, c CommandEncoder)[]byte{
+	t.Helper()
+	encoded, err := c.Encode()
+	if nil != err{
+		t.Fatal(err)
+	}
+	return encoded
+}
+
+func TestParseBytes(t *testing.T){
+	m := loadFixture(t)
+	if CPU_TYPE_X86_64 != m.Header.Cpu{
+		t.Errorf("cpu = %v, want x86_64", m.Header.Cpu)
+	}
+	if int(m.Header.Ncmd) != len(m.LoadCommands){
+		t.Errorf("%d load commands parsed, header says %d", len(m.LoadCommands), m.Header.Ncmd)
+	}
+	if nil == m.Section("__TEXT", "__cstring"){
+		t.Error("no __TEXT,__cstring section")
+	}
+}
+
+//Malformed files come back as errors instead of panicking or allocating a load command table sized by
+//whatever the header claims.
+func TestParseBytesMalformed(t *testing.T){
+	fixture, err := os.ReadFile(FIXTURE)
+	if nil != err{
+		t.Fatal(err)
+	}
+	withHeader := func(field int, value uint32)[]byte{
+		data := append([]byte(nil), fixture...)
+		binary.LittleEndian.PutUint32(data[field:], value)
+		return data
+	}
+	truncatedCommands := append([]byte(nil), fixture[:32 + 40]...)
+
+	cases := []struct{
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"text", []byte("hello, this is not a Mach-O file\n")},
+		{"short", fixture[:20]},
+		{"32 bit magic", withHeader(0, 0xfeedface)},
+		{"huge ncmd", withHeader(16, 0xffffffff)},
+		{"huge sizeofcmds", withHeader(20, 0xffffffff)},
+		{"ncmd past sizeofcmds", withHeader(16, 0x10000)},
+		{"truncated commands", truncatedCommands},
+		{"universal", []byte{0xca, 0xfe, 0xba, 0xbe, 0, 0, 0, 0}},
+	}
+	for _, c := range cases{
+		if _, err := ParseBytes(c.data); nil == err{
+			t.Errorf("%s: ParseBytes accepted a malformed file", c.name)
+		}
+	}
+}
@@ -0,0 +1,426 @@
+package machoHeader
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+//Layouts from objc4 objc-runtime-new.h, 64 bit only
+const (
+	OBJC_CLASS_SIZE			= 40		//isa, superclass, cache, vtable, data
+	OBJC_CATEGORY_SIZE		= 48
+	OBJC_FAST_DATA_MASK		= 0x00007ffffffffff8
+	OBJC_FAST_IS_SWIFT		= 0x3		//FAST_IS_SWIFT_LEGACY | FAST_IS_SWIFT_STABLE
+
+	RO_META					= 0x1
+	RO_ROOT					= 0x2
+
+	//method_list_t entsizeAndFlags
+	METHOD_LIST_SMALL				= 0x80000000	//relative method list, 32 bit offsets
+	METHOD_LIST_DIRECT_SELECTORS	= 0x40000000	//relative names point at the string, not at a selref
+	METHOD_LIST_FLAGS_MASK			= 0xffff0003
+
+	OBJC_IMAGE_IS_REPLACEMENT					= 1 << 0
+	OBJC_IMAGE_SUPPORTS_GC						= 1 << 1
+	OBJC_IMAGE_REQUIRES_GC						= 1 << 2
+	OBJC_IMAGE_OPTIMIZED_BY_DYLD				= 1 << 3
+	OBJC_IMAGE_SUPPORTS_COMPACTION				= 1 << 4
+	OBJC_IMAGE_IS_SIMULATED						= 1 << 5
+	OBJC_IMAGE_HAS_CATEGORY_CLASS_PROPERTIES	= 1 << 6
+)
+
+type ObjCMethod struct{
+	Name string			`json:"name"`
+	Types string		`json:"types"`
+	Implementation uint64	`json:"implementation,omitempty"`
+}
+
+type ObjCIvar struct{
+	Name string			`json:"name"`
+	Type string			`json:"type"`
+	Offset uint32		`json:"offset"`
+	Size uint32			`json:"size"`
+}
+
+type ObjCProperty struct{
+	Name string			`json:"name"`
+	Attributes string	`json:"attributes"`
+}
+
+type ObjCClass struct{
+	Address uint64					`json:"address"`
+	Name string						`json:"name"`
+	SuperClass string				`json:"superclass,omitempty"`
+	Flags uint32					`json:"flags"`
+	InstanceSize uint32				`json:"instance_size"`
+	Swift bool						`json:"swift,omitempty"`
+	Protocols []string				`json:"protocols,omitempty"`
+	Ivars []ObjCIvar				`json:"ivars,omitempty"`
+	Properties []ObjCProperty		`json:"properties,omitempty"`
+	ClassProperties []ObjCProperty	`json:"class_properties,omitempty"`
+	InstanceMethods []ObjCMethod	`json:"instance_methods,omitempty"`
+	ClassMethods []ObjCMethod		`json:"class_methods,omitempty"`
+}
+
+type ObjCCategory struct{
+	Address uint64					`json:"address"`
+	Name string						`json:"name"`
+	Class string					`json:"class"`
+	Protocols []string				`json:"protocols,omitempty"`
+	Properties []ObjCProperty		`json:"properties,omitempty"`
+	InstanceMethods []ObjCMethod	`json:"instance_methods,omitempty"`
+	ClassMethods []ObjCMethod		`json:"class_methods,omitempty"`
+}
+
+type ObjCProtocol struct{
+	Address uint64							`json:"address"`
+	Name string								`json:"name"`
+	Protocols []string						`json:"protocols,omitempty"`
+	Properties []ObjCProperty				`json:"properties,omitempty"`
+	InstanceMethods []ObjCMethod			`json:"instance_methods,omitempty"`
+	ClassMethods []ObjCMethod				`json:"class_methods,omitempty"`
+	OptionalInstanceMethods []ObjCMethod	`json:"optional_instance_methods,omitempty"`
+	OptionalClassMethods []ObjCMethod		`json:"optional_class_methods,omitempty"`
+}
+
+type ObjCImageInfo struct{
+	Version uint32		`json:"version"`
+	Flags uint32		`json:"flags"`
+}
+
+type ObjCMetadata struct{
+	ImageInfo *ObjCImageInfo		`json:"image_info,omitempty"`
+	Classes []ObjCClass				`json:"classes,omitempty"`
+	Categories []ObjCCategory		`json:"categories,omitempty"`
+	Protocols []ObjCProtocol		`json:"protocols,omitempty"`
+	Selectors []string				`json:"selectors,omitempty"`
+}
+
+//Reads runtime metadata (Objective-C and Swift), which is spread over several segments and linked by pointers
+//that may be chained fixups or binds. The first error is kept and later reads return zero values, so one bad pointer
+//costs a name rather than the whole listing.
+type metadataReader struct{
+	m FileHeader
+	fixups *PointerFixups
+	binds map[uint64]string
+	err error
+	nesting int		//Swift context names being built, see contextName
+}
+
+/*
+	//////////////////////////////////////// PUBLIC METHODS ////////////////////////////////////////
+*/
+
+//Swift ABI version stored in the image info flags by swiftc, 0 for pure Objective-C.
+func (i ObjCImageInfo) SwiftVersion()uint32{
+	if stable := i.Flags >> 16; 0 != stable{
+		return stable
+	}
+	return (i.Flags >> 8) & 0xff
+}
+
+/*
+	//////////////////////////////////////// PUBLIC CLASS METHODS ////////////////////////////////////////
+*/
+
+//Parses __objc_imageinfo, __objc_classlist, __objc_catlist, __objc_protolist and __objc_selrefs, which may
+//live in __DATA, __DATA_CONST or __DATA_DIRTY. Images without Objective-C return empty metadata.
+func (m FileHeader) ObjC()(ObjCMetadata, error){
+	var metadata ObjCMetadata
+	r := newMetadataReader(m)
+
+	if section := m.objcSection("__objc_imageinfo"); nil != section{
+		if data, err := m.SectionData(*section); nil == err && len(data) >= 8{
+			metadata.ImageInfo = &ObjCImageInfo{binary.LittleEndian.Uint32(data[0:4]), binary.LittleEndian.Uint32(data[4:8])}
+		}
+	}
+	for _, address := range r.pointerList("__objc_classlist"){
+		metadata.Classes = append(metadata.Classes, r.class(address))
+	}
+	for _, address := range r.pointerList("__objc_catlist"){
+		metadata.Categories = append(metadata.Categories, r.category(address))
+	}
+	for _, address := range r.pointerList("__objc_protolist"){
+		metadata.Protocols = append(metadata.Protocols, r.protocol(address))
+	}
+	for _, address := range r.pointerList("__objc_selrefs"){
+		metadata.Selectors = append(metadata.Selectors, r.stringAt(address))
+	}
+	return metadata, r.err
+}
+
+/*
+	//////////////////////////////////////// PRIVATE CLASS METHODS ////////////////////////////////////////
+*/
+
+func (m FileHeader) objcSection(name string)*SectionHeader{
+	for _, segment := range []string{"__DATA_CONST", "__DATA", "__DATA_DIRTY", "__OBJC_CONST"}{
+		if section := m.Section(segment, name); nil != section{
+			return section
+		}
+	}
+	return nil
+}
+
+func newMetadataReader(m FileHeader)*metadataReader{
+	r := &metadataReader{m: m, fixups: m.PointerFixups()}
+	if 0 == r.fixups.Format{
+		r.binds, r.err = m.Binds()
+	}
+	return r
+}
+
+func (r *metadataReader) fail(err error){
+	if nil == r.err{
+		r.err = err
+	}
+}
+
+func (r *metadataReader) bytes(address uint64, size uint64)[]byte{
+	offset, err := r.m.VAToOffset(address)
+	if nil == err{
+		var data []byte
+		if data, err = r.m.ReadBytes(offset, size); nil == err{
+			return data
+		}
+	}
+	r.fail(err)
+	return make([]byte, size)
+}
+
+func (r *metadataReader) u32(address uint64)uint32{
+	return binary.LittleEndian.Uint32(r.bytes(address, 4))
+}
+
+//Reads and untags a pointer. Binds come back as address 0 and the bound symbol.
+func (r *metadataReader) pointer(address uint64)(uint64, string){
+	target, symbol := r.fixups.Untag(binary.LittleEndian.Uint64(r.bytes(address, 8)))
+	if 0 == target && "" == symbol{
+		symbol = r.binds[address]
+	}
+	return target, symbol
+}
+
+//Follows a char * field.
+func (r *metadataReader) cString(address uint64)string{
+	target, _ := r.pointer(address)
+	return r.stringAt(target)
+}
+
+func (r *metadataReader) stringAt(address uint64)string{
+	if 0 == address{
+		return ""
+	}
+	offset, err := r.m.VAToOffset(address)
+	if nil != err || offset >= uint64(len(r.m.data)){
+		r.fail(fmt.Errorf("string at 0x%x: %w", address, err))
+		return ""
+	}
+	return cString(r.m.data[offset:])
+}
+
+//The targets of every pointer in one of the __objc_*list sections.
+func (r *metadataReader) pointerList(name string)[]uint64{
+	section := r.m.objcSection(name)
+	if nil == section{
+		return nil
+	}
+	var addresses []uint64
+	for i := uint64(0); i + 8 <= section.Size; i += 8{
+		target, symbol := r.pointer(section.Address + i)
+		if 0 == target{
+			r.fail(fmt.Errorf("%s entry at 0x%x is unresolved %s", name, section.Address + i, symbol))
+			continue
+		}
+		addresses = append(addresses, target)
+	}
+	return addresses
+}
+
+//Name of the class a class pointer refers to, which may be a bind to another image.
+func (r *metadataReader) className(address uint64)string{
+	target, symbol := r.pointer(address)
+	if 0 == target{
+		symbol = strings.TrimPrefix(symbol, "_OBJC_CLASS_$_")
+		return strings.TrimPrefix(symbol, "_OBJC_METACLASS_$_")
+	}
+	data, _ := r.pointer(target + 32)
+	return r.cString((data & OBJC_FAST_DATA_MASK) + 24)
+}
+
+func (r *metadataReader) class(address uint64)ObjCClass{
+	class := ObjCClass{Address: address}
+	class.SuperClass = r.className(address + 8)
+	data, _ := r.pointer(address + 32)
+	class.Swift = 0 != data & OBJC_FAST_IS_SWIFT
+	ro := data & OBJC_FAST_DATA_MASK
+
+	class.Flags = r.u32(ro)
+	class.InstanceSize = r.u32(ro + 8)
+	class.Name = r.cString(ro + 24)
+	class.InstanceMethods = r.methods(ro + 32)
+	class.Protocols = r.protocolNames(ro + 40)
+	class.Ivars = r.ivars(ro + 48)
+	class.Properties = r.properties(ro + 64)
+
+	//class methods and class properties live on the metaclass, which is the class's isa
+	if meta, _ := r.pointer(address); 0 != meta{
+		metaData, _ := r.pointer(meta + 32)
+		metaRO := metaData & OBJC_FAST_DATA_MASK
+		class.ClassMethods = r.methods(metaRO + 32)
+		class.ClassProperties = r.properties(metaRO + 64)
+	}
+	return class
+}
+
+func (r *metadataReader) category(address uint64)ObjCCategory{
+	category := ObjCCategory{Address: address}
+	category.Name = r.cString(address)
+	category.Class = r.className(address + 8)
+	category.InstanceMethods = r.methods(address + 16)
+	category.ClassMethods = r.methods(address + 24)
+	category.Protocols = r.protocolNames(address + 32)
+	category.Properties = r.properties(address + 40)
+	return category
+}
+
+func (r *metadataReader) protocol(address uint64)ObjCProtocol{
+	protocol := ObjCProtocol{Address: address}
+	protocol.Name = r.cString(address + 8)
+	protocol.Protocols = r.protocolNames(address + 16)
+	protocol.InstanceMethods = r.methods(address + 24)
+	protocol.ClassMethods = r.methods(address + 32)
+	protocol.OptionalInstanceMethods = r.methods(address + 40)
+	protocol.OptionalClassMethods = r.methods(address + 48)
+	protocol.Properties = r.properties(address + 56)
+
+	//the extended method types carry the class names of object arguments, in the same order as the methods
+	size := r.u32(address + 64)
+	if size >= 80{
+		if extended, _ := r.pointer(address + 72); 0 != extended{
+			i := uint64(0)
+			for _, list := range [][]ObjCMethod{protocol.InstanceMethods, protocol.ClassMethods, protocol.OptionalInstanceMethods, protocol.OptionalClassMethods}{
+				for j := range list{
+					if types := r.cString(extended + 8 * i); "" != types{
+						list[j].Types = types
+					}
+					i++
+				}
+			}
+		}
+	}
+	return protocol
+}
+
+//Rejects list headers which would make the reader walk megabytes of unrelated data.
+func (r *metadataReader) sane(list uint64, entrySize uint64, count uint64)bool{
+	if 0 == entrySize || entrySize > 256 || count > 0x100000{
+		r.fail(fmt.Errorf("list at 0x%x has entry size %d and %d entries", list, entrySize, count))
+		return false
+	}
+	return true
+}
+
+//Reads the method_list_t a field points to. Relative (small) method lists store 32 bit offsets from each
+//field to the selector reference, type string and implementation.
+func (r *metadataReader) methods(field uint64)[]ObjCMethod{
+	list, _ := r.pointer(field)
+	if 0 == list{
+		return nil
+	}
+	flags := r.u32(list)
+	count := uint64(r.u32(list + 4))
+	entrySize := uint64(flags &^ METHOD_LIST_FLAGS_MASK)
+	if !r.sane(list, entrySize, count){
+		return nil
+	}
+
+	var methods []ObjCMethod
+	for i := uint64(0); i < count; i++{
+		entry := list + 8 + i * entrySize
+		var method ObjCMethod
+		if 0 != flags & METHOD_LIST_SMALL{
+			relative := func(position uint64)uint64{
+				return uint64(int64(position) + int64(int32(r.u32(position))))
+			}
+			if 0 != flags & METHOD_LIST_DIRECT_SELECTORS{
+				method.Name = r.stringAt(relative(entry))
+			} else {
+				method.Name = r.cString(relative(entry))
+			}
+			method.Types = r.stringAt(relative(entry + 4))
+			if 0 != r.u32(entry + 8){
+				method.Implementation = relative(entry + 8)
+			}
+		} else {
+			method.Name = r.cString(entry)
+			method.Types = r.cString(entry + 8)
+			method.Implementation, _ = r.pointer(entry + 16)
+		}
+		methods = append(methods, method)
+	}
+	return methods
+}
+
+func (r *metadataReader) ivars(field uint64)[]ObjCIvar{
+	list, _ := r.pointer(field)
+	if 0 == list{
+		return nil
+	}
+	entrySize := uint64(r.u32(list))
+	count := uint64(r.u32(list + 4))
+	if !r.sane(list, entrySize, count){
+		return nil
+	}
+	var ivars []ObjCIvar
+	for i := uint64(0); i < count; i++{
+		entry := list + 8 + i * entrySize
+		ivar := ObjCIvar{Name: r.cString(entry + 8), Type: r.cString(entry + 16), Size: r.u32(entry + 28)}
+		if offset, _ := r.pointer(entry); 0 != offset{
+			ivar.Offset = r.u32(offset)
+		}
+		ivars = append(ivars, ivar)
+	}
+	return ivars
+}
+
+func (r *metadataReader) properties(field uint64)[]ObjCProperty{
+	list, _ := r.pointer(field)
+	if 0 == list{
+		return nil
+	}
+	entrySize := uint64(r.u32(list))
+	count := uint64(r.u32(list + 4))
+	if !r.sane(list, entrySize, count){
+		return nil
+	}
+	var properties []ObjCProperty
+	for i := uint64(0); i < count; i++{
+		entry := list + 8 + i * entrySize
+		properties = append(properties, ObjCProperty{r.cString(entry), r.cString(entry + 8)})
+	}
+	return properties
+}
+
+//protocol_list_t is a 64 bit count followed by protocol_t pointers.
+func (r *metadataReader) protocolNames(field uint64)[]string{
+	list, _ := r.pointer(field)
+	if 0 == list{
+		return nil
+	}
+	count := binary.LittleEndian.Uint64(r.bytes(list, 8))
+	if !r.sane(list, 8, count){
+		return nil
+	}
+	var names []string
+	for i := uint64(0); i < count; i++{
+		protocol, symbol := r.pointer(list + 8 + 8 * i)
+		if 0 == protocol{
+			names = append(names, strings.TrimPrefix(symbol, "__OBJC_PROTOCOL_$_"))
+			continue
+		}
+		names = append(names, r.cString(protocol + 8))
+	}
+	return names
+}
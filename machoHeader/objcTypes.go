@@ -0,0 +1,353 @@
+package machoHeader
+
+import (
+	"fmt"
+	"strings"
+)
+
+//Objective-C type encodings, see "Type Encodings" in the Objective-C Runtime Programming Guide
+var objcSimpleTypes = map[byte]string{
+	'c': "char",
+	'i': "int",
+	's': "short",
+	'l': "long",
+	'q': "long long",
+	'C': "unsigned char",
+	'I': "unsigned int",
+	'S': "unsigned short",
+	'L': "unsigned long",
+	'Q': "unsigned long long",
+	'f': "float",
+	'd': "double",
+	'D': "long double",
+	'B': "BOOL",
+	'v': "void",
+	'*': "char *",
+	'#': "Class",
+	':': "SEL",
+	'?': "void *",		//function pointer or unknown
+	't': "__int128",
+	'T': "unsigned __int128",
+}
+
+var objcTypeQualifiers = map[byte]string{
+	'r': "const",
+	'n': "in",
+	'N': "inout",
+	'o': "out",
+	'O': "bycopy",
+	'R': "byref",
+	'V': "oneway",
+	'A': "_Atomic",
+}
+
+/*
+	//////////////////////////////////////// PUBLIC METHODS ////////////////////////////////////////
+*/
+
+//Decodes one type encoding such as `@"NSString"` or `^{CGPoint=dd}` into C syntax.
+func DecodeObjCType(encoding string)string{
+	decoded, _ := decodeObjCType(encoding)
+	return decoded
+}
+
+//Renders a method like class-dump: "- (void)setName:(NSString *)arg1;".
+func (method ObjCMethod) Declaration(classMethod bool)string{
+	prefix := "-"
+	if classMethod{
+		prefix = "+"
+	}
+	types := splitMethodTypes(method.Types)
+	returnType := "id"
+	if 0 != len(types){
+		returnType = types[0]
+	}
+
+	//arguments 1 and 2 are self and _cmd
+	parts := strings.SplitAfter(method.Name, ":")
+	if 1 == len(parts){
+		return fmt.Sprintf("%s (%s)%s;", prefix, returnType, method.Name)
+	}
+	var out []string
+	for i, part := range parts{
+		if "" == part{
+			continue
+		}
+		argumentType := "id"
+		if i + 3 < len(types){
+			argumentType = types[i + 3]
+		}
+		out = append(out, fmt.Sprintf("%s(%s)arg%d", part, argumentType, i + 1))
+	}
+	return fmt.Sprintf("%s (%s)%s;", prefix, returnType, strings.Join(out, " "))
+}
+
+//Renders a property like class-dump: "@property(nonatomic, copy) NSString *name;".
+func (property ObjCProperty) Declaration()string{
+	var attributes []string
+	propertyType := "id"
+	for _, attribute := range strings.Split(property.Attributes, ","){
+		if "" == attribute{
+			continue
+		}
+		value := attribute[1:]
+		switch attribute[0]{
+		case 'T':
+			propertyType = DecodeObjCType(value)
+		case 'R':
+			attributes = append(attributes, "readonly")
+		case 'C':
+			attributes = append(attributes, "copy")
+		case '&':
+			attributes = append(attributes, "retain")
+		case 'W':
+			attributes = append(attributes, "weak")
+		case 'N':
+			attributes = append(attributes, "nonatomic")
+		case 'D':
+			attributes = append(attributes, "dynamic")
+		case 'G':
+			attributes = append(attributes, "getter=" + value)
+		case 'S':
+			attributes = append(attributes, "setter=" + value)
+		}
+	}
+	declaration := "@property"
+	if 0 != len(attributes){
+		declaration += "(" + strings.Join(attributes, ", ") + ")"
+	}
+	return fmt.Sprintf("%s %s;", declaration, typedName(propertyType, property.Name))
+}
+
+//Renders the metadata as a class-dump style header: protocols, then classes, then categories.
+func (o ObjCMetadata) Header()string{
+	var b strings.Builder
+	if nil != o.ImageInfo{
+		fmt.Fprintf(&b, "// image info version %d flags 0x%x", o.ImageInfo.Version, o.ImageInfo.Flags)
+		if swift := o.ImageInfo.SwiftVersion(); 0 != swift{
+			fmt.Fprintf(&b, " swift version %d", swift)
+		}
+		b.WriteString("\n\n")
+	}
+
+	for _, protocol := range o.Protocols{
+		fmt.Fprintf(&b, "@protocol %s%s\n", protocol.Name, protocolClause(protocol.Protocols))
+		writeProperties(&b, protocol.Properties, false)
+		writeMethods(&b, protocol.ClassMethods, true)
+		writeMethods(&b, protocol.InstanceMethods, false)
+		if 0 != len(protocol.OptionalClassMethods) || 0 != len(protocol.OptionalInstanceMethods){
+			b.WriteString("\n@optional\n")
+			writeMethods(&b, protocol.OptionalClassMethods, true)
+			writeMethods(&b, protocol.OptionalInstanceMethods, false)
+		}
+		b.WriteString("@end\n\n")
+	}
+
+	for _, class := range o.Classes{
+		fmt.Fprintf(&b, "// 0x%x", class.Address)
+		if class.Swift{
+			b.WriteString(" swift")
+		}
+		fmt.Fprintf(&b, "\n@interface %s", class.Name)
+		if "" != class.SuperClass{
+			b.WriteString(" : " + class.SuperClass)
+		}
+		b.WriteString(protocolClause(class.Protocols) + "\n")
+		if 0 != len(class.Ivars){
+			b.WriteString("{\n")
+			for _, ivar := range class.Ivars{
+				fmt.Fprintf(&b, "    %s;\t// +0x%x\n", typedName(DecodeObjCType(ivar.Type), ivar.Name), ivar.Offset)
+			}
+			b.WriteString("}\n")
+		}
+		writeProperties(&b, class.ClassProperties, true)
+		writeProperties(&b, class.Properties, false)
+		writeMethods(&b, class.ClassMethods, true)
+		writeMethods(&b, class.InstanceMethods, false)
+		b.WriteString("@end\n\n")
+	}
+
+	for _, category := range o.Categories{
+		fmt.Fprintf(&b, "@interface %s (%s)%s\n", category.Class, category.Name, protocolClause(category.Protocols))
+		writeProperties(&b, category.Properties, false)
+		writeMethods(&b, category.ClassMethods, true)
+		writeMethods(&b, category.InstanceMethods, false)
+		b.WriteString("@end\n\n")
+	}
+	return b.String()
+}
+
+/*
+	//////////////////////////////////////// PRIVATE METHODS ////////////////////////////////////////
+*/
+
+func writeMethods(b *strings.Builder, methods []ObjCMethod, classMethods bool){
+	if 0 == len(methods){
+		return
+	}
+	b.WriteString("\n")
+	for _, method := range methods{
+		b.WriteString(method.Declaration(classMethods))
+		if 0 != method.Implementation{
+			fmt.Fprintf(b, "\t// 0x%x", method.Implementation)
+		}
+		b.WriteString("\n")
+	}
+}
+
+func writeProperties(b *strings.Builder, properties []ObjCProperty, classProperties bool){
+	if 0 == len(properties){
+		return
+	}
+	b.WriteString("\n")
+	for _, property := range properties{
+		declaration := property.Declaration()
+		if classProperties{
+			declaration = strings.Replace(declaration, "@property(", "@property(class, ", 1)
+			declaration = strings.Replace(declaration, "@property ", "@property(class) ", 1)
+		}
+		b.WriteString(declaration + "\n")
+	}
+}
+
+func protocolClause(protocols []string)string{
+	if 0 == len(protocols){
+		return ""
+	}
+	return " <" + strings.Join(protocols, ", ") + ">"
+}
+
+//Puts the name in the right place for pointer, array and bitfield types: "NSString *name", "int name[4]",
+//"unsigned int name:1".
+func typedName(cType string, name string)string{
+	if colon := strings.Index(cType, " :"); -1 != colon{
+		return cType[:colon] + " " + name + cType[colon+1:]
+	}
+	if open := strings.Index(cType, "["); -1 != open{
+		return cType[:open] + " " + name + cType[open:]
+	}
+	if strings.HasSuffix(cType, "*"){
+		return cType + name
+	}
+	return cType + " " + name
+}
+
+//Splits a method encoding such as "v24@0:8@16" into its return and argument types, dropping the offsets.
+func splitMethodTypes(encoding string)[]string{
+	var types []string
+	for "" != encoding{
+		decoded, rest := decodeObjCType(encoding)
+		if rest == encoding{
+			break
+		}
+		types = append(types, decoded)
+		encoding = strings.TrimLeft(rest, "-0123456789")
+	}
+	return types
+}
+
+//Decodes the first type in encoding and returns it with the unread remainder. An array, struct, union or
+//block signature which is never closed is returned as it is, with nothing left over.
+func decodeObjCType(encoding string)(string, string){
+	if "" == encoding{
+		return "", ""
+	}
+	c := encoding[0]
+	rest := encoding[1:]
+
+	if qualifier, ok := objcTypeQualifiers[c]; ok{
+		inner, rest := decodeObjCType(rest)
+		return qualifier + " " + inner, rest
+	}
+	if simple, ok := objcSimpleTypes[c]; ok{
+		return simple, rest
+	}
+
+	switch c{
+	case '@':
+		if strings.HasPrefix(rest, "?"){
+			//block, optionally followed by its signature in <>
+			rest = rest[1:]
+			if strings.HasPrefix(rest, "<"){
+				end := matchingClose(rest, '<', '>')
+				if -1 == end{
+					return encoding, ""
+				}
+				rest = rest[end:]
+			}
+			return "CDUnknownBlockType", rest
+		}
+		if strings.HasPrefix(rest, `"`){
+			end := strings.IndexByte(rest[1:], '"')
+			if -1 != end{
+				name := rest[1 : end+1]
+				rest = rest[end+2:]
+				if strings.HasPrefix(name, "<"){
+					return "id " + name, rest
+				}
+				return name + " *", rest
+			}
+		}
+		return "id", rest
+	case '^':
+		inner, rest := decodeObjCType(rest)
+		if strings.HasSuffix(inner, "*"){
+			return inner + "*", rest
+		}
+		return inner + " *", rest
+	case 'b':
+		width := strings.TrimLeft(rest, "0123456789")
+		return fmt.Sprintf("unsigned int :%s", rest[:len(rest)-len(width)]), width
+	case '[':
+		end := matchingClose(encoding, '[', ']')
+		if -1 == end{
+			return encoding, ""
+		}
+		body := encoding[1 : end-1]
+		count := body[:len(body)-len(strings.TrimLeft(body, "0123456789"))]
+		inner, _ := decodeObjCType(body[len(count):])
+		return fmt.Sprintf("%s[%s]", inner, count), encoding[end:]
+	case '{', '(':
+		closing := byte('}')
+		keyword := "struct"
+		if '(' == c{
+			closing, keyword = ')', "union"
+		}
+		end := matchingClose(encoding, c, closing)
+		if -1 == end{
+			return encoding, ""
+		}
+		body := encoding[1 : end-1]
+		name := body
+		if equals := strings.IndexByte(body, '='); -1 != equals{
+			name = body[:equals]
+		}
+		if "?" == name || "" == name{
+			return keyword + " {...}", encoding[end:]
+		}
+		return keyword + " " + name, encoding[end:]
+	}
+	return string(c), rest
+}
+
+//Returns the index just past the bracket which closes the one at the start of s, or -1 if it is not closed.
+func matchingClose(s string, open byte, close byte)int{
+	depth := 0
+	for i := 0; i < len(s); i++{
+		switch{
+		case '"' == s[i]:
+			//class names in quotes can hold brackets
+			if end := strings.IndexByte(s[i+1:], '"'); -1 != end{
+				i += end + 1
+			}
+		case open == s[i]:
+			depth++
+		case close == s[i]:
+			depth--
+			if 0 == depth{
+				return i + 1
+			}
+		}
+	}
+	return -1
+}
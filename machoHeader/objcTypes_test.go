@@ -0,0 +1,76 @@
+package machoHeader
+
+import (
+	"testing"
+)
+
+func TestDecodeObjCType(t *testing.T){
+	cases := []struct{
+		encoding string
+		decoded string
+		rest string
+	}{
+		{"i", "int", ""},
+		{"Q8", "unsigned long long", "8"},
+		{`@"NSString"16`, "NSString *", "16"},
+		{`@"<NSCopying>"`, "id <NSCopying>", ""},
+		{"@", "id", ""},
+		{"@?<v@?>8", "CDUnknownBlockType", "8"},
+		{"^{CGPoint=dd}", "struct CGPoint *", ""},
+		{"^^i", "int **", ""},
+		{"r*", "const char *", ""},
+		{"[4i]", "int[4]", ""},
+		{"{?=ii}", "struct {...}", ""},
+		{"(u=if)x", "union u", "x"},
+		{`{Pair="a"@"NSArray<[x]>"}`, "struct Pair", ""},
+		{"b3i", "unsigned int :3", "i"},
+		//never closed, returned as they are
+		{"[", "[", ""},
+		{"[5", "[5", ""},
+		{"{", "{", ""},
+		{"{CGPoint=dd", "{CGPoint=dd", ""},
+		{"(", "(", ""},
+		{"^{", "{ *", ""},
+		{"@?<v", "@?<v", ""},
+		{"", "", ""},
+	}
+	for _, c := range cases{
+		decoded, rest := decodeObjCType(c.encoding)
+		if c.decoded != decoded || c.rest != rest{
+			t.Errorf("decodeObjCType(%q) = %q, %q, want %q, %q", c.encoding, decoded, rest, c.decoded, c.rest)
+		}
+	}
+}
+
+func TestObjCDeclarations(t *testing.T){
+	methods := []struct{
+		method ObjCMethod
+		declaration string
+	}{
+		{ObjCMethod{Name: "init", Types: "@16@0:8"}, "- (id)init;"},
+		{ObjCMethod{Name: "setName:", Types: `v24@0:8@"NSString"16`}, "- (void)setName:(NSString *)arg1;"},
+		{ObjCMethod{Name: "at:put:", Types: "v32@0:8Q16^{CGPoint=dd}24"}, "- (void)at:(unsigned long long)arg1 put:(struct CGPoint *)arg2;"},
+		{ObjCMethod{Name: "broken:", Types: "v16@0:8["}, "- (void)broken:([)arg1;"},
+		{ObjCMethod{Name: "empty:", Types: ""}, "- (id)empty:(id)arg1;"},
+	}
+	for _, c := range methods{
+		if declaration := c.method.Declaration(false); c.declaration != declaration{
+			t.Errorf("%+v: %q, want %q", c.method, declaration, c.declaration)
+		}
+	}
+
+	properties := []struct{
+		property ObjCProperty
+		declaration string
+	}{
+		{ObjCProperty{"name", `T@"NSString",C,N,V_name`}, "@property(copy, nonatomic) NSString *name;"},
+		{ObjCProperty{"size", "T[4i],R"}, "@property(readonly) int size[4];"},
+		{ObjCProperty{"flag", "Tb1,GisFlag"}, "@property(getter=isFlag) unsigned int flag:1;"},
+		{ObjCProperty{"broken", "T{,N"}, "@property(nonatomic) { broken;"},
+	}
+	for _, c := range properties{
+		if declaration := c.property.Declaration(); c.declaration != declaration{
+			t.Errorf("%+v: %q, want %q", c.property, declaration, c.declaration)
+		}
+	}
+}
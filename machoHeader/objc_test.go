@@ -0,0 +1,188 @@
+package machoHeader
+
+import (
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+//Lays out runtime metadata for buildImage: structures go in __DATA from IMAGE_DATA+0x1000, strings in __TEXT
+//from IMAGE_TEXT+0x1000. Pointers are plain addresses, as in an image without chained fixups.
+type metadataLayout struct{
+	data []byte
+	strings []byte
+}
+
+func newMetadataLayout()*metadataLayout{
+	return &metadataLayout{data: make([]byte, 0x2000)}
+}
+
+//Address of a fresh copy of s in __TEXT.
+func (l *metadataLayout) str(s string)uint64{
+	address := IMAGE_TEXT + 0x1000 + uint64(len(l.strings))
+	l.strings = append(append(l.strings, s...), 0)
+	return address
+}
+
+func (l *metadataLayout) u32(address uint64, value uint32){
+	binary.LittleEndian.PutUint32(l.data[address - IMAGE_DATA - 0x1000:], value)
+}
+
+func (l *metadataLayout) u64(address uint64, values ...uint64){
+	for i, value := range values{
+		binary.LittleEndian.PutUint64(l.data[address - IMAGE_DATA - 0x1000 + 8 * uint64(i):], value)
+	}
+}
+
+//A classic method_list_t of name and type pairs, with no implementations.
+func (l *metadataLayout) methods(address uint64, methods ...string){
+	l.u32(address, 24)
+	l.u32(address + 4, uint32(len(methods) / 2))
+	for i := 0; i < len(methods); i += 2{
+		l.u64(address + 8 + 12 * uint64(i), l.str(methods[i]), l.str(methods[i+1]))
+	}
+}
+
+func (l *metadataLayout) sections(lists ...imageSection)[]imageSection{
+	return append(lists,
+		imageSection{segment: "__TEXT", address: IMAGE_TEXT + 0x1000, data: l.strings},
+		imageSection{segment: "__DATA", address: IMAGE_DATA + 0x1000, data: l.data})
+}
+
+func pointerSection(name string, address uint64, targets ...uint64)imageSection{
+	data := make([]byte, 8 * len(targets))
+	for i, target := range targets{
+		binary.LittleEndian.PutUint64(data[8*i:], target)
+	}
+	return imageSection{segment: "__DATA", name: name, address: address, data: data}
+}
+
+//One class Widget : NSObject <Shape> with an ivar, a property, an instance and a class method, a category on it,
+//the Shape protocol with extended method types, a selector reference and the image info.
+func objcImage(t *testing.T, ivarCount uint32)FileHeader{
+	t.Helper()
+	const BASE = IMAGE_DATA + 0x1000
+	const (
+		CLASS = BASE + iota * 0x100
+		META
+		SUPER
+		RO
+		META_RO
+		SUPER_RO
+		LISTS
+		PROTOCOL
+		CATEGORY
+	)
+	l := newMetadataLayout()
+
+	//isa, superclass, cache, vtable, data
+	l.u64(CLASS, META, SUPER, 0, 0, RO)
+	l.u64(META, 0, 0, 0, 0, META_RO)
+	l.u64(SUPER, 0, 0, 0, 0, SUPER_RO)
+	l.u32(RO, RO_ROOT)
+	l.u32(RO + 8, 24)
+	//name, methods, protocols, ivars, weak ivar layout, properties
+	l.u64(RO + 24, l.str("Widget"), LISTS, LISTS + 0x40, LISTS + 0x60, 0, LISTS + 0xa0)
+	l.u64(META_RO + 24, l.str("Widget"), LISTS + 0xc0)
+	l.u32(META_RO, RO_META)
+	l.u64(SUPER_RO + 24, l.str("NSObject"))
+
+	l.methods(LISTS, "setName:", `v24@0:8@16`)
+	l.u64(LISTS + 0x40, 1, PROTOCOL)
+	//offset pointer, name, type, alignment and size
+	l.u32(LISTS + 0x60, 32)
+	l.u32(LISTS + 0x64, ivarCount)
+	l.u64(LISTS + 0x68, LISTS + 0x90, l.str("_name"), l.str(`@"NSString"`))
+	l.u32(LISTS + 0x68 + 28, 8)
+	l.u32(LISTS + 0x90, 16)
+	l.u32(LISTS + 0xa0, 16)
+	l.u32(LISTS + 0xa4, 1)
+	l.u64(LISTS + 0xa8, l.str("name"), l.str(`T@"NSString",C,N,V_name`))
+	l.methods(LISTS + 0xc0, "shared", "@16@0:8")
+
+	//isa, name, protocols, instance methods ... properties, size, flags, extended method types
+	l.u64(PROTOCOL + 8, l.str("Shape"), 0, PROTOCOL + 0x80)
+	l.u32(PROTOCOL + 64, 80)
+	l.u64(PROTOCOL + 72, PROTOCOL + 0xc0)
+	l.methods(PROTOCOL + 0x80, "drawIn:", "v24@0:8@16")
+	l.u64(PROTOCOL + 0xc0, l.str(`v24@0:8@"NSView"16`))
+
+	//name, class, instance methods
+	l.u64(CATEGORY, l.str("Extras"), CLASS, CATEGORY + 0x40)
+	l.methods(CATEGORY + 0x40, "extra", "v16@0:8[")
+
+	imageInfo := imageSection{segment: "__DATA", name: "__objc_imageinfo", address: IMAGE_DATA + 0x40, data: []byte{0, 0, 0, 0, 0x40, 0x06, 0, 0}}
+	return buildImage(t, l.sections(
+		pointerSection("__objc_classlist", IMAGE_DATA, CLASS),
+		pointerSection("__objc_catlist", IMAGE_DATA + 0x10, CATEGORY),
+		pointerSection("__objc_protolist", IMAGE_DATA + 0x20, PROTOCOL),
+		pointerSection("__objc_selrefs", IMAGE_DATA + 0x30, l.str("alloc")),
+		imageInfo))
+}
+
+func TestObjC(t *testing.T){
+	metadata, err := objcImage(t, 1).ObjC()
+	if nil != err{
+		t.Fatal(err)
+	}
+	if nil == metadata.ImageInfo || 6 != metadata.ImageInfo.SwiftVersion(){
+		t.Errorf("image info %+v", metadata.ImageInfo)
+	}
+	if !reflect.DeepEqual([]string{"alloc"}, metadata.Selectors){
+		t.Errorf("selectors %v", metadata.Selectors)
+	}
+
+	if 1 != len(metadata.Classes){
+		t.Fatalf("%d classes", len(metadata.Classes))
+	}
+	class := metadata.Classes[0]
+	want := ObjCClass{
+		Address: class.Address, Name: "Widget", SuperClass: "NSObject", Flags: RO_ROOT, InstanceSize: 24,
+		Protocols: []string{"Shape"},
+		Ivars: []ObjCIvar{{Name: "_name", Type: `@"NSString"`, Offset: 16, Size: 8}},
+		Properties: []ObjCProperty{{"name", `T@"NSString",C,N,V_name`}},
+		InstanceMethods: []ObjCMethod{{Name: "setName:", Types: "v24@0:8@16"}},
+		ClassMethods: []ObjCMethod{{Name: "shared", Types: "@16@0:8"}},
+	}
+	if !reflect.DeepEqual(want, class){
+		t.Errorf("class %+v\nwant %+v", class, want)
+	}
+
+	if 1 != len(metadata.Protocols) || "Shape" != metadata.Protocols[0].Name{
+		t.Fatalf("protocols %+v", metadata.Protocols)
+	}
+	//the extended type names the argument's class
+	if methods := metadata.Protocols[0].InstanceMethods; 1 != len(methods) || `v24@0:8@"NSView"16` != methods[0].Types{
+		t.Errorf("protocol methods %+v", methods)
+	}
+	if 1 != len(metadata.Categories) || "Extras" != metadata.Categories[0].Name || "Widget" != metadata.Categories[0].Class{
+		t.Errorf("categories %+v", metadata.Categories)
+	}
+
+	header := metadata.Header()
+	for _, line := range []string{
+		"@interface Widget : NSObject <Shape>",
+		"    NSString *_name;\t// +0x10",
+		"@property(copy, nonatomic) NSString *name;",
+		"+ (id)shared;",
+		"- (void)drawIn:(NSView *)arg1;",
+		"@interface Widget (Extras)",
+		"- (void)extra;",
+	}{
+		if !strings.Contains(header, line){
+			t.Errorf("header has no %q:\n%s", line, header)
+		}
+	}
+}
+
+//A list claiming millions of entries is reported and skipped, and everything else is still read.
+func TestObjCMalformed(t *testing.T){
+	metadata, err := objcImage(t, 0x1000000).ObjC()
+	if nil == err || !strings.Contains(err.Error(), "entries"){
+		t.Errorf("error = %v", err)
+	}
+	if 1 != len(metadata.Classes) || nil != metadata.Classes[0].Ivars || 1 != len(metadata.Classes[0].InstanceMethods){
+		t.Errorf("classes %+v", metadata.Classes)
+	}
+}
@@ -0,0 +1,275 @@
+package machoHeader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+//A compliance policy, loaded from JSON:
+//
+//	{"rules": [
+//		{"name": "macOS 11 or later", "type": "min_os_version", "platform": "macOS", "value": "11.0"},
+//		{"type": "team_id", "value": "ABCDE12345"},
+//		{"type": "dylib_prefixes", "values": ["/usr/lib/", "/System/Library/", "@rpath/"]},
+//		{"type": "segment_not_writable", "value": "__TEXT"}
+//	]}
+type Policy struct{
+	Rules []PolicyRule	`json:"rules"`
+}
+
+type PolicyRule struct{
+	Name string			`json:"name,omitempty"`
+	Type string			`json:"type"`
+	Platform string		`json:"platform,omitempty"`	//min_os_version, as PlatformName spells it
+	Value string		`json:"value,omitempty"`
+	Values []string		`json:"values,omitempty"`
+}
+
+//One platform an image is built for and the oldest version of it the image runs on.
+type PlatformVersion struct{
+	Platform string
+	MinOS uint32
+}
+
+type RuleResult struct{
+	Rule string			`json:"rule"`
+	Passed bool			`json:"passed"`
+	Detail string		`json:"detail,omitempty"`
+}
+
+//Each rule type checks one property of the image and returns whether it passed and why.
+type ruleCheck func(rule PolicyRule, m FileHeader)(bool, string)
+
+var ruleChecks = map[string]ruleCheck{
+	"min_os_version":		checkMinOSVersion,
+	"team_id":				checkTeamID,
+	"signed":				checkSigned,
+	"hardened_runtime":		checkHardenedRuntime,
+	"dylib_prefixes":		checkDylibPrefixes,
+	"segment_not_writable":	checkSegmentNotWritable,
+	"no_rwx_segments":		checkNoRWXSegments,
+	"header_flag":			checkHeaderFlag,
+	"no_load_command":		checkNoLoadCommand,
+	"checksec":				checkChecksec,
+}
+
+//Platforms of the LC_VERSION_MIN_ commands, which came before LC_BUILD_VERSION
+var versionMinPlatforms = map[uint32]string{
+	LC_VERSION_MIN_MACOSX:		"macOS",
+	LC_VERSION_MIN_IPHONEOS:	"iOS",
+	LC_VERSION_MIN_TVOS:		"tvOS",
+	LC_VERSION_MIN_WATCHOS:		"watchOS",
+}
+
+/*
+	//////////////////////////////////////// PUBLIC METHODS ////////////////////////////////////////
+*/
+
+//Reads a JSON policy file and checks every rule type is known.
+func LoadPolicy(fileName string)(Policy, error){
+	var policy Policy
+	data, err := os.ReadFile(fileName)
+	if nil != err{
+		return policy, err
+	}
+	if err = json.Unmarshal(data, &policy); nil != err{
+		return policy, fmt.Errorf("%s: %w", fileName, err)
+	}
+	if 0 == len(policy.Rules){
+		return policy, fmt.Errorf("%s: policy has no rules", fileName)
+	}
+	for i, rule := range policy.Rules{
+		if _, ok := ruleChecks[rule.Type]; !ok{
+			return policy, fmt.Errorf("%s: rule %d has unknown type %q", fileName, i, rule.Type)
+		}
+		//version numbers mean nothing without the platform, macOS 11 shipped alongside iOS 14
+		if "min_os_version" == rule.Type && "" == rule.Platform{
+			return policy, fmt.Errorf("%s: rule %d needs a platform such as \"macOS\" or \"iOS\"", fileName, i)
+		}
+	}
+	return policy, nil
+}
+
+//Parses "11", "10.15" or "10.15.4" into the packed xxxx.yy.zz form load commands use.
+func ParseVersion(version string)(uint32, error){
+	parts := strings.Split(version, ".")
+	if len(parts) > 3{
+		return 0, fmt.Errorf("invalid version %q", version)
+	}
+	var packed uint32
+	limits := []uint64{0xffff, 0xff, 0xff}
+	shifts := []uint{16, 8, 0}
+	for i, part := range parts{
+		value, err := strconv.ParseUint(part, 10, 32)
+		if nil != err || value > limits[i]{
+			return 0, fmt.Errorf("invalid version %q", version)
+		}
+		packed |= uint32(value) << shifts[i]
+	}
+	return packed, nil
+}
+
+func (p Policy) Evaluate(m FileHeader)[]RuleResult{
+	var results []RuleResult
+	for _, rule := range p.Rules{
+		passed, detail := ruleChecks[rule.Type](rule, m)
+		results = append(results, RuleResult{rule.String(), passed, detail})
+	}
+	return results
+}
+
+func (r PolicyRule) String()string{
+	if "" != r.Name{
+		return r.Name
+	}
+	if 0 != len(r.Values){
+		return r.Type + " " + strings.Join(r.Values, ",")
+	}
+	if "" != r.Platform{
+		return r.Type + " " + r.Platform + " " + r.Value
+	}
+	return strings.TrimSpace(r.Type + " " + r.Value)
+}
+
+/*
+	//////////////////////////////////////// PUBLIC CLASS METHODS ////////////////////////////////////////
+*/
+
+//Returns the platform and minimum OS version of every LC_BUILD_VERSION and older LC_VERSION_MIN_ command.
+//Zippered images have two, one for macOS and one for macCatalyst.
+func (m FileHeader) MinOSVersions()([]PlatformVersion, error){
+	var versions []PlatformVersion
+	for i := range m.LoadCommands{
+		switch c := m.LoadCommands[i].Decoded.(type){
+		case BuildVersionCommand:
+			versions = append(versions, PlatformVersion{PlatformName(c.Platform), c.MinOS})
+		case VersionMinCommand:
+			versions = append(versions, PlatformVersion{versionMinPlatforms[c.Command], c.Version})
+		}
+	}
+	if 0 == len(versions){
+		return nil, errors.New("no LC_BUILD_VERSION or LC_VERSION_MIN command")
+	}
+	return versions, nil
+}
+
+/*
+	//////////////////////////////////////// PRIVATE METHODS ////////////////////////////////////////
+*/
+
+func checkMinOSVersion(rule PolicyRule, m FileHeader)(bool, string){
+	required, err := ParseVersion(rule.Value)
+	if nil != err{
+		return false, err.Error()
+	}
+	versions, err := m.MinOSVersions()
+	if nil != err{
+		return false, err.Error()
+	}
+
+	//every command for the platform has to meet the version
+	passed := true
+	var found, others []string
+	for _, version := range versions{
+		if !strings.EqualFold(rule.Platform, version.Platform){
+			others = append(others, version.Platform)
+			continue
+		}
+		found = append(found, FormatVersion(version.MinOS))
+		passed = passed && version.MinOS >= required
+	}
+	if 0 == len(found){
+		return false, fmt.Sprintf("built for %s, not %s", strings.Join(others, ", "), rule.Platform)
+	}
+	return passed, fmt.Sprintf("%s minimum version %s", rule.Platform, strings.Join(found, ", "))
+}
+
+func checkTeamID(rule PolicyRule, m FileHeader)(bool, string){
+	signature, err := m.CodeSignature()
+	if nil != err{
+		return false, err.Error()
+	}
+	if nil == signature{
+		return false, "not signed"
+	}
+	if "" == signature.TeamID{
+		return false, "signature has no team ID"
+	}
+	return rule.Value == signature.TeamID, "team ID " + signature.TeamID
+}
+
+func checkSigned(rule PolicyRule, m FileHeader)(bool, string){
+	signature, err := m.CodeSignature()
+	if nil != err{
+		return false, err.Error()
+	}
+	if nil == signature{
+		return false, "not signed"
+	}
+	return true, strings.Join(signature.FlagNames(), " ")
+}
+
+func checkHardenedRuntime(rule PolicyRule, m FileHeader)(bool, string){
+	report := m.Checksec()
+	return report.HardenedRuntime, strings.Join(report.CodeSigningFlags, " ")
+}
+
+func checkDylibPrefixes(rule PolicyRule, m FileHeader)(bool, string){
+	var outside []string
+	for _, dylib := range m.Dylibs(){
+		allowed := false
+		for _, prefix := range rule.Values{
+			allowed = allowed || strings.HasPrefix(dylib.Name, prefix)
+		}
+		if !allowed{
+			outside = append(outside, dylib.Name)
+		}
+	}
+	if 0 != len(outside){
+		return false, "loads " + strings.Join(outside, ", ")
+	}
+	return true, fmt.Sprintf("%d dylibs", len(m.Dylibs()))
+}
+
+func checkSegmentNotWritable(rule PolicyRule, m FileHeader)(bool, string){
+	segment := m.Segment(rule.Value)
+	if nil == segment{
+		return true, "no " + rule.Value + " segment"
+	}
+	detail := fmt.Sprintf("%s max %s init %s", segment.SegmentName, segment.MaxProtection(), segment.InitProtection())
+	return !segment.InitProtection().Writable() && !segment.MaxProtection().Writable(), detail
+}
+
+func checkNoRWXSegments(rule PolicyRule, m FileHeader)(bool, string){
+	segments := m.Checksec().RWXSegments
+	if 0 != len(segments){
+		return false, strings.Join(segments, ", ") + " writable and executable"
+	}
+	return true, ""
+}
+
+func checkHeaderFlag(rule PolicyRule, m FileHeader)(bool, string){
+	flag, ok := headerFlagNames[rule.Value]
+	if !ok{
+		return false, "unknown flag " + rule.Value
+	}
+	return m.HasFlag(flag), fmt.Sprintf("flags 0x%x", m.Header.Flags)
+}
+
+func checkNoLoadCommand(rule PolicyRule, m FileHeader)(bool, string){
+	for i := range m.LoadCommands{
+		if rule.Value == CommandName(m.LoadCommands[i].Command){
+			return false, "has " + m.LoadCommands[i].Value().String()
+		}
+	}
+	return true, ""
+}
+
+func checkChecksec(rule PolicyRule, m FileHeader)(bool, string){
+	violations := m.Checksec().Violations
+	return 0 == len(violations), strings.Join(violations, "; ")
+}
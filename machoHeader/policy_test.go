@@ -0,0 +1,100 @@
+package machoHeader
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//Rewrites the fixture's LC_BUILD_VERSION, which says macOS 10.15.4, to another platform and version.
+func withBuildVersion(t *testing.T, platform uint32, minOS uint32)FileHeader{
+	t.Helper()
+	m := loadFixture(t)
+	data := append([]byte(nil), m.data...)
+	for i := range m.LoadCommands{
+		if LC_BUILD_VERSION == m.LoadCommands[i].Command{
+			binary.LittleEndian.PutUint32(data[m.LoadCommands[i].Offset + 8:], platform)
+			binary.LittleEndian.PutUint32(data[m.LoadCommands[i].Offset + 12:], minOS)
+		}
+	}
+	patched, err := ParseBytes(data)
+	if nil != err{
+		t.Fatal(err)
+	}
+	return patched
+}
+
+func TestMinOSVersion(t *testing.T){
+	macOS := loadFixture(t)
+	iOS := withBuildVersion(t, 2, 14 << 16)
+
+	cases := []struct{
+		m FileHeader
+		platform string
+		value string
+		passed bool
+	}{
+		{macOS, "macOS", "10.15", true},
+		{macOS, "macos", "10.15.4", true},
+		{macOS, "macOS", "11.0", false},
+		{macOS, "iOS", "10", false},
+		{iOS, "iOS", "14", true},
+		{iOS, "iOS", "15", false},
+		//iOS 14 is not macOS 11 even though the number is larger
+		{iOS, "macOS", "11.0", false},
+	}
+	for _, c := range cases{
+		rule := PolicyRule{Type: "min_os_version", Platform: c.platform, Value: c.value}
+		passed, detail := checkMinOSVersion(rule, c.m)
+		if c.passed != passed{
+			t.Errorf("%s: passed = %v (%s), want %v", rule, passed, detail, c.passed)
+		}
+	}
+}
+
+func TestLoadPolicy(t *testing.T){
+	dir := t.TempDir()
+	write := func(name string, text string)string{
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(text), 0644); nil != err{
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	policy, err := LoadPolicy(write("good.json", `{"rules": [
+		{"type": "min_os_version", "platform": "macOS", "value": "10.15"},
+		{"type": "dylib_prefixes", "values": ["/usr/lib/"]},
+		{"type": "header_flag", "value": "MH_PIE"},
+		{"type": "header_flag", "value": "MH_NO_HEAP_EXECUTION"},
+		{"name": "no dyld", "type": "no_load_command", "value": "LC_LOAD_DYLINKER"}]}`))
+	if nil != err{
+		t.Fatal(err)
+	}
+	results := policy.Evaluate(loadFixture(t))
+	want := map[string]bool{
+		"min_os_version macOS 10.15":				true,
+		"dylib_prefixes /usr/lib/":					true,
+		"header_flag MH_PIE":						true,
+		"header_flag MH_NO_HEAP_EXECUTION":			false,
+		"no dyld":									false,
+	}
+	for _, result := range results{
+		if passed, ok := want[result.Rule]; !ok || passed != result.Passed{
+			t.Errorf("%s passed = %v (%s)", result.Rule, result.Passed, result.Detail)
+		}
+	}
+
+	bad := map[string]string{
+		`{"rules": []}`:											"no rules",
+		`{"rules": [{"type": "nope"}]}`:							"unknown type",
+		`{"rules": [{"type": "min_os_version", "value": "11"}]}`:	"needs a platform",
+	}
+	for text, message := range bad{
+		if _, err := LoadPolicy(write("bad.json", text)); nil == err || !strings.Contains(err.Error(), message){
+			t.Errorf("LoadPolicy(%s) error = %v, want %q", text, err, message)
+		}
+	}
+}
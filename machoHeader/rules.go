@@ -0,0 +1,690 @@
+package machoHeader
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+//A small YARA-like rule language. Patterns can be restricted to a segment or a section and conditions can
+//mix pattern hits with structural checks on the image:
+//
+//	rule AntiDebug {
+//		strings:
+//			$ptrace = "PT_DENY_ATTACH" nocase in __TEXT,__cstring
+//			$svc = { 50 00 80 d2 ?? ?? ?? d4 } in __TEXT,__text
+//			$url = /https?:\/\/[a-z0-9.]+/ in __TEXT
+//		condition:
+//			($ptrace or $svc) and imports("_ptrace") and not flag("MH_NO_HEAP_EXECUTION")
+//	}
+//
+//Conditions support and, or, not, parentheses, $name, "any of them", "all of them", true, false and the
+//functions imports(symbol), links(dylib prefix), has_command(LC_ name), has_section(segment, section) and
+//flag(MH_ name). Text patterns accept the nocase and wide (UTF-16LE) modifiers. // starts a comment.
+type RuleSet struct{
+	Rules []Rule
+}
+
+type Rule struct{
+	Name string
+	Patterns []RulePattern
+	condition ruleExpr
+}
+
+type RulePattern struct{
+	ID string				//without the $
+	Kind string				//"text", "hex" or "regex"
+	Source string
+	NoCase bool
+	Wide bool
+	Segment string			//empty for the whole file
+	Section string			//empty for the whole segment
+	literal []byte
+	mask []bool				//hex patterns, false for ?? wildcards
+	regex *regexp.Regexp
+}
+
+type PatternMatch struct{
+	ID string
+	Offset uint64
+	VA uint64				//0 if the offset is not mapped
+	Data []byte
+}
+
+type RuleMatch struct{
+	Rule string
+	Matches []PatternMatch
+}
+
+//Stops runaway patterns such as /./ from producing one match per byte.
+const MAX_PATTERN_MATCHES = 1000
+
+type ruleExpr interface{
+	eval(ctx *matchContext)bool
+}
+
+type matchContext struct{
+	m FileHeader
+	current *Rule
+	hits map[string][]PatternMatch
+	imports map[string]bool		//read on the first imports() and shared by every rule
+}
+
+type exprAnd struct{ left, right ruleExpr }
+type exprOr struct{ left, right ruleExpr }
+type exprNot struct{ operand ruleExpr }
+type exprConst bool
+type exprPattern string
+type exprOf bool		//true for all of them, false for any of them
+type exprCall struct{
+	name string
+	args []string
+}
+
+type ruleToken struct{
+	kind string		//"ident", "pattern", "string", "hex", "regex", "punct"
+	text string
+	line int
+}
+
+type ruleParser struct{
+	tokens []ruleToken
+	pos int
+	current *Rule
+}
+
+var ruleFunctions = map[string]int{
+	"imports":		1,
+	"links":		1,
+	"has_command":	1,
+	"has_section":	2,
+	"flag":			1,
+}
+
+/*
+	//////////////////////////////////////// PUBLIC METHODS ////////////////////////////////////////
+*/
+
+func LoadRules(fileName string)(RuleSet, error){
+	source, err := os.ReadFile(fileName)
+	if nil != err{
+		return RuleSet{}, err
+	}
+	rules, err := ParseRules(string(source))
+	if nil != err{
+		return rules, fmt.Errorf("%s: %w", fileName, err)
+	}
+	return rules, nil
+}
+
+func ParseRules(source string)(RuleSet, error){
+	var rules RuleSet
+	tokens, err := tokenizeRules(source)
+	if nil != err{
+		return rules, err
+	}
+	p := ruleParser{tokens: tokens}
+	for !p.done(){
+		rule, err := p.rule()
+		if nil != err{
+			return rules, err
+		}
+		for _, other := range rules.Rules{
+			if other.Name == rule.Name{
+				return rules, fmt.Errorf("line %d: duplicate rule %s", p.line(), rule.Name)
+			}
+		}
+		rules.Rules = append(rules.Rules, rule)
+	}
+	if 0 == len(rules.Rules){
+		return rules, fmt.Errorf("no rules")
+	}
+	return rules, nil
+}
+
+//Returns the rules whose condition holds for the image, with every hit of their patterns.
+func (r RuleSet) Match(m FileHeader)[]RuleMatch{
+	var matches []RuleMatch
+	ctx := matchContext{m: m}
+	for i := range r.Rules{
+		ctx.current, ctx.hits = &r.Rules[i], map[string][]PatternMatch{}
+		if !r.Rules[i].condition.eval(&ctx){
+			continue
+		}
+		match := RuleMatch{Rule: r.Rules[i].Name}
+		for _, pattern := range r.Rules[i].Patterns{
+			match.Matches = append(match.Matches, ctx.find(pattern.ID)...)
+		}
+		matches = append(matches, match)
+	}
+	return matches
+}
+
+/*
+	//////////////////////////////////////// PUBLIC CLASS METHODS ////////////////////////////////////////
+*/
+
+//Returns every hit of the pattern inside its scope. A missing segment or section simply has no hits.
+func (p RulePattern) Find(m FileHeader)[]PatternMatch{
+	var data []byte
+	var base uint64
+	switch{
+	case "" == p.Segment:
+		data = m.data
+	case "" == p.Section:
+		segment := m.Segment(p.Segment)
+		if nil == segment{
+			return nil
+		}
+		data, _ = m.ReadBytes(segment.FileOffset, segment.FileSize)
+		base = segment.FileOffset
+	default:
+		section := m.Section(p.Segment, p.Section)
+		if nil == section{
+			return nil
+		}
+		data, _ = m.SectionData(*section)
+		base = uint64(section.Offset)
+	}
+
+	var spans [][]int
+	switch p.Kind{
+	case "regex":
+		spans = p.regex.FindAllIndex(data, MAX_PATTERN_MATCHES)
+	case "hex":
+		spans = findMasked(data, p.literal, p.mask)
+	default:
+		haystack, needle := data, p.literal
+		if p.NoCase{
+			haystack, needle = asciiLower(data), asciiLower(p.literal)
+		}
+		spans = findMasked(haystack, needle, nil)
+	}
+
+	var matches []PatternMatch
+	for _, span := range spans{
+		offset := base + uint64(span[0])
+		va, _ := m.OffsetToVA(offset)
+		matches = append(matches, PatternMatch{p.ID, offset, va, data[span[0]:span[1]]})
+	}
+	return matches
+}
+
+func (p RulePattern) String()string{
+	s := "$" + p.ID + " = " + p.Source
+	if p.NoCase{
+		s += " nocase"
+	}
+	if p.Wide{
+		s += " wide"
+	}
+	if "" != p.Segment{
+		s += " in " + p.Segment
+		if "" != p.Section{
+			s += "," + p.Section
+		}
+	}
+	return s
+}
+
+/*
+	//////////////////////////////////////// PRIVATE METHODS ////////////////////////////////////////
+*/
+
+//Finds non-overlapping occurrences of needle. A nil mask compares every byte.
+func findMasked(data []byte, needle []byte, mask []bool)[][]int{
+	var spans [][]int
+	if 0 == len(needle){
+		return nil
+	}
+	for i := 0; i + len(needle) <= len(data) && len(spans) < MAX_PATTERN_MATCHES; {
+		if nil == mask{
+			index := bytes.Index(data[i:], needle)
+			if -1 == index{
+				break
+			}
+			spans = append(spans, []int{i + index, i + index + len(needle)})
+			i += index + len(needle)
+			continue
+		}
+		matched := true
+		for j := range needle{
+			if mask[j] && data[i+j] != needle[j]{
+				matched = false
+				break
+			}
+		}
+		if matched{
+			spans = append(spans, []int{i, i + len(needle)})
+			i += len(needle)
+		} else {
+			i++
+		}
+	}
+	return spans
+}
+
+//bytes.ToLower would replace invalid UTF-8 and move every offset after it
+func asciiLower(data []byte)[]byte{
+	lower := make([]byte, len(data))
+	for i, b := range data{
+		if b >= 'A' && b <= 'Z'{
+			b += 'a' - 'A'
+		}
+		lower[i] = b
+	}
+	return lower
+}
+
+func widen(text []byte)[]byte{
+	wide := make([]byte, 0, 2 * len(text))
+	for _, b := range text{
+		wide = append(wide, b, 0)
+	}
+	return wide
+}
+
+//Parses "48 8b ?? 05" into bytes and a mask.
+func parseHexPattern(source string)([]byte, []bool, error){
+	digits := strings.Join(strings.Fields(source), "")
+	if 0 == len(digits) || 0 != len(digits) % 2{
+		return nil, nil, fmt.Errorf("hex pattern {%s} must have an even number of digits", source)
+	}
+	var literal []byte
+	var mask []bool
+	for i := 0; i < len(digits); i += 2{
+		if "??" == digits[i:i+2]{
+			literal = append(literal, 0)
+			mask = append(mask, false)
+			continue
+		}
+		value, err := strconv.ParseUint(digits[i:i+2], 16, 8)
+		if nil != err{
+			return nil, nil, fmt.Errorf("hex pattern {%s}: bad byte %s", source, digits[i:i+2])
+		}
+		literal = append(literal, byte(value))
+		mask = append(mask, true)
+	}
+	if !mask[0] || !mask[len(mask)-1]{
+		return nil, nil, fmt.Errorf("hex pattern {%s} cannot start or end with ??", source)
+	}
+	return literal, mask, nil
+}
+
+func tokenizeRules(source string)([]ruleToken, error){
+	var tokens []ruleToken
+	line := 1
+	for i := 0; i < len(source); {
+		c := source[i]
+		afterEquals := 0 != len(tokens) && "=" == tokens[len(tokens)-1].text
+		switch{
+		case '\n' == c:
+			line++
+			i++
+		case ' ' == c || '\t' == c || '\r' == c:
+			i++
+		case strings.HasPrefix(source[i:], "//") && !afterEquals:
+			for i < len(source) && '\n' != source[i]{
+				i++
+			}
+		case '"' == c:
+			end := i + 1
+			for end < len(source) && '"' != source[end]{
+				if '\\' == source[end]{
+					end++
+				}
+				end++
+			}
+			if end >= len(source){
+				return nil, fmt.Errorf("line %d: unterminated string", line)
+			}
+			text, err := strconv.Unquote(source[i:end+1])
+			if nil != err{
+				return nil, fmt.Errorf("line %d: bad string %s", line, source[i:end+1])
+			}
+			tokens = append(tokens, ruleToken{"string", text, line})
+			i = end + 1
+		case '{' == c && afterEquals:
+			end := strings.IndexByte(source[i:], '}')
+			if -1 == end{
+				return nil, fmt.Errorf("line %d: unterminated hex pattern", line)
+			}
+			tokens = append(tokens, ruleToken{"hex", source[i+1 : i+end], line})
+			i += end + 1
+		case '/' == c && afterEquals:
+			end := i + 1
+			for end < len(source) && '/' != source[end] && '\n' != source[end]{
+				if '\\' == source[end]{
+					end++
+				}
+				end++
+			}
+			if end >= len(source) || '/' != source[end]{
+				return nil, fmt.Errorf("line %d: unterminated regex", line)
+			}
+			//an escaped / only needs escaping for the rule syntax
+			tokens = append(tokens, ruleToken{"regex", strings.ReplaceAll(source[i+1:end], `\/`, "/"), line})
+			i = end + 1
+		case '$' == c || '_' == c || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c)):
+			end := i + 1
+			for end < len(source) && ('_' == source[end] || '.' == source[end] || unicode.IsLetter(rune(source[end])) || unicode.IsDigit(rune(source[end]))){
+				end++
+			}
+			kind := "ident"
+			if '$' == c{
+				kind = "pattern"
+			}
+			tokens = append(tokens, ruleToken{kind, source[i:end], line})
+			i = end
+		case strings.ContainsRune("{}():=,", rune(c)):
+			tokens = append(tokens, ruleToken{"punct", string(c), line})
+			i++
+		default:
+			return nil, fmt.Errorf("line %d: unexpected character %q", line, c)
+		}
+	}
+	return tokens, nil
+}
+
+/*
+	//////////////////////////////////////// PRIVATE CLASS METHODS ////////////////////////////////////////
+*/
+
+func (c *matchContext) find(id string)[]PatternMatch{
+	if hits, ok := c.hits[id]; ok{
+		return hits
+	}
+	for _, pattern := range c.current.Patterns{
+		if id == pattern.ID{
+			c.hits[id] = pattern.Find(c.m)
+		}
+	}
+	return c.hits[id]
+}
+
+func (e exprAnd) eval(ctx *matchContext)bool{ return e.left.eval(ctx) && e.right.eval(ctx) }
+func (e exprOr) eval(ctx *matchContext)bool{ return e.left.eval(ctx) || e.right.eval(ctx) }
+func (e exprNot) eval(ctx *matchContext)bool{ return !e.operand.eval(ctx) }
+func (e exprConst) eval(ctx *matchContext)bool{ return bool(e) }
+func (e exprPattern) eval(ctx *matchContext)bool{ return 0 != len(ctx.find(string(e))) }
+
+func (e exprOf) eval(ctx *matchContext)bool{
+	for _, pattern := range ctx.current.Patterns{
+		hit := 0 != len(ctx.find(pattern.ID))
+		if bool(e) != hit{
+			return !bool(e)
+		}
+	}
+	return bool(e)
+}
+
+func (e exprCall) eval(ctx *matchContext)bool{
+	m := ctx.m
+	switch e.name{
+	case "imports":
+		if nil == ctx.imports{
+			ctx.imports = m.ImportSet()
+		}
+		return ctx.imports[e.args[0]]
+	case "links":
+		for _, dylib := range m.Dylibs(){
+			if strings.HasPrefix(dylib.Name, e.args[0]){
+				return true
+			}
+		}
+	case "has_command":
+		for i := range m.LoadCommands{
+			if e.args[0] == CommandName(m.LoadCommands[i].Command){
+				return true
+			}
+		}
+	case "has_section":
+		return nil != m.Section(e.args[0], e.args[1])
+	case "flag":
+		return m.HasFlag(headerFlagNames[e.args[0]])
+	}
+	return false
+}
+
+func (p *ruleParser) done()bool{
+	return p.pos >= len(p.tokens)
+}
+
+func (p *ruleParser) line()int{
+	if p.done(){
+		if 0 == len(p.tokens){
+			return 1
+		}
+		return p.tokens[len(p.tokens)-1].line
+	}
+	return p.tokens[p.pos].line
+}
+
+func (p *ruleParser) peek()ruleToken{
+	if p.done(){
+		return ruleToken{}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *ruleParser) accept(text string)bool{
+	if !p.done() && "string" != p.tokens[p.pos].kind && text == p.tokens[p.pos].text{
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *ruleParser) expect(text string)error{
+	if !p.accept(text){
+		return fmt.Errorf("line %d: expected %s, found %q", p.line(), text, p.peek().text)
+	}
+	return nil
+}
+
+func (p *ruleParser) next(kind string)(string, error){
+	if p.done() || kind != p.tokens[p.pos].kind{
+		return "", fmt.Errorf("line %d: expected %s, found %q", p.line(), kind, p.peek().text)
+	}
+	p.pos++
+	return p.tokens[p.pos-1].text, nil
+}
+
+func (p *ruleParser) rule()(Rule, error){
+	var rule Rule
+	p.current = &rule
+	if err := p.expect("rule"); nil != err{
+		return rule, err
+	}
+	name, err := p.next("ident")
+	if nil != err{
+		return rule, err
+	}
+	rule.Name = name
+	if err = p.expect("{"); nil != err{
+		return rule, err
+	}
+
+	if p.accept("strings"){
+		if err = p.expect(":"); nil != err{
+			return rule, err
+		}
+		for "pattern" == p.peek().kind{
+			pattern, err := p.pattern()
+			if nil != err{
+				return rule, err
+			}
+			rule.Patterns = append(rule.Patterns, pattern)
+		}
+	}
+
+	if err = p.expect("condition"); nil != err{
+		return rule, err
+	}
+	if err = p.expect(":"); nil != err{
+		return rule, err
+	}
+	rule.condition, err = p.or()
+	if nil != err{
+		return rule, err
+	}
+	return rule, p.expect("}")
+}
+
+func (p *ruleParser) pattern()(RulePattern, error){
+	var pattern RulePattern
+	line := p.line()
+	id, _ := p.next("pattern")
+	pattern.ID = strings.TrimPrefix(id, "$")
+	if "" == pattern.ID{
+		return pattern, fmt.Errorf("line %d: pattern needs a name", line)
+	}
+	for _, other := range p.current.Patterns{
+		if other.ID == pattern.ID{
+			return pattern, fmt.Errorf("line %d: duplicate pattern $%s", line, pattern.ID)
+		}
+	}
+	if err := p.expect("="); nil != err{
+		return pattern, err
+	}
+
+	token := p.peek()
+	p.pos++
+	var err error
+	switch token.kind{
+	case "string":
+		pattern.Kind, pattern.Source, pattern.literal = "text", strconv.Quote(token.text), []byte(token.text)
+	case "hex":
+		pattern.Kind, pattern.Source = "hex", "{" + token.text + "}"
+		pattern.literal, pattern.mask, err = parseHexPattern(token.text)
+	case "regex":
+		pattern.Kind, pattern.Source = "regex", "/" + token.text + "/"
+		pattern.regex, err = regexp.Compile(token.text)
+	default:
+		return pattern, fmt.Errorf("line %d: $%s needs a string, {hex} or /regex/", line, pattern.ID)
+	}
+	if nil != err{
+		return pattern, fmt.Errorf("line %d: %w", line, err)
+	}
+
+	for{
+		if p.accept("nocase"){
+			pattern.NoCase = true
+		} else if p.accept("wide"){
+			pattern.Wide = true
+		} else {
+			break
+		}
+	}
+	if "text" != pattern.Kind && (pattern.NoCase || pattern.Wide){
+		return pattern, fmt.Errorf("line %d: nocase and wide only apply to text patterns", line)
+	}
+	if pattern.Wide{
+		pattern.literal = widen(pattern.literal)
+	}
+
+	if p.accept("in"){
+		if pattern.Segment, err = p.next("ident"); nil != err{
+			return pattern, err
+		}
+		if p.accept(","){
+			if pattern.Section, err = p.next("ident"); nil != err{
+				return pattern, err
+			}
+		}
+	}
+	return pattern, nil
+}
+
+func (p *ruleParser) or()(ruleExpr, error){
+	left, err := p.and()
+	for nil == err && p.accept("or"){
+		var right ruleExpr
+		right, err = p.and()
+		left = exprOr{left, right}
+	}
+	return left, err
+}
+
+func (p *ruleParser) and()(ruleExpr, error){
+	left, err := p.unary()
+	for nil == err && p.accept("and"){
+		var right ruleExpr
+		right, err = p.unary()
+		left = exprAnd{left, right}
+	}
+	return left, err
+}
+
+func (p *ruleParser) unary()(ruleExpr, error){
+	line := p.line()
+	token := p.peek()
+	switch{
+	case p.accept("not"):
+		operand, err := p.unary()
+		return exprNot{operand}, err
+	case p.accept("("):
+		inner, err := p.or()
+		if nil != err{
+			return nil, err
+		}
+		return inner, p.expect(")")
+	case p.accept("true"):
+		return exprConst(true), nil
+	case p.accept("false"):
+		return exprConst(false), nil
+	case "pattern" == token.kind:
+		p.pos++
+		id := strings.TrimPrefix(token.text, "$")
+		for _, pattern := range p.current.Patterns{
+			if id == pattern.ID{
+				return exprPattern(id), nil
+			}
+		}
+		return nil, fmt.Errorf("line %d: undefined pattern %s", line, token.text)
+	case p.accept("any"), p.accept("all"):
+		if err := p.expect("of"); nil != err{
+			return nil, err
+		}
+		if err := p.expect("them"); nil != err{
+			return nil, err
+		}
+		if 0 == len(p.current.Patterns){
+			return nil, fmt.Errorf("line %d: %s of them without any strings", line, token.text)
+		}
+		return exprOf("all" == token.text), nil
+	case "ident" == token.kind:
+		p.pos++
+		count, ok := ruleFunctions[token.text]
+		if !ok{
+			return nil, fmt.Errorf("line %d: unknown function or keyword %s", line, token.text)
+		}
+		call := exprCall{name: token.text}
+		if err := p.expect("("); nil != err{
+			return nil, err
+		}
+		for i := 0; i < count; i++{
+			if 0 != i{
+				if err := p.expect(","); nil != err{
+					return nil, err
+				}
+			}
+			arg, err := p.next("string")
+			if nil != err{
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+		}
+		if "flag" == call.name{
+			if _, ok := headerFlagNames[call.args[0]]; !ok{
+				return nil, fmt.Errorf("line %d: unknown header flag %s", line, call.args[0])
+			}
+		}
+		return call, p.expect(")")
+	}
+	return nil, fmt.Errorf("line %d: unexpected %q in condition", line, token.text)
+}
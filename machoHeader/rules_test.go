@@ -0,0 +1,129 @@
+package machoHeader
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseRules(t *testing.T){
+	rules, err := ParseRules(`
+		// a comment
+		rule Hello {
+			strings:
+				$text = "HELLO" nocase in __TEXT,__cstring
+				$wide = "hi" wide
+				$hex = { 55 48 ?? e5 } in __TEXT
+				$url = /https?:\/\/[a-z]+/
+			condition:
+				($text or $hex) and not $url
+		}
+		rule Always { condition: true }`)
+	if nil != err{
+		t.Fatal(err)
+	}
+	if 2 != len(rules.Rules) || "Hello" != rules.Rules[0].Name || "Always" != rules.Rules[1].Name{
+		t.Fatalf("parsed %+v", rules.Rules)
+	}
+
+	patterns := rules.Rules[0].Patterns
+	want := []string{
+		`$text = "HELLO" nocase in __TEXT,__cstring`,
+		`$wide = "hi" wide`,
+		`$hex = { 55 48 ?? e5 } in __TEXT`,
+		`$url = /https?://[a-z]+/`,
+	}
+	if len(want) != len(patterns){
+		t.Fatalf("%d patterns, want %d", len(patterns), len(want))
+	}
+	for i := range want{
+		if got := patterns[i].String(); want[i] != got{
+			t.Errorf("pattern %d = %s, want %s", i, got, want[i])
+		}
+	}
+	if "h\x00i\x00" != string(patterns[1].literal){
+		t.Errorf("wide literal = %q", patterns[1].literal)
+	}
+	if 4 != len(patterns[2].mask) || patterns[2].mask[2]{
+		t.Errorf("hex mask = %v", patterns[2].mask)
+	}
+}
+
+func TestParseRulesErrors(t *testing.T){
+	cases := map[string]string{
+		"":																	"no rules",
+		"rule A { condition: $x }":											"undefined pattern",
+		"rule A { strings: $a = \"x\" $a = \"y\" condition: $a }":			"duplicate pattern",
+		"rule A { condition: true } rule A { condition: false }":			"duplicate rule",
+		"rule A { strings: $a = { 4 } condition: $a }":						"even number",
+		"rule A { strings: $a = { ?? 41 } condition: $a }":					"cannot start or end",
+		"rule A { strings: $a = { 41 } nocase condition: $a }":				"only apply to text",
+		"rule A { strings: $a = /(/ condition: $a }":						"missing closing",
+		"rule A { strings: $a = \"x condition: $a }":						"unterminated string",
+		"rule A { condition: flag(\"MH_NOPE\") }":							"unknown header flag",
+		"rule A { condition: frobnicate(\"x\") }":							"unknown function",
+		"rule A { condition: has_section(\"__TEXT\") }":					"expected ,",
+		"rule A { condition: any of them }":								"without any strings",
+		"rule A { condition: true":											"expected }",
+	}
+	for source, want := range cases{
+		_, err := ParseRules(source)
+		if nil == err || !strings.Contains(err.Error(), want){
+			t.Errorf("ParseRules(%q) error = %v, want %q", source, err, want)
+		}
+	}
+}
+
+func TestRuleMatch(t *testing.T){
+	m := loadFixture(t)
+	rules, err := ParseRules(`
+		rule CString { strings: $a = "HELLO WORLD" nocase in __TEXT,__cstring condition: $a }
+		rule WrongSection { strings: $a = "hello world" in __DATA condition: $a }
+		rule Prologue { strings: $a = { 55 48 ?? e5 } in __TEXT,__text condition: $a }
+		rule Regex { strings: $a = /hel+o w[a-z]+/ condition: $a }
+		rule Imports { condition: imports("_printf") and not imports("_ptrace") }
+		rule Links { condition: links("/usr/lib/libSystem") }
+		rule Structure { condition: has_command("LC_MAIN") and has_section("__TEXT", "__text") and flag("MH_PIE") }
+		rule NoHeap { condition: flag("MH_NO_HEAP_EXECUTION") }
+		rule AllOf { strings: $a = "hello" $b = "missing" condition: all of them }
+		rule AnyOf { strings: $a = "hello" $b = "missing" condition: any of them }
+		rule Wide { strings: $a = "hello" wide condition: $a }`)
+	if nil != err{
+		t.Fatal(err)
+	}
+
+	matched := map[string]RuleMatch{}
+	for _, match := range rules.Match(m){
+		matched[match.Rule] = match
+	}
+	for _, name := range []string{"CString", "Prologue", "Regex", "Imports", "Links", "Structure", "AnyOf"}{
+		if _, ok := matched[name]; !ok{
+			t.Errorf("rule %s did not match", name)
+		}
+	}
+	for _, name := range []string{"WrongSection", "NoHeap", "AllOf", "Wide"}{
+		if _, ok := matched[name]; ok{
+			t.Errorf("rule %s matched", name)
+		}
+	}
+
+	hits := matched["CString"].Matches
+	if 1 != len(hits) || "hello world" != string(hits[0].Data) || 0x100000fa2 != hits[0].VA{
+		t.Errorf("CString hits = %+v", hits)
+	}
+	if hits := matched["Prologue"].Matches; 0 == len(hits) || 0x100000f50 != hits[0].VA{
+		t.Errorf("Prologue hits = %+v", hits)
+	}
+}
+
+func TestFindMasked(t *testing.T){
+	data := []byte("abcabcab")
+	if spans := findMasked(data, []byte("abc"), nil); 2 != len(spans) || 3 != spans[1][0]{
+		t.Errorf("literal spans = %v", spans)
+	}
+	if spans := findMasked(data, []byte("aXc"), []bool{true, false, true}); 2 != len(spans){
+		t.Errorf("masked spans = %v", spans)
+	}
+	if spans := findMasked(data, nil, nil); nil != spans{
+		t.Errorf("empty needle spans = %v", spans)
+	}
+}
@@ -0,0 +1,173 @@
+package machoHeader
+
+import (
+	"fmt"
+	"strings"
+)
+
+//The Flags field of a section is split into a type (low byte) and attributes (high 3 bytes).
+//taken from Library/Developer/CommandLineTools/SDKs/MacOSX10.15.sdk/usr/include/mach-o/loader.h
+const (
+	SECTION_TYPE				= 0x000000ff	/* 256 section types */
+	SECTION_ATTRIBUTES			= 0xffffff00	/*  24 section attributes */
+	SECTION_ATTRIBUTES_USR		= 0xff000000	/* User setable attributes */
+	SECTION_ATTRIBUTES_SYS		= 0x00ffff00	/* system setable attributes */
+)
+
+type SectionType uint32
+
+const (
+	S_REGULAR								SectionType = 0x0	/* regular section */
+	S_ZEROFILL								SectionType = 0x1	/* zero fill on demand section */
+	S_CSTRING_LITERALS						SectionType = 0x2	/* section with only literal C strings*/
+	S_4BYTE_LITERALS						SectionType = 0x3	/* section with only 4 byte literals */
+	S_8BYTE_LITERALS						SectionType = 0x4	/* section with only 8 byte literals */
+	S_LITERAL_POINTERS						SectionType = 0x5	/* section with only pointers to literals */
+	S_NON_LAZY_SYMBOL_POINTERS				SectionType = 0x6	/* section with only non-lazy symbol pointers */
+	S_LAZY_SYMBOL_POINTERS					SectionType = 0x7	/* section with only lazy symbol pointers */
+	S_SYMBOL_STUBS							SectionType = 0x8	/* section with only symbol stubs, byte size of stub in the reserved2 field */
+	S_MOD_INIT_FUNC_POINTERS				SectionType = 0x9	/* section with only function pointers for initialization*/
+	S_MOD_TERM_FUNC_POINTERS				SectionType = 0xa	/* section with only function pointers for termination */
+	S_COALESCED								SectionType = 0xb	/* section contains symbols that are to be coalesced */
+	S_GB_ZEROFILL							SectionType = 0xc	/* zero fill on demand section (that can be larger than 4 gigabytes) */
+	S_INTERPOSING							SectionType = 0xd	/* section with only pairs of function pointers for interposing */
+	S_16BYTE_LITERALS						SectionType = 0xe	/* section with only 16 byte literals */
+	S_DTRACE_DOF							SectionType = 0xf	/* section contains DTrace Object Format */
+	S_LAZY_DYLIB_SYMBOL_POINTERS			SectionType = 0x10	/* section with only lazy symbol pointers to lazy loaded dylibs */
+	S_THREAD_LOCAL_REGULAR					SectionType = 0x11	/* template of initial values for TLVs */
+	S_THREAD_LOCAL_ZEROFILL					SectionType = 0x12	/* template of initial values for TLVs */
+	S_THREAD_LOCAL_VARIABLES				SectionType = 0x13	/* TLV descriptors */
+	S_THREAD_LOCAL_VARIABLE_POINTERS		SectionType = 0x14	/* pointers to TLV descriptors */
+	S_THREAD_LOCAL_INIT_FUNCTION_POINTERS	SectionType = 0x15	/* functions to call to initialize TLV values */
+	S_INIT_FUNC_OFFSETS						SectionType = 0x16	/* 32-bit offsets to initializers */
+)
+
+type SectionAttributes uint32
+
+const (
+	S_ATTR_PURE_INSTRUCTIONS	SectionAttributes = 0x80000000	/* section contains only true machine instructions */
+	S_ATTR_NO_TOC				SectionAttributes = 0x40000000	/* section contains coalesced symbols that are not to be in a ranlib table of contents */
+	S_ATTR_STRIP_STATIC_SYMS	SectionAttributes = 0x20000000	/* ok to strip static symbols in this section in files with the MH_DYLDLINK flag */
+	S_ATTR_NO_DEAD_STRIP		SectionAttributes = 0x10000000	/* no dead stripping */
+	S_ATTR_LIVE_SUPPORT			SectionAttributes = 0x08000000	/* blocks are live if they reference live blocks */
+	S_ATTR_SELF_MODIFYING_CODE	SectionAttributes = 0x04000000	/* Used with i386 code stubs written on by dyld */
+	S_ATTR_DEBUG				SectionAttributes = 0x02000000	/* a debug section */
+	S_ATTR_SOME_INSTRUCTIONS	SectionAttributes = 0x00000400	/* section contains some machine instructions */
+	S_ATTR_EXT_RELOC			SectionAttributes = 0x00000200	/* section has external relocation entries */
+	S_ATTR_LOC_RELOC			SectionAttributes = 0x00000100	/* section has local relocation entries */
+)
+
+var sectionTypeNames = map[SectionType]string{
+	S_REGULAR:								"S_REGULAR",
+	S_ZEROFILL:								"S_ZEROFILL",
+	S_CSTRING_LITERALS:						"S_CSTRING_LITERALS",
+	S_4BYTE_LITERALS:						"S_4BYTE_LITERALS",
+	S_8BYTE_LITERALS:						"S_8BYTE_LITERALS",
+	S_LITERAL_POINTERS:						"S_LITERAL_POINTERS",
+	S_NON_LAZY_SYMBOL_POINTERS:				"S_NON_LAZY_SYMBOL_POINTERS",
+	S_LAZY_SYMBOL_POINTERS:					"S_LAZY_SYMBOL_POINTERS",
+	S_SYMBOL_STUBS:							"S_SYMBOL_STUBS",
+	S_MOD_INIT_FUNC_POINTERS:				"S_MOD_INIT_FUNC_POINTERS",
+	S_MOD_TERM_FUNC_POINTERS:				"S_MOD_TERM_FUNC_POINTERS",
+	S_COALESCED:							"S_COALESCED",
+	S_GB_ZEROFILL:							"S_GB_ZEROFILL",
+	S_INTERPOSING:							"S_INTERPOSING",
+	S_16BYTE_LITERALS:						"S_16BYTE_LITERALS",
+	S_DTRACE_DOF:							"S_DTRACE_DOF",
+	S_LAZY_DYLIB_SYMBOL_POINTERS:			"S_LAZY_DYLIB_SYMBOL_POINTERS",
+	S_THREAD_LOCAL_REGULAR:					"S_THREAD_LOCAL_REGULAR",
+	S_THREAD_LOCAL_ZEROFILL:				"S_THREAD_LOCAL_ZEROFILL",
+	S_THREAD_LOCAL_VARIABLES:				"S_THREAD_LOCAL_VARIABLES",
+	S_THREAD_LOCAL_VARIABLE_POINTERS:		"S_THREAD_LOCAL_VARIABLE_POINTERS",
+	S_THREAD_LOCAL_INIT_FUNCTION_POINTERS:	"S_THREAD_LOCAL_INIT_FUNCTION_POINTERS",
+	S_INIT_FUNC_OFFSETS:					"S_INIT_FUNC_OFFSETS",
+}
+
+//Kept as a slice so attributes are always listed from the highest bit down.
+var sectionAttributeNames = []struct{
+	attribute SectionAttributes
+	name string
+}{
+	{S_ATTR_PURE_INSTRUCTIONS,		"S_ATTR_PURE_INSTRUCTIONS"},
+	{S_ATTR_NO_TOC,					"S_ATTR_NO_TOC"},
+	{S_ATTR_STRIP_STATIC_SYMS,		"S_ATTR_STRIP_STATIC_SYMS"},
+	{S_ATTR_NO_DEAD_STRIP,			"S_ATTR_NO_DEAD_STRIP"},
+	{S_ATTR_LIVE_SUPPORT,			"S_ATTR_LIVE_SUPPORT"},
+	{S_ATTR_SELF_MODIFYING_CODE,	"S_ATTR_SELF_MODIFYING_CODE"},
+	{S_ATTR_DEBUG,					"S_ATTR_DEBUG"},
+	{S_ATTR_SOME_INSTRUCTIONS,		"S_ATTR_SOME_INSTRUCTIONS"},
+	{S_ATTR_EXT_RELOC,				"S_ATTR_EXT_RELOC"},
+	{S_ATTR_LOC_RELOC,				"S_ATTR_LOC_RELOC"},
+}
+
+/*
+	//////////////////////////////////////// PUBLIC METHODS ////////////////////////////////////////
+*/
+
+func (t SectionType) String()string{
+	if name, ok := sectionTypeNames[t]; ok{
+		return name
+	}
+	return fmt.Sprintf("UNKNOWN_SECTION_TYPE(0x%x)", uint32(t))
+}
+
+//Returns the names of every attribute bit set, e.g. "S_ATTR_PURE_INSTRUCTIONS|S_ATTR_SOME_INSTRUCTIONS".
+//Bits without a name are printed in hex at the end.
+func (a SectionAttributes) String()string{
+	var names []string
+	remaining := a
+	for _, entry := range sectionAttributeNames{
+		if entry.attribute == entry.attribute & a{
+			names = append(names, entry.name)
+			remaining &^= entry.attribute
+		}
+	}
+	if 0 != remaining{
+		names = append(names, fmt.Sprintf("0x%x", uint32(remaining)))
+	}
+	if 0 == len(names){
+		return "none"
+	}
+	return strings.Join(names, "|")
+}
+
+//Returns true if every bit in attribute is set.
+func (a SectionAttributes) Has(attribute SectionAttributes)bool{
+	return attribute == attribute & a
+}
+
+/*
+	//////////////////////////////////////// PUBLIC CLASS METHODS ////////////////////////////////////////
+*/
+
+func (s SectionHeader) Type()SectionType{
+	return SectionType(s.Flags & SECTION_TYPE)
+}
+
+func (s SectionHeader) Attributes()SectionAttributes{
+	return SectionAttributes(s.Flags & SECTION_ATTRIBUTES)
+}
+
+/*
+	//////////////////////////////////////// PRIVATE METHODS ////////////////////////////////////////
+*/
+
+//Prints the section type followed by each attribute bit, in the same layout translateFlags uses for the header.
+func translateSectionFlags(arg uint32){
+	sectionType := SectionType(arg & SECTION_TYPE)
+	fmt.Printf("\t\t0x%x %s\n", uint32(sectionType), sectionType)
+	for _, entry := range sectionAttributeNames{
+		if entry.attribute == entry.attribute & SectionAttributes(arg){
+			fmt.Printf("\t\t0x%x %s\n", uint32(entry.attribute), entry.name)
+		}
+	}
+}
+
+/*
+	//////////////////////////////////////// PRIVATE CLASS METHODS ////////////////////////////////////////
+*/
+
+func (s SectionHeader) isZeroFill()bool{
+	sectionType := s.Type()
+	return S_ZEROFILL == sectionType || S_GB_ZEROFILL == sectionType || S_THREAD_LOCAL_ZEROFILL == sectionType
+}
@@ -0,0 +1,125 @@
+package machoHeader
+
+import (
+	"fmt"
+	"strings"
+)
+
+//taken from Library/Developer/CommandLineTools/SDKs/MacOSX10.15.sdk/usr/include/mach/vm_prot.h
+type VMProtection uint32
+
+const (
+	VM_PROT_NONE		VMProtection = 0x0
+	VM_PROT_READ		VMProtection = 0x1	/* read permission */
+	VM_PROT_WRITE		VMProtection = 0x2	/* write permission */
+	VM_PROT_EXECUTE		VMProtection = 0x4	/* execute permission */
+)
+
+//Constants for the flags field of the segment_command
+//taken from Library/Developer/CommandLineTools/SDKs/MacOSX10.15.sdk/usr/include/mach-o/loader.h
+type SegmentFlags uint32
+
+const (
+	SG_HIGHVM				SegmentFlags = 0x1	/* the file contents for this segment is for the high part of the VM space, the low part is zero filled (for stacks in core files) */
+	SG_FVMLIB				SegmentFlags = 0x2	/* this segment is the VM that is allocated by a fixed VM library, for overlap checking in the link editor */
+	SG_NORELOC				SegmentFlags = 0x4	/* this segment has nothing that was relocated in it and nothing relocated to it */
+	SG_PROTECTED_VERSION_1	SegmentFlags = 0x8	/* This segment is protected.  If the segment starts at file offset 0, the first page of the segment is not protected. */
+	SG_READ_ONLY			SegmentFlags = 0x10	/* This segment is made read-only after fixups */
+)
+
+var segmentFlagNames = []struct{
+	flag SegmentFlags
+	name string
+}{
+	{SG_HIGHVM,					"SG_HIGHVM"},
+	{SG_FVMLIB,					"SG_FVMLIB"},
+	{SG_NORELOC,				"SG_NORELOC"},
+	{SG_PROTECTED_VERSION_1,	"SG_PROTECTED_VERSION_1"},
+	{SG_READ_ONLY,				"SG_READ_ONLY"},
+}
+
+/*
+	//////////////////////////////////////// PUBLIC METHODS ////////////////////////////////////////
+*/
+
+//Renders the protection the same way vmmap does, e.g. "r-x".
+func (p VMProtection) String()string{
+	perms := []byte("---")
+	if p.Readable(){
+		perms[0] = 'r'
+	}
+	if p.Writable(){
+		perms[1] = 'w'
+	}
+	if p.Executable(){
+		perms[2] = 'x'
+	}
+	return string(perms)
+}
+
+func (p VMProtection) Readable()bool{
+	return VM_PROT_READ == p & VM_PROT_READ
+}
+
+func (p VMProtection) Writable()bool{
+	return VM_PROT_WRITE == p & VM_PROT_WRITE
+}
+
+func (p VMProtection) Executable()bool{
+	return VM_PROT_EXECUTE == p & VM_PROT_EXECUTE
+}
+
+func (f SegmentFlags) String()string{
+	var names []string
+	remaining := f
+	for _, entry := range segmentFlagNames{
+		if entry.flag == entry.flag & f{
+			names = append(names, entry.name)
+			remaining &^= entry.flag
+		}
+	}
+	if 0 != remaining{
+		names = append(names, fmt.Sprintf("0x%x", uint32(remaining)))
+	}
+	if 0 == len(names){
+		return "none"
+	}
+	return strings.Join(names, "|")
+}
+
+func (f SegmentFlags) Has(flag SegmentFlags)bool{
+	return flag == flag & f
+}
+
+/*
+	//////////////////////////////////////// PUBLIC CLASS METHODS ////////////////////////////////////////
+*/
+
+func (l LoadCommand) MaxProtection()VMProtection{
+	return VMProtection(l.MaxVMProtectionFlag)
+}
+
+func (l LoadCommand) InitProtection()VMProtection{
+	return VMProtection(l.InitVMProtectionFlag)
+}
+
+func (l LoadCommand) SegmentFlags()SegmentFlags{
+	return SegmentFlags(l.Flags)
+}
+
+//True when the segment is mapped both writable and executable from the start.
+func (l LoadCommand) IsWritableExecutable()bool{
+	return l.InitProtection().Writable() && l.InitProtection().Executable()
+}
+
+/*
+	//////////////////////////////////////// PRIVATE METHODS ////////////////////////////////////////
+*/
+
+func translateSegmentFlags(arg uint32, indent int){
+	for _, entry := range segmentFlagNames{
+		if entry.flag == entry.flag & SegmentFlags(arg){
+			fmt.Printf("%s\t0x%x %s\n", strings.Repeat("-",indent), uint32(entry.flag), entry.name)
+		}
+	}
+}
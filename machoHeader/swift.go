@@ -0,0 +1,373 @@
+package machoHeader
+
+import (
+	"cycle1/demangle"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+//Context descriptor kinds, from swift/ABI/MetadataValues.h
+const (
+	SWIFT_CONTEXT_MODULE		= 0
+	SWIFT_CONTEXT_EXTENSION		= 1
+	SWIFT_CONTEXT_ANONYMOUS		= 2
+	SWIFT_CONTEXT_PROTOCOL		= 3
+	SWIFT_CONTEXT_OPAQUE_TYPE	= 4
+	SWIFT_CONTEXT_CLASS			= 16
+	SWIFT_CONTEXT_STRUCT		= 17
+	SWIFT_CONTEXT_ENUM			= 18
+
+	SWIFT_CONTEXT_KIND_MASK		= 0x1f
+	SWIFT_CONTEXT_IS_GENERIC	= 0x80
+
+	//field record flags
+	SWIFT_FIELD_IS_INDIRECT_CASE	= 0x1
+	SWIFT_FIELD_IS_VAR				= 0x2
+
+	//TypeReferenceKind in type records and conformance flags
+	SWIFT_TYPE_REF_DIRECT				= 0
+	SWIFT_TYPE_REF_INDIRECT				= 1
+	SWIFT_TYPE_REF_DIRECT_OBJC_NAME		= 2
+	SWIFT_TYPE_REF_INDIRECT_OBJC_CLASS	= 3
+)
+
+var swiftContextKinds = map[uint32]string{
+	SWIFT_CONTEXT_MODULE:		"module",
+	SWIFT_CONTEXT_EXTENSION:	"extension",
+	SWIFT_CONTEXT_ANONYMOUS:	"anonymous",
+	SWIFT_CONTEXT_PROTOCOL:		"protocol",
+	SWIFT_CONTEXT_OPAQUE_TYPE:	"opaque type",
+	SWIFT_CONTEXT_CLASS:		"class",
+	SWIFT_CONTEXT_STRUCT:		"struct",
+	SWIFT_CONTEXT_ENUM:			"enum",
+}
+
+type SwiftField struct{
+	Name string			`json:"name"`
+	Type string			`json:"type,omitempty"`		//empty for enum cases without a payload
+	Var bool			`json:"var,omitempty"`
+	Indirect bool		`json:"indirect,omitempty"`
+}
+
+type SwiftType struct{
+	Address uint64				`json:"address"`
+	Kind string					`json:"kind"`
+	Name string					`json:"name"`			//qualified with the module and enclosing types
+	Generic bool				`json:"generic,omitempty"`
+	SuperClass string			`json:"superclass,omitempty"`
+	Fields []SwiftField			`json:"fields,omitempty"`
+	Conformances []string		`json:"conformances,omitempty"`
+}
+
+type SwiftProtocol struct{
+	Address uint64				`json:"address"`
+	Name string					`json:"name"`
+	Requirements uint32			`json:"requirements"`
+	AssociatedTypes []string	`json:"associated_types,omitempty"`
+}
+
+type SwiftConformance struct{
+	Address uint64			`json:"address"`
+	Type string				`json:"type"`
+	Protocol string			`json:"protocol"`
+}
+
+//The concrete types a conformance binds to a protocol's associated types, from __swift5_assocty.
+type SwiftAssociatedTypes struct{
+	Type string					`json:"type"`
+	Protocol string				`json:"protocol"`
+	Bindings []SwiftField		`json:"bindings"`			//Name is the associated type, Type what it is bound to
+}
+
+type SwiftMetadata struct{
+	Types []SwiftType						`json:"types,omitempty"`
+	Protocols []SwiftProtocol				`json:"protocols,omitempty"`
+	Conformances []SwiftConformance			`json:"conformances,omitempty"`
+	AssociatedTypes []SwiftAssociatedTypes	`json:"associated_types,omitempty"`
+}
+
+/*
+	//////////////////////////////////////// PUBLIC CLASS METHODS ////////////////////////////////////////
+*/
+
+//Parses the Swift 5 reflection sections: nominal types from __swift5_types (with their fields from
+//__swift5_fieldmd, whose names live in __swift5_reflstr), protocols from __swift5_protos, conformances from
+//__swift5_proto and associated type bindings from __swift5_assocty. Type names inside fields are mangled;
+//references to types in this image are resolved to their names and standard library types are spelled out.
+func (m FileHeader) Swift()(SwiftMetadata, error){
+	var metadata SwiftMetadata
+	r := newMetadataReader(m)
+
+	byDescriptor := map[uint64]int{}
+	for _, descriptor := range r.relativeList("__swift5_types", true){
+		byDescriptor[descriptor] = len(metadata.Types)
+		metadata.Types = append(metadata.Types, r.swiftType(descriptor))
+	}
+	for _, descriptor := range r.relativeList("__swift5_protos", true){
+		metadata.Protocols = append(metadata.Protocols, r.swiftProtocol(descriptor))
+	}
+	for _, record := range r.relativeList("__swift5_proto", false){
+		conformance, descriptor := r.swiftConformance(record)
+		metadata.Conformances = append(metadata.Conformances, conformance)
+		if index, ok := byDescriptor[descriptor]; ok{
+			metadata.Types[index].Conformances = append(metadata.Types[index].Conformances, conformance.Protocol)
+		}
+	}
+	if section := m.swiftSection("__swift5_assocty"); nil != section{
+		for address := section.Address; address + 16 <= section.Address + section.Size; {
+			associated, size := r.swiftAssociatedTypes(address)
+			metadata.AssociatedTypes = append(metadata.AssociatedTypes, associated)
+			if 0 == size{
+				//the next descriptor cannot be found, rather than misreading the rest of the section
+				break
+			}
+			address += size
+		}
+	}
+	return metadata, r.err
+}
+
+/*
+	//////////////////////////////////////// PRIVATE CLASS METHODS ////////////////////////////////////////
+*/
+
+func (m FileHeader) swiftSection(name string)*SectionHeader{
+	for _, segment := range []string{"__TEXT", "__DATA_CONST", "__DATA"}{
+		if section := m.Section(segment, name); nil != section{
+			return section
+		}
+	}
+	return nil
+}
+
+//Follows a 32 bit relative pointer, returning 0 for a null offset.
+func (r *metadataReader) relative(field uint64)uint64{
+	offset := int32(r.u32(field))
+	if 0 == offset{
+		return 0
+	}
+	return uint64(int64(field) + int64(offset))
+}
+
+//Follows a relative pointer whose low bit says the target is a pointer to the real target. An indirect
+//pointer to another image comes back as 0 and the bound symbol.
+func (r *metadataReader) relativeIndirect(field uint64)(uint64, string){
+	offset := int32(r.u32(field))
+	if 0 == offset{
+		return 0, ""
+	}
+	target := uint64(int64(field) + int64(offset &^ 1))
+	if 0 != offset & 1{
+		return r.pointer(target)
+	}
+	return target, ""
+}
+
+//Reads a section of 32 bit relative pointers. Type records keep a TypeReferenceKind in the low bits.
+func (r *metadataReader) relativeList(name string, typeRecords bool)[]uint64{
+	section := r.m.swiftSection(name)
+	if nil == section{
+		return nil
+	}
+	var targets []uint64
+	for field := section.Address; field + 4 <= section.Address + section.Size; field += 4{
+		offset := int32(r.u32(field))
+		if !typeRecords{
+			targets = append(targets, uint64(int64(field) + int64(offset)))
+			continue
+		}
+		target := uint64(int64(field) + int64(offset &^ 3))
+		if SWIFT_TYPE_REF_INDIRECT == offset & 3{
+			target, _ = r.pointer(target)
+		}
+		if 0 != target{
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+//Builds Module.Outer.Name by walking the parent chain. An extension stands for the type it extends, whose
+//mangled name already holds the module and enclosing types, and anonymous contexts are skipped over.
+func (r *metadataReader) contextName(descriptor uint64)string{
+	//extended type names refer back to descriptors, which looping metadata could do forever
+	r.nesting++
+	defer func(){ r.nesting-- }()
+	if r.nesting > 8{
+		r.fail(fmt.Errorf("context descriptor 0x%x: names nest too deeply", descriptor))
+		return ""
+	}
+
+	var names []string
+	for depth := 0; 0 != descriptor && depth < 32; depth++{
+		flags := r.u32(descriptor)
+		switch flags & SWIFT_CONTEXT_KIND_MASK{
+		case SWIFT_CONTEXT_EXTENSION:
+			if extended := r.mangledName(r.relative(descriptor + 8)); "" != extended{
+				return strings.Join(append([]string{extended}, names...), ".")
+			}
+		case SWIFT_CONTEXT_ANONYMOUS:
+		default:
+			names = append([]string{r.stringAt(r.relative(descriptor + 8))}, names...)
+		}
+		parent, symbol := r.relativeIndirect(descriptor + 4)
+		if 0 == parent && "" != symbol{
+			names = append([]string{symbol}, names...)
+		}
+		descriptor = parent
+	}
+	return strings.Join(names, ".")
+}
+
+func (r *metadataReader) swiftType(descriptor uint64)SwiftType{
+	flags := r.u32(descriptor)
+	kind := flags & SWIFT_CONTEXT_KIND_MASK
+	swiftType := SwiftType{Address: descriptor, Kind: swiftContextKinds[kind], Name: r.contextName(descriptor), Generic: 0 != flags & SWIFT_CONTEXT_IS_GENERIC}
+	if "" == swiftType.Kind{
+		swiftType.Kind = fmt.Sprintf("kind %d", kind)
+	}
+	if SWIFT_CONTEXT_CLASS == kind{
+		if superclass := r.relative(descriptor + 20); 0 != superclass{
+			swiftType.SuperClass = r.mangledName(superclass)
+		}
+	}
+	if fields := r.relative(descriptor + 16); 0 != fields{
+		swiftType.Fields = r.swiftFields(fields)
+	}
+	return swiftType
+}
+
+//Reads a field descriptor from __swift5_fieldmd.
+func (r *metadataReader) swiftFields(descriptor uint64)[]SwiftField{
+	recordSize := uint64(binary.LittleEndian.Uint16(r.bytes(descriptor + 10, 2)))
+	count := uint64(r.u32(descriptor + 12))
+	if 0 == count || !r.sane(descriptor, recordSize, count){
+		return nil
+	}
+	var fields []SwiftField
+	for i := uint64(0); i < count; i++{
+		record := descriptor + 16 + i * recordSize
+		flags := r.u32(record)
+		field := SwiftField{Name: r.stringAt(r.relative(record + 8)), Var: 0 != flags & SWIFT_FIELD_IS_VAR, Indirect: 0 != flags & SWIFT_FIELD_IS_INDIRECT_CASE}
+		if typeName := r.relative(record + 4); 0 != typeName{
+			field.Type = r.mangledName(typeName)
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func (r *metadataReader) swiftProtocol(descriptor uint64)SwiftProtocol{
+	protocol := SwiftProtocol{Address: descriptor, Name: r.contextName(descriptor), Requirements: r.u32(descriptor + 16)}
+	if names := r.relative(descriptor + 20); 0 != names{
+		protocol.AssociatedTypes = strings.Fields(r.stringAt(names))
+	}
+	return protocol
+}
+
+//Reads a protocol conformance descriptor and also returns the type descriptor it is for, when that is local.
+func (r *metadataReader) swiftConformance(record uint64)(SwiftConformance, uint64){
+	conformance := SwiftConformance{Address: record}
+	protocol, symbol := r.relativeIndirect(record)
+	if 0 != protocol{
+		conformance.Protocol = r.contextName(protocol)
+	} else {
+		conformance.Protocol = symbol
+	}
+
+	var descriptor uint64
+	flags := r.u32(record + 12)
+	typeRef := record + 4
+	switch (flags >> 3) & 7{
+	case SWIFT_TYPE_REF_DIRECT:
+		descriptor = r.relative(typeRef)
+		conformance.Type = r.contextName(descriptor)
+	case SWIFT_TYPE_REF_INDIRECT:
+		descriptor, symbol = r.pointer(r.relative(typeRef))
+		if 0 != descriptor{
+			conformance.Type = r.contextName(descriptor)
+		} else {
+			conformance.Type = symbol
+		}
+	case SWIFT_TYPE_REF_DIRECT_OBJC_NAME:
+		conformance.Type = r.stringAt(r.relative(typeRef))
+	case SWIFT_TYPE_REF_INDIRECT_OBJC_CLASS:
+		_, symbol = r.pointer(r.relative(typeRef))
+		conformance.Type = strings.TrimPrefix(symbol, "_OBJC_CLASS_$_")
+	}
+	return conformance, descriptor
+}
+
+//Reads one associated type descriptor and returns it with its size, which is 0 when the record size or
+//count is not believable.
+func (r *metadataReader) swiftAssociatedTypes(descriptor uint64)(SwiftAssociatedTypes, uint64){
+	associated := SwiftAssociatedTypes{Type: r.mangledName(r.relative(descriptor)), Protocol: r.mangledName(r.relative(descriptor + 4))}
+	count := uint64(r.u32(descriptor + 8))
+	recordSize := uint64(r.u32(descriptor + 12))
+	if 0 == count{
+		return associated, 16
+	}
+	if !r.sane(descriptor, recordSize, count){
+		return associated, 0
+	}
+	for i := uint64(0); i < count; i++{
+		record := descriptor + 16 + i * recordSize
+		associated.Bindings = append(associated.Bindings, SwiftField{Name: r.stringAt(r.relative(record)), Type: r.mangledName(r.relative(record + 4))})
+	}
+	return associated, 16 + count * recordSize
+}
+
+//Reads a mangled type name. Bytes 0x01-0x17 start a symbolic reference followed by a 32 bit relative offset
+//to a context descriptor (0x02 through a pointer), and 0x18-0x1f an absolute pointer. The name is returned
+//demangled with references replaced by the name they point to. If it cannot be demangled it stays mangled,
+//with the references written in angle brackets.
+func (r *metadataReader) mangledName(address uint64)string{
+	if 0 == address{
+		return ""
+	}
+	var mangled, fallback strings.Builder
+	references := make(map[int]string)
+	for position := address; position - address < 4096; {
+		c := r.bytes(position, 1)[0]
+		switch{
+		case 0 == c:
+			demangled, err := demangle.SwiftType(mangled.String(), func(offset int)string{
+				return references[offset]
+			})
+			if nil != err{
+				return fallback.String()
+			}
+			return demangled
+		case c <= 0x17:
+			var descriptor uint64
+			var symbol string
+			if 0x02 == c{
+				descriptor, symbol = r.pointer(r.relative(position + 1))
+			} else {
+				descriptor = r.relative(position + 1)
+			}
+			if 0 != descriptor{
+				symbol = r.contextName(descriptor)
+			}
+			references[mangled.Len()] = symbol
+			mangled.Write(r.bytes(position, 5))
+			fallback.WriteString("<" + symbol + ">")
+			position += 5
+		case c <= 0x1f:
+			target, symbol := r.pointer(position + 1)
+			if 0 != target{
+				symbol = fmt.Sprintf("0x%x", target)
+			}
+			references[mangled.Len()] = symbol
+			mangled.Write(r.bytes(position, 9))
+			fallback.WriteString("<" + symbol + ">")
+			position += 9
+		default:
+			mangled.WriteByte(c)
+			fallback.WriteByte(c)
+			position++
+		}
+	}
+	return fallback.String()
+}
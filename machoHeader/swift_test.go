@@ -0,0 +1,255 @@
+package machoHeader
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+//Lays out Swift reflection metadata for buildImage. Everything lives in __TEXT from SWIFT_BASE, descriptors
+//and lists first and strings from SWIFT_BASE+0x1000, and the sections are views of the same bytes.
+const SWIFT_BASE = IMAGE_TEXT + 0x1000
+
+type swiftLayout struct{
+	data []byte
+	next uint64		//where the next string goes
+}
+
+func newSwiftLayout()*swiftLayout{
+	return &swiftLayout{data: make([]byte, 0x2000), next: SWIFT_BASE + 0x1000}
+}
+
+func (l *swiftLayout) u32(address uint64, value uint32){
+	binary.LittleEndian.PutUint32(l.data[address - SWIFT_BASE:], value)
+}
+
+//Points the 32 bit relative field at target, or writes a null offset for 0.
+func (l *swiftLayout) rel(field uint64, target uint64){
+	if 0 != target{
+		l.u32(field, uint32(int32(int64(target) - int64(field))))
+	}
+}
+
+func (l *swiftLayout) str(s string)uint64{
+	address := l.next
+	copy(l.data[address - SWIFT_BASE:], s)
+	l.next += uint64(len(s)) + 1
+	return address
+}
+
+//A mangled name made of one symbolic reference to the descriptor at target.
+func (l *swiftLayout) symbolic(target uint64)uint64{
+	address := l.str("\x01\xff\xff\xff\xff")
+	l.rel(address + 1, target)
+	return address
+}
+
+//Writes the flags, parent and name every named context descriptor starts with.
+func (l *swiftLayout) context(address uint64, flags uint32, parent uint64, name string){
+	l.u32(address, flags)
+	l.rel(address + 4, parent)
+	l.rel(address + 8, l.str(name))
+}
+
+func (l *swiftLayout) list(address uint64, targets ...uint64){
+	for i, target := range targets{
+		l.rel(address + 4 * uint64(i), target)
+	}
+}
+
+//A field descriptor of 12 byte records given as flags, mangled type and name.
+func (l *swiftLayout) fields(address uint64, records ...swiftRecord){
+	binary.LittleEndian.PutUint16(l.data[address - SWIFT_BASE + 10:], 12)
+	l.u32(address + 12, uint32(len(records)))
+	for i, record := range records{
+		field := address + 16 + 12 * uint64(i)
+		l.u32(field, record.flags)
+		if "" != record.mangled{
+			l.rel(field + 4, l.str(record.mangled))
+		}
+		l.rel(field + 4, record.reference)
+		l.rel(field + 8, l.str(record.name))
+	}
+}
+
+type swiftRecord struct{
+	flags uint32
+	mangled string
+	reference uint64		//a descriptor to refer to instead of mangled
+	name string
+}
+
+func (l *swiftLayout) section(name string, address uint64, size uint64)imageSection{
+	return imageSection{segment: "__TEXT", name: name, address: address, data: l.data[address - SWIFT_BASE : address - SWIFT_BASE + size]}
+}
+
+//Module Mod with struct Outer, Inner declared in an extension of Outer, class Widget : Base, an enum with an
+//indirect case and protocol Drawable, which Outer and the Objective-C class NSView conform to. broken is
+//"extension" for an extension of Inner itself, so naming Inner never ends, or "assocty" for an associated
+//type descriptor with an unbelievable record size.
+func swiftImage(t *testing.T, broken string)FileHeader{
+	t.Helper()
+	const (
+		TYPES = SWIFT_BASE + iota * 0x40
+		PROTOS
+		PROTO
+		ASSOCTY
+	)
+	const (
+		MODULE = SWIFT_BASE + 0x200 + iota * 0x40
+		OUTER
+		EXTENSION
+		INNER
+		WIDGET
+		BASE
+		SHAPE
+		PROTOCOL
+		CONFORMANCES
+		OUTER_FIELDS
+		INNER_FIELDS
+		SHAPE_FIELDS
+	)
+	l := newSwiftLayout()
+
+	l.context(MODULE, SWIFT_CONTEXT_MODULE, 0, "Mod")
+	l.context(OUTER, SWIFT_CONTEXT_STRUCT, MODULE, "Outer")
+	l.rel(OUTER + 16, OUTER_FIELDS)
+	l.fields(OUTER_FIELDS, swiftRecord{mangled: "Si", name: "x"}, swiftRecord{flags: SWIFT_FIELD_IS_VAR, mangled: "SaySSG", name: "items"})
+	l.u32(EXTENSION, SWIFT_CONTEXT_EXTENSION)
+	l.rel(EXTENSION + 4, MODULE)
+	if "extension" == broken{
+		l.rel(EXTENSION + 8, l.symbolic(INNER))
+	} else {
+		l.rel(EXTENSION + 8, l.symbolic(OUTER))
+	}
+	l.context(INNER, SWIFT_CONTEXT_STRUCT, EXTENSION, "Inner")
+	//no fields, and a record size of 0 to go with them
+	l.rel(INNER + 16, INNER_FIELDS)
+	l.context(WIDGET, SWIFT_CONTEXT_CLASS | SWIFT_CONTEXT_IS_GENERIC, MODULE, "Widget")
+	l.rel(WIDGET + 20, l.symbolic(BASE))
+	l.context(BASE, SWIFT_CONTEXT_CLASS, MODULE, "Base")
+	l.context(SHAPE, SWIFT_CONTEXT_ENUM, MODULE, "Shape")
+	l.rel(SHAPE + 16, SHAPE_FIELDS)
+	l.fields(SHAPE_FIELDS,
+		swiftRecord{mangled: "Sd", name: "circle"},
+		swiftRecord{name: "empty"},
+		swiftRecord{flags: SWIFT_FIELD_IS_INDIRECT_CASE, reference: l.symbolic(SHAPE), name: "pair"})
+
+	l.context(PROTOCOL, SWIFT_CONTEXT_PROTOCOL, MODULE, "Drawable")
+	l.u32(PROTOCOL + 16, 3)
+	l.rel(PROTOCOL + 20, l.str("Canvas Pen"))
+	//protocol, type reference, witness table and flags holding the type reference kind
+	l.rel(CONFORMANCES, PROTOCOL)
+	l.rel(CONFORMANCES + 4, OUTER)
+	l.rel(CONFORMANCES + 0x10, PROTOCOL)
+	l.rel(CONFORMANCES + 0x14, l.str("NSView"))
+	l.u32(CONFORMANCES + 0x1c, SWIFT_TYPE_REF_DIRECT_OBJC_NAME << 3)
+
+	//conforming type, protocol, count and record size, then the name and type of each binding
+	l.rel(ASSOCTY, l.symbolic(OUTER))
+	l.rel(ASSOCTY + 4, l.symbolic(PROTOCOL))
+	l.u32(ASSOCTY + 8, 1)
+	l.u32(ASSOCTY + 12, 8)
+	l.rel(ASSOCTY + 16, l.str("Canvas"))
+	l.rel(ASSOCTY + 20, l.str("Si"))
+	//no bindings, and no record size either
+	l.rel(ASSOCTY + 24, l.symbolic(WIDGET))
+	l.rel(ASSOCTY + 28, l.symbolic(PROTOCOL))
+	l.rel(ASSOCTY + 40, l.symbolic(SHAPE))
+	l.rel(ASSOCTY + 44, l.symbolic(PROTOCOL))
+	l.u32(ASSOCTY + 48, 1)
+	if "assocty" == broken{
+		l.u32(ASSOCTY + 52, 0x1000)
+	} else {
+		l.u32(ASSOCTY + 52, 8)
+	}
+	l.rel(ASSOCTY + 56, l.str("Pen"))
+	l.rel(ASSOCTY + 60, l.str("Sd"))
+
+	l.list(TYPES, OUTER, INNER, WIDGET, BASE, SHAPE)
+	l.list(PROTOS, PROTOCOL)
+	l.list(PROTO, CONFORMANCES, CONFORMANCES + 0x10)
+	return buildImage(t, []imageSection{
+		{segment: "__TEXT", address: SWIFT_BASE, data: l.data},
+		l.section("__swift5_types", TYPES, 20),
+		l.section("__swift5_protos", PROTOS, 4),
+		l.section("__swift5_proto", PROTO, 8),
+		l.section("__swift5_assocty", ASSOCTY, 64),
+	})
+}
+
+func TestSwift(t *testing.T){
+	metadata, err := swiftImage(t, "").Swift()
+	if nil != err{
+		t.Fatal(err)
+	}
+
+	types := []SwiftType{
+		{Kind: "struct", Name: "Mod.Outer", Conformances: []string{"Mod.Drawable"}, Fields: []SwiftField{
+			{Name: "x", Type: "Swift.Int"},
+			{Name: "items", Type: "[Swift.String]", Var: true},
+		}},
+		//declared in an extension, which names Outer by its mangled name
+		{Kind: "struct", Name: "Mod.Outer.Inner"},
+		{Kind: "class", Name: "Mod.Widget", Generic: true, SuperClass: "Mod.Base"},
+		{Kind: "class", Name: "Mod.Base"},
+		{Kind: "enum", Name: "Mod.Shape", Fields: []SwiftField{
+			{Name: "circle", Type: "Swift.Double"},
+			{Name: "empty"},
+			{Name: "pair", Type: "Mod.Shape", Indirect: true},
+		}},
+	}
+	if len(types) != len(metadata.Types){
+		t.Fatalf("types %+v", metadata.Types)
+	}
+	for i := range types{
+		types[i].Address = metadata.Types[i].Address
+		if !reflect.DeepEqual(types[i], metadata.Types[i]){
+			t.Errorf("type %+v\nwant %+v", metadata.Types[i], types[i])
+		}
+	}
+
+	if 1 != len(metadata.Protocols){
+		t.Fatalf("protocols %+v", metadata.Protocols)
+	}
+	protocol := metadata.Protocols[0]
+	if "Mod.Drawable" != protocol.Name || 3 != protocol.Requirements || !reflect.DeepEqual([]string{"Canvas", "Pen"}, protocol.AssociatedTypes){
+		t.Errorf("protocol %+v", protocol)
+	}
+
+	var conformances [][2]string
+	for _, conformance := range metadata.Conformances{
+		conformances = append(conformances, [2]string{conformance.Type, conformance.Protocol})
+	}
+	if !reflect.DeepEqual([][2]string{{"Mod.Outer", "Mod.Drawable"}, {"NSView", "Mod.Drawable"}}, conformances){
+		t.Errorf("conformances %v", conformances)
+	}
+
+	associated := []SwiftAssociatedTypes{
+		{Type: "Mod.Outer", Protocol: "Mod.Drawable", Bindings: []SwiftField{{Name: "Canvas", Type: "Swift.Int"}}},
+		{Type: "Mod.Widget", Protocol: "Mod.Drawable"},
+		{Type: "Mod.Shape", Protocol: "Mod.Drawable", Bindings: []SwiftField{{Name: "Pen", Type: "Swift.Double"}}},
+	}
+	if !reflect.DeepEqual(associated, metadata.AssociatedTypes){
+		t.Errorf("associated types %+v\nwant %+v", metadata.AssociatedTypes, associated)
+	}
+}
+
+//Bad metadata is reported, and costs the names and bindings it touches rather than the rest of the listing.
+func TestSwiftMalformed(t *testing.T){
+	metadata, err := swiftImage(t, "assocty").Swift()
+	if nil == err{
+		t.Error("no error for an associated type record size of 0x1000")
+	}
+	if 3 != len(metadata.AssociatedTypes) || nil != metadata.AssociatedTypes[2].Bindings || 1 != len(metadata.AssociatedTypes[0].Bindings){
+		t.Errorf("associated types %+v", metadata.AssociatedTypes)
+	}
+
+	metadata, err = swiftImage(t, "extension").Swift()
+	if nil == err{
+		t.Error("no error for an extension of the type declared in it")
+	}
+	if 5 != len(metadata.Types) || "Mod.Outer" != metadata.Types[0].Name || "Mod.Base" != metadata.Types[3].Name{
+		t.Errorf("types %+v", metadata.Types)
+	}
+}
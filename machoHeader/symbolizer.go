@@ -0,0 +1,168 @@
+package machoHeader
+
+import (
+	"cycle1/demangle"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//What an address in the image is, for disassembly comments and cross references
+const (
+	REFERENCE_STUB		= "stub"		//a symbol stub, Name is the imported function
+	REFERENCE_POINTER	= "pointer"		//a GOT or lazy pointer slot, Name is the imported symbol
+	REFERENCE_SYMBOL	= "symbol"		//a defined symbol or a function, Name may have a +offset
+	REFERENCE_STRING	= "string"		//a string literal, Name is its value
+	REFERENCE_CFSTRING	= "cfstring"
+	REFERENCE_SELECTOR	= "selector"	//an __objc_selrefs slot, Name is the selector
+	REFERENCE_CLASS		= "class"		//an __objc_classrefs or __objc_superrefs slot, Name is the class
+	REFERENCE_SECTION	= "section"		//anything else inside a section, Name is "__DATA,__data+0x10"
+)
+
+type Reference struct{
+	Kind string			`json:"kind"`
+	Name string			`json:"name"`
+	Address uint64		`json:"address"`
+}
+
+//Resolves addresses to names. Building one reads the symbol, indirect symbol and string tables once, so
+//it is shared by everything which annotates many addresses.
+type symbolizer struct{
+	m FileHeader
+	r *metadataReader
+	symbols map[uint64]string
+	imports map[uint64]Reference		//stubs and pointer slots from the indirect symbol table
+	literals map[uint64]StringLiteral
+	functions []Function
+}
+
+/*
+	//////////////////////////////////////// PUBLIC METHODS ////////////////////////////////////////
+*/
+
+//Formats the reference the way disassembly comments show it.
+func (r Reference) String()string{
+	switch r.Kind{
+	case REFERENCE_STUB:
+		return "symbol stub for: " + r.Name
+	case REFERENCE_POINTER:
+		return "pointer to: " + r.Name
+	case REFERENCE_STRING:
+		return strconv.Quote(r.Name)
+	case REFERENCE_CFSTRING:
+		return "@" + strconv.Quote(r.Name)
+	case REFERENCE_SELECTOR:
+		return "selector: " + r.Name
+	case REFERENCE_CLASS:
+		return "class: " + r.Name
+	}
+	return r.Name
+}
+
+//The reference with the symbol it names demangled, keeping any +offset. Strings, selectors, classes and
+//sections are left as they are.
+func (r Reference) Demangled()Reference{
+	switch r.Kind{
+	case REFERENCE_STUB, REFERENCE_POINTER:
+		r.Name = demangle.Demangle(r.Name)
+	case REFERENCE_SYMBOL:
+		name, offset, _ := strings.Cut(r.Name, "+0x")
+		if demangled := demangle.Demangle(name); demangled != name{
+			r.Name = demangled
+			if "" != offset{
+				r.Name += "+0x" + offset
+			}
+		}
+	}
+	return r
+}
+
+/*
+	//////////////////////////////////////// PRIVATE CLASS METHODS ////////////////////////////////////////
+*/
+
+func (m FileHeader) newSymbolizer()*symbolizer{
+	s := &symbolizer{m: m, r: newMetadataReader(m), symbols: m.functionNames(), imports: map[uint64]Reference{}, literals: map[uint64]StringLiteral{}}
+	s.functions, _ = m.Functions()
+
+	symbols, _ := m.Symbols()
+	indirect, _ := m.IndirectSymbols()
+	for i := range m.LoadCommands{
+		for _, section := range m.LoadCommands[i].Sections{
+			kind, stride := REFERENCE_POINTER, uint64(8)
+			switch section.Type(){
+			case S_SYMBOL_STUBS:
+				kind, stride = REFERENCE_STUB, uint64(section.Special2)
+			case S_NON_LAZY_SYMBOL_POINTERS, S_LAZY_SYMBOL_POINTERS, S_LAZY_DYLIB_SYMBOL_POINTERS, S_THREAD_LOCAL_VARIABLE_POINTERS:
+			default:
+				continue
+			}
+			if 0 == stride{
+				continue
+			}
+			//reserved1 is the index of the section's first entry in the indirect symbol table
+			for j := uint64(0); j < section.Size / stride; j++{
+				index := uint64(section.Special1) + j
+				if index >= uint64(len(indirect)) || 0 != indirect[index] & (INDIRECT_SYMBOL_LOCAL | INDIRECT_SYMBOL_ABS){
+					continue
+				}
+				if symbol := indirect[index]; symbol < uint32(len(symbols)){
+					s.imports[section.Address + j * stride] = Reference{Kind: kind, Name: symbols[symbol].Name}
+				}
+			}
+		}
+	}
+
+	literals, _ := m.Strings()
+	for _, literal := range literals{
+		s.literals[literal.Address] = literal
+	}
+	return s
+}
+
+//Classifies addr, returning a reference with an empty Kind for addresses outside every section.
+func (s *symbolizer) resolve(addr uint64)Reference{
+	reference := s.classify(addr)
+	reference.Address = addr
+	return reference
+}
+
+func (s *symbolizer) classify(addr uint64)Reference{
+	if reference, ok := s.imports[addr]; ok{
+		return reference
+	}
+	if name, ok := s.symbols[addr]; ok{
+		return Reference{Kind: REFERENCE_SYMBOL, Name: name}
+	}
+	if literal, ok := s.literals[addr]; ok{
+		if LITERAL_CFSTRING == literal.Kind{
+			return Reference{Kind: REFERENCE_CFSTRING, Name: literal.Value}
+		}
+		return Reference{Kind: REFERENCE_STRING, Name: literal.Value}
+	}
+
+	section, err := s.m.SectionForVA(addr)
+	if nil != err{
+		return Reference{}
+	}
+	switch section.SectionName{
+	case "__objc_selrefs":
+		target, _ := s.r.pointer(addr)
+		if name := s.r.stringAt(target); "" != name{
+			return Reference{Kind: REFERENCE_SELECTOR, Name: name}
+		}
+	case "__objc_classrefs", "__objc_superrefs":
+		if name := s.r.className(addr); "" != name{
+			return Reference{Kind: REFERENCE_CLASS, Name: name}
+		}
+	case "__got", "__auth_got":
+		//chained fixup images bind the GOT without indirect symbol entries
+		if target, symbol := s.r.pointer(addr); 0 == target && "" != symbol{
+			return Reference{Kind: REFERENCE_POINTER, Name: symbol}
+		}
+	}
+	if function := FunctionAt(s.functions, addr); nil != function && "" != function.Name{
+		return Reference{Kind: REFERENCE_SYMBOL, Name: fmt.Sprintf("%s+0x%x", function.Name, addr - function.Address)}
+	}
+	return Reference{Kind: REFERENCE_SECTION, Name: fmt.Sprintf("%s+0x%x", sectionLabel(*section), addr - section.Address)}
+}
@@ -0,0 +1,177 @@
+package machoHeader
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+//taken from Library/Developer/CommandLineTools/SDKs/MacOSX10.15.sdk/usr/include/mach-o/nlist.h
+const (
+	N_STAB		= 0xe0	/* if any of these bits set, a symbolic debugging entry */
+	N_PEXT		= 0x10	/* private external symbol bit */
+	N_TYPE		= 0x0e	/* mask for the type bits */
+	N_EXT		= 0x01	/* external symbol bit, set for external symbols */
+
+	N_UNDF		= 0x0	/* undefined, n_sect == NO_SECT */
+	N_ABS		= 0x2	/* absolute, n_sect == NO_SECT */
+	N_SECT		= 0xe	/* defined in section number n_sect */
+	N_PBUD		= 0xc	/* prebound undefined (defined in a dylib) */
+	N_INDR		= 0xa	/* indirect */
+
+	N_WEAK_REF	= 0x0040	/* symbol is weak referenced */
+	N_WEAK_DEF	= 0x0080	/* coalesced symbol is a weak definition */
+
+	NLIST_64_SIZE = 16
+)
+
+//Library ordinals of undefined symbols which do not refer to a dylib load command, from mach-o/nlist.h
+const (
+	SELF_LIBRARY_ORDINAL	= 0x0
+	MAX_LIBRARY_ORDINAL		= 0xfd
+	DYNAMIC_LOOKUP_ORDINAL	= 0xfe		//flat namespace lookup
+	EXECUTABLE_ORDINAL		= 0xff		//bound from the main executable
+)
+
+//Values in the indirect symbol table which do not refer to a symbol
+const (
+	INDIRECT_SYMBOL_LOCAL	= 0x80000000
+	INDIRECT_SYMBOL_ABS		= 0x40000000
+)
+
+type Symbol struct{
+	Name string
+	Type uint8
+	Sect uint8
+	Desc uint16
+	Value uint64
+}
+
+/*
+	//////////////////////////////////////// PUBLIC METHODS ////////////////////////////////////////
+*/
+
+func (s Symbol) IsDebug()bool{
+	return 0 != s.Type & N_STAB
+}
+
+func (s Symbol) IsExternal()bool{
+	return 0 != s.Type & N_EXT
+}
+
+//Undefined external symbols are the ones bound from other images at load time.
+func (s Symbol) IsUndefined()bool{
+	return !s.IsDebug() && N_UNDF == s.Type & N_TYPE
+}
+
+func (s Symbol) IsDefined()bool{
+	return !s.IsDebug() && N_SECT == s.Type & N_TYPE
+}
+
+//For an undefined symbol in a two level namespace image, the 1 based index of the dylib it comes from.
+func (s Symbol) LibraryOrdinal()int{
+	return int(s.Desc >> 8)
+}
+
+//Describes where an undefined symbol is bound from: the install name of one of dylibs, which are the image's
+//Dylibs, or "this image", "flat namespace" or "main executable" for the special ordinals.
+func (s Symbol) Library(dylibs []DylibCommand)string{
+	switch ordinal := s.LibraryOrdinal(); {
+	case SELF_LIBRARY_ORDINAL == ordinal:
+		return "this image"
+	case DYNAMIC_LOOKUP_ORDINAL == ordinal:
+		return "flat namespace"
+	case EXECUTABLE_ORDINAL == ordinal:
+		return "main executable"
+	case ordinal <= len(dylibs):
+		return dylibs[ordinal-1].Name
+	default:
+		return fmt.Sprintf("ordinal %d", ordinal)
+	}
+}
+
+/*
+	//////////////////////////////////////// PUBLIC CLASS METHODS ////////////////////////////////////////
+*/
+
+//Reads the LC_SYMTAB symbol table. Images without one return an empty list.
+func (m FileHeader) Symbols()([]Symbol, error){
+	found := m.FindCommands(LC_SYMTAB)
+	if 0 == len(found){
+		return nil, nil
+	}
+	symtab, ok := found[0].(SymtabCommand)
+	if !ok{
+		return nil, errors.New("LC_SYMTAB could not be decoded")
+	}
+
+	table, err := m.ReadBytes(uint64(symtab.SymbolOffset), uint64(symtab.NumSymbols) * NLIST_64_SIZE)
+	if nil != err{
+		return nil, fmt.Errorf("symbol table: %w", err)
+	}
+	strings, err := m.ReadBytes(uint64(symtab.StringOffset), uint64(symtab.StringSize))
+	if nil != err{
+		return nil, fmt.Errorf("string table: %w", err)
+	}
+
+	symbols := make([]Symbol, symtab.NumSymbols)
+	for i := range symbols{
+		entry := table[i * NLIST_64_SIZE:]
+		symbols[i] = Symbol{
+			Type:	entry[4],
+			Sect:	entry[5],
+			Desc:	binary.LittleEndian.Uint16(entry[6:8]),
+			Value:	binary.LittleEndian.Uint64(entry[8:16]),
+		}
+		if index := binary.LittleEndian.Uint32(entry[0:4]); index < uint32(len(strings)){
+			symbols[i].Name = cString(strings[index:])
+		}
+	}
+	return symbols, nil
+}
+
+//Returns the undefined external symbols, the functions and data the image imports.
+func (m FileHeader) Imports()([]Symbol, error){
+	symbols, err := m.Symbols()
+	var imports []Symbol
+	for _, symbol := range symbols{
+		if symbol.IsUndefined() && symbol.IsExternal(){
+			imports = append(imports, symbol)
+		}
+	}
+	return imports, err
+}
+
+//Returns the (mangled) names of the imported symbols as a set, e.g. "___stack_chk_guard". The symbol table
+//is read every time, so callers which look up several names keep the set.
+func (m FileHeader) ImportSet()map[string]bool{
+	imports, _ := m.Imports()
+	set := make(map[string]bool, len(imports))
+	for _, symbol := range imports{
+		set[symbol.Name] = true
+	}
+	return set
+}
+
+
+//Reads the indirect symbol table from LC_DYSYMTAB. Each entry is an index into Symbols, or one of the
+//INDIRECT_SYMBOL_ values.
+func (m FileHeader) IndirectSymbols()([]uint32, error){
+	found := m.FindCommands(LC_DYSYMTAB)
+	if 0 == len(found){
+		return nil, nil
+	}
+	dysymtab, ok := found[0].(DysymtabCommand)
+	if !ok{
+		return nil, errors.New("LC_DYSYMTAB could not be decoded")
+	}
+	table, err := m.ReadBytes(uint64(dysymtab.IndirectSymOffset), uint64(dysymtab.NumIndirectSyms) * 4)
+	if nil != err{
+		return nil, fmt.Errorf("indirect symbol table: %w", err)
+	}
+	indirect := make([]uint32, dysymtab.NumIndirectSyms)
+	for i := range indirect{
+		indirect[i] = binary.LittleEndian.Uint32(table[i * 4:])
+	}
+	return indirect, nil
+}
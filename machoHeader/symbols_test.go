@@ -0,0 +1,44 @@
+package machoHeader
+
+import (
+	"testing"
+)
+
+func TestImports(t *testing.T){
+	m := loadFixture(t)
+	imports, err := m.Imports()
+	if nil != err{
+		t.Fatal(err)
+	}
+	dylibs := m.Dylibs()
+	found := false
+	for _, symbol := range imports{
+		if "_printf" == symbol.Name{
+			found = true
+			if library := symbol.Library(dylibs); "/usr/lib/libSystem.B.dylib" != library{
+				t.Errorf("_printf is bound from %q", library)
+			}
+		}
+	}
+	if !found || !m.ImportSet()["_printf"]{
+		t.Errorf("_printf is not imported: %+v", imports)
+	}
+}
+
+func TestSymbolLibrary(t *testing.T){
+	dylibs := []DylibCommand{{Name: "/usr/lib/libSystem.B.dylib"}, {Name: "@rpath/Foo.framework/Foo"}}
+	cases := map[int]string{
+		SELF_LIBRARY_ORDINAL:	"this image",
+		1:						"/usr/lib/libSystem.B.dylib",
+		2:						"@rpath/Foo.framework/Foo",
+		3:						"ordinal 3",
+		DYNAMIC_LOOKUP_ORDINAL:	"flat namespace",
+		EXECUTABLE_ORDINAL:		"main executable",
+	}
+	for ordinal, want := range cases{
+		symbol := Symbol{Name: "_f", Type: N_EXT, Desc: uint16(ordinal) << 8}
+		if library := symbol.Library(dylibs); want != library{
+			t.Errorf("ordinal 0x%x: %q, want %q", ordinal, library, want)
+		}
+	}
+}
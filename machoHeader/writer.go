@@ -0,0 +1,354 @@
+package machoHeader
+
+import (
+	"debug/macho"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+)
+
+//Size of mach_header_64, including the reserved field
+const MACH_HEADER_64_SIZE = 32
+
+var ErrNoHeaderSpace = errors.New("not enough padding after the load commands")
+
+//Typed commands which can be turned back into bytes implement CommandEncoder. Commands registered by third
+//parties can implement it too, otherwise they have to be updated through their Raw bytes.
+type CommandEncoder interface{
+	Encode() ([]byte, error)
+}
+
+/*
+	//////////////////////////////////////// PUBLIC METHODS ////////////////////////////////////////
+*/
+
+//Builds a LoadCommand from a typed command so it can be added to a FileHeader.
+func NewLoadCommand(c Command)(LoadCommand, error){
+	var l LoadCommand
+	err := l.Update(c)
+	return l, err
+}
+
+func (c DylibCommand) Encode()([]byte, error){
+	data := stringCommand(c.Command, 24, c.Name)
+	binary.LittleEndian.PutUint32(data[12:16], c.Timestamp)
+	binary.LittleEndian.PutUint32(data[16:20], c.CurrentVersion)
+	binary.LittleEndian.PutUint32(data[20:24], c.CompatibilityVersion)
+	return data, nil
+}
+
+func (c DylinkerCommand) Encode()([]byte, error){
+	return stringCommand(c.Command, 12, c.Name), nil
+}
+
+func (c SubCommand) Encode()([]byte, error){
+	return stringCommand(c.Command, 12, c.Name), nil
+}
+
+func (c RpathCommand) Encode()([]byte, error){
+	return stringCommand(c.Command, 12, c.Path), nil
+}
+
+func (c UUIDCommand) Encode()([]byte, error){
+	data := fixedCommand(c.Command, 24)
+	copy(data[8:24], c.UUID[:])
+	return data, nil
+}
+
+func (c SymtabCommand) Encode()([]byte, error){
+	return fixedCommand(c.Command, 24, c.SymbolOffset, c.NumSymbols, c.StringOffset, c.StringSize), nil
+}
+
+func (c DysymtabCommand) Encode()([]byte, error){
+	return fixedCommand(c.Command, 80, c.ILocalSym, c.NLocalSym, c.IExtDefSym, c.NExtDefSym, c.IUndefSym, c.NUndefSym,
+		c.TocOffset, c.NumToc, c.ModTabOffset, c.NumModTab, c.ExtRefSymOffset, c.NumExtRefSyms,
+		c.IndirectSymOffset, c.NumIndirectSyms, c.ExtRelOffset, c.NumExtRel, c.LocRelOffset, c.NumLocRel), nil
+}
+
+func (c LinkeditDataCommand) Encode()([]byte, error){
+	return fixedCommand(c.Command, 16, c.DataOffset, c.DataSize), nil
+}
+
+func (c EncryptionInfoCommand) Encode()([]byte, error){
+	size := uint32(20)
+	if LC_ENCRYPTION_INFO_64 == c.Command{
+		size = 24
+	}
+	return fixedCommand(c.Command, size, c.CryptOffset, c.CryptSize, c.CryptID), nil
+}
+
+func (c DyldInfoCommand) Encode()([]byte, error){
+	return fixedCommand(c.Command, 48, c.RebaseOffset, c.RebaseSize, c.BindOffset, c.BindSize, c.WeakBindOffset,
+		c.WeakBindSize, c.LazyBindOffset, c.LazyBindSize, c.ExportOffset, c.ExportSize), nil
+}
+
+func (c VersionMinCommand) Encode()([]byte, error){
+	return fixedCommand(c.Command, 16, c.Version, c.SDK), nil
+}
+
+func (c BuildVersionCommand) Encode()([]byte, error){
+	fields := []uint32{c.Platform, c.MinOS, c.SDK, uint32(len(c.Tools))}
+	for _, tool := range c.Tools{
+		fields = append(fields, tool.Tool, tool.Version)
+	}
+	return fixedCommand(c.Command, uint32(8 + 4 * len(fields)), fields...), nil
+}
+
+func (c EntryPointCommand) Encode()([]byte, error){
+	data := fixedCommand(c.Command, 24)
+	binary.LittleEndian.PutUint64(data[8:16], c.EntryOffset)
+	binary.LittleEndian.PutUint64(data[16:24], c.StackSize)
+	return data, nil
+}
+
+func (c SourceVersionCommand) Encode()([]byte, error){
+	data := fixedCommand(c.Command, 16)
+	binary.LittleEndian.PutUint64(data[8:16], c.Version)
+	return data, nil
+}
+
+func (c LinkerOptionCommand) Encode()([]byte, error){
+	body := []byte{}
+	for _, option := range c.Options{
+		body = append(append(body, option...), 0)
+	}
+	data := fixedCommand(c.Command, align8(12 + uint32(len(body))), uint32(len(c.Options)))
+	copy(data[12:], body)
+	return data, nil
+}
+
+func (c NoteCommand) Encode()([]byte, error){
+	data := fixedCommand(c.Command, 40)
+	if err := putName(data[8:24], c.DataOwner); nil != err{
+		return nil, fmt.Errorf("LC_NOTE data owner %w", err)
+	}
+	binary.LittleEndian.PutUint64(data[24:32], c.Offset)
+	binary.LittleEndian.PutUint64(data[32:40], c.DataSize)
+	return data, nil
+}
+
+/*
+	//////////////////////////////////////// PUBLIC CLASS METHODS ////////////////////////////////////////
+*/
+
+//Replaces the contents of a load command with c. c is encoded into Raw and decoded again, so Decoded always
+//matches what will be written. Edits made directly to Decoded are not written out; use Update, or change Raw.
+func (l *LoadCommand) Update(c Command)error{
+	if segment, ok := c.(*LoadCommand); ok{
+		raw, err := segment.encodeSegment64()
+		if nil != err{
+			return err
+		}
+		offset := l.Offset
+		*l = *segment
+		l.Offset = offset
+		l.Raw = raw
+		l.CommandSize = uint32(len(raw))
+		l.NumOfSections = uint32(len(l.Sections))
+		return nil
+	}
+
+	encoder, ok := c.(CommandEncoder)
+	if !ok{
+		return fmt.Errorf("%s: no encoder for %T", CommandName(c.Type()), c)
+	}
+
+	raw, err := encoder.Encode()
+	if nil != err{
+		return fmt.Errorf("%s: %w", CommandName(c.Type()), err)
+	}
+	decoded, err := DecodeCommand(raw)
+	if nil != err{
+		return err
+	}
+
+	offset := l.Offset
+	*l = LoadCommand{Command: c.Type(), CommandSize: uint32(len(raw)), Decoded: decoded, Offset: offset, Raw: raw}
+	return nil
+}
+
+//Returns how many bytes the header and load commands take up once encoded, and how many bytes are available
+//before the first section or segment contents begin.
+func (m FileHeader) CommandSpace()(used uint64, available uint64){
+	used = MACH_HEADER_64_SIZE
+	for i := range m.LoadCommands{
+		used += m.LoadCommands[i].encodedSize()
+	}
+	return used, m.commandLimit()
+}
+
+//Serializes the FileHeader back into a complete Mach-O image. Ncmd and Cmdsz are recomputed from
+//LoadCommands, segments are encoded from their fields and every other command from its Raw bytes. Everything
+//after the load commands is copied from the original file, and the padding the commands leave behind is zeroed.
+func (m FileHeader) Bytes()([]byte, error){
+	if macho.Magic64 != m.Header.Magic{
+		return nil, fmt.Errorf("only 64 bit little endian images can be written, magic is 0x%x", m.Header.Magic)
+	}
+	if len(m.data) < MACH_HEADER_64_SIZE{
+		return nil, errors.New("FileHeader was not loaded from a file")
+	}
+
+	var commands []byte
+	for i := range m.LoadCommands{
+		raw, err := m.LoadCommands[i].encode()
+		if nil != err{
+			return nil, fmt.Errorf("load command %d (%s): %w", i, CommandName(m.LoadCommands[i].Command), err)
+		}
+		if len(raw) < 8 || 0 != len(raw) % 8{
+			return nil, fmt.Errorf("load command %d (%s) is %d bytes, which is not a multiple of 8", i, CommandName(m.LoadCommands[i].Command), len(raw))
+		}
+		commands = append(commands, raw...)
+	}
+
+	end := uint64(MACH_HEADER_64_SIZE + len(commands))
+	limit := m.commandLimit()
+	if end > limit{
+		return nil, fmt.Errorf("load commands need 0x%x bytes but the first section starts at 0x%x: %w", end, limit, ErrNoHeaderSpace)
+	}
+
+	out := make([]byte, len(m.data))
+	copy(out, m.data)
+
+	binary.LittleEndian.PutUint32(out[0:4], m.Header.Magic)
+	binary.LittleEndian.PutUint32(out[4:8], uint32(m.Header.Cpu))
+	binary.LittleEndian.PutUint32(out[8:12], m.Header.SubCpu)
+	binary.LittleEndian.PutUint32(out[12:16], uint32(m.Header.Type))
+	binary.LittleEndian.PutUint32(out[16:20], uint32(len(m.LoadCommands)))
+	binary.LittleEndian.PutUint32(out[20:24], uint32(len(commands)))
+	binary.LittleEndian.PutUint32(out[24:28], m.Header.Flags)
+	copy(out[MACH_HEADER_64_SIZE:], commands)
+
+	//If the commands shrank, clear whatever the old ones left behind
+	oldEnd := uint64(MACH_HEADER_64_SIZE) + uint64(binary.LittleEndian.Uint32(m.data[20:24]))
+	for i := end; i < oldEnd && i < uint64(len(out)); i++{
+		out[i] = 0
+	}
+
+	return out, nil
+}
+
+//Writes the image produced by Bytes to outputFilename.
+func (m FileHeader) WriteFile(outputFilename string)error{
+	out, err := m.Bytes()
+	if nil != err{
+		return err
+	}
+	return os.WriteFile(outputFilename, out, 0755)
+}
+
+/*
+	//////////////////////////////////////// PRIVATE METHODS ////////////////////////////////////////
+*/
+
+func align8(size uint32)uint32{
+	return (size + 7) &^ 7
+}
+
+func fixedCommand(command uint32, size uint32, fields ...uint32)[]byte{
+	data := make([]byte, size)
+	binary.LittleEndian.PutUint32(data[0:4], command)
+	binary.LittleEndian.PutUint32(data[4:8], size)
+	for i, field := range fields{
+		binary.LittleEndian.PutUint32(data[8 + 4 * i:], field)
+	}
+	return data
+}
+
+//Copies a segment or section name into its fixed size field, which it may fill without a terminating 0.
+func putName(field []byte, name string)error{
+	if len(name) > len(field){
+		return fmt.Errorf("%q is longer than %d bytes", name, len(field))
+	}
+	copy(field, name)
+	return nil
+}
+
+//Commands holding an lc_str place the string right after the fixed part and pad the whole command to 8 bytes.
+func stringCommand(command uint32, fixedSize uint32, value string)[]byte{
+	data := fixedCommand(command, align8(fixedSize + uint32(len(value)) + 1), fixedSize)
+	copy(data[fixedSize:], value)
+	return data
+}
+
+/*
+	//////////////////////////////////////// PRIVATE CLASS METHODS ////////////////////////////////////////
+*/
+
+func (l *LoadCommand) encode()([]byte, error){
+	if LC_SEGMENT_64 == l.Command{
+		return l.encodeSegment64()
+	}
+	return l.Raw, nil
+}
+
+//Size of the command once encoded, which does not depend on whether its names fit.
+func (l *LoadCommand) encodedSize()uint64{
+	if LC_SEGMENT_64 == l.Command{
+		return uint64(MACH_HEADER_SIZE + SECTION_HEADER_SIZE * len(l.Sections))
+	}
+	return uint64(len(l.Raw))
+}
+
+func (l *LoadCommand) encodeSegment64()([]byte, error){
+	data := fixedCommand(LC_SEGMENT_64, uint32(MACH_HEADER_SIZE + SECTION_HEADER_SIZE * len(l.Sections)))
+	if err := putName(data[8:24], l.SegmentName); nil != err{
+		return nil, fmt.Errorf("segment name %w", err)
+	}
+	binary.LittleEndian.PutUint64(data[24:32], l.VmAddress)
+	binary.LittleEndian.PutUint64(data[32:40], l.VmSize)
+	binary.LittleEndian.PutUint64(data[40:48], l.FileOffset)
+	binary.LittleEndian.PutUint64(data[48:56], l.FileSize)
+	binary.LittleEndian.PutUint32(data[56:60], l.MaxVMProtectionFlag)
+	binary.LittleEndian.PutUint32(data[60:64], l.InitVMProtectionFlag)
+	binary.LittleEndian.PutUint32(data[64:68], uint32(len(l.Sections)))
+	binary.LittleEndian.PutUint32(data[68:72], l.Flags)
+
+	for j, section := range l.Sections{
+		s := data[MACH_HEADER_SIZE + j * SECTION_HEADER_SIZE:]
+		if err := putName(s[0:16], section.SectionName); nil != err{
+			return nil, fmt.Errorf("section name %w", err)
+		}
+		if err := putName(s[16:32], section.SegmentName); nil != err{
+			return nil, fmt.Errorf("segment name of section %s %w", section.SectionName, err)
+		}
+		binary.LittleEndian.PutUint64(s[32:40], section.Address)
+		binary.LittleEndian.PutUint64(s[40:48], section.Size)
+		binary.LittleEndian.PutUint32(s[48:52], section.Offset)
+		binary.LittleEndian.PutUint32(s[52:56], section.Alignment)
+		binary.LittleEndian.PutUint32(s[56:60], section.RelocOffset)
+		binary.LittleEndian.PutUint32(s[60:64], section.NumReloc)
+		binary.LittleEndian.PutUint32(s[64:68], section.Flags)
+		binary.LittleEndian.PutUint32(s[68:72], section.Special1)
+		binary.LittleEndian.PutUint32(s[72:76], section.Special2)
+		binary.LittleEndian.PutUint32(s[76:80], section.Special3)
+	}
+	return data, nil
+}
+
+//Returns a copy whose load commands can be edited in place, as Update does, without touching m's. Raw bytes
+//and section lists are still shared, the writer replaces rather than edits them.
+func (m FileHeader) clone()FileHeader{
+	m.LoadCommands = append([]LoadCommand(nil), m.LoadCommands...)
+	return m
+}
+
+//The load commands can grow until they run into the first byte of section or segment data.
+func (m FileHeader) commandLimit()uint64{
+	limit := uint64(len(m.data))
+	for i := range m.LoadCommands{
+		segment := &m.LoadCommands[i]
+		if LC_SEGMENT_64 != segment.Command{
+			continue
+		}
+		if 0 != segment.FileOffset && 0 != segment.FileSize && segment.FileOffset < limit{
+			limit = segment.FileOffset
+		}
+		for _, section := range segment.Sections{
+			if 0 != section.Offset && 0 != section.Size && !section.isZeroFill() && uint64(section.Offset) < limit{
+				limit = uint64(section.Offset)
+			}
+		}
+	}
+	return limit
+}
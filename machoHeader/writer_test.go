@@ -0,0 +1,85 @@
+package machoHeader
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+//Every typed encoder produces a command of the size it claims which decodes back to the same value.
+func TestEncoders(t *testing.T){
+	commands := []CommandEncoder{
+		DylibCommand{CommandHeader{LC_LOAD_DYLIB, 56}, "/usr/lib/libSystem.B.dylib", 2, 0x50c6405, 0x10000},
+		DylinkerCommand{CommandHeader{LC_LOAD_DYLINKER, 32}, "/usr/lib/dyld"},
+		SubCommand{CommandHeader{LC_SUB_FRAMEWORK, 24}, "Umbrella"},
+		RpathCommand{CommandHeader{LC_RPATH, 32}, "@loader_path/../lib"},
+		UUIDCommand{CommandHeader{LC_UUID, 24}, [16]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}},
+		SymtabCommand{CommandHeader{LC_SYMTAB, 24}, 0x3000, 5, 0x3100, 0x40},
+		DysymtabCommand{CommandHeader{LC_DYSYMTAB, 80}, 0, 1, 1, 2, 3, 2, 0, 0, 0, 0, 0, 0, 0x3050, 3, 0, 0, 0, 0},
+		LinkeditDataCommand{CommandHeader{LC_FUNCTION_STARTS, 16}, 0x3000, 8},
+		EncryptionInfoCommand{CommandHeader{LC_ENCRYPTION_INFO_64, 24}, 0x4000, 0x1000, 1},
+		DyldInfoCommand{CommandHeader{LC_DYLD_INFO_ONLY, 48}, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+		VersionMinCommand{CommandHeader{LC_VERSION_MIN_MACOSX, 16}, 0xa0f00, 0xb0000},
+		BuildVersionCommand{CommandHeader{LC_BUILD_VERSION, 32}, 1, 0xb0000, 0xd0000, []BuildToolVersion{{3, 0x3010000}}},
+		EntryPointCommand{CommandHeader{LC_MAIN, 24}, 0xf50, 0},
+		SourceVersionCommand{CommandHeader{LC_SOURCE_VERSION, 16}, 0x10000000000},
+		LinkerOptionCommand{CommandHeader{LC_LINKER_OPTION, 32}, []string{"-framework", "Cocoa"}},
+		//a name of exactly 16 bytes fills the field without a terminating 0
+		NoteCommand{CommandHeader{LC_NOTE, 40}, "0123456789abcdef", 0x3000, 0x20},
+	}
+	for _, c := range commands{
+		encoded := encodeCommand(t, c)
+		if size := c.(Command).Size(); size != uint32(len(encoded)){
+			t.Errorf("%T is %d bytes, want %d", c, len(encoded), size)
+		}
+		decoded, err := DecodeCommand(encoded)
+		if nil != err || !reflect.DeepEqual(c, decoded){
+			t.Errorf("%T decodes as %+v, %v\nwant %+v", c, decoded, err, c)
+		}
+	}
+}
+
+//Names which do not fit their 16 byte fields are errors rather than being cut short.
+func TestEncodeLongNames(t *testing.T){
+	note := NoteCommand{CommandHeader{LC_NOTE, 0}, "0123456789abcdefg", 0, 0}
+	if _, err := note.Encode(); nil == err{
+		t.Error("encoded a 17 byte LC_NOTE data owner")
+	}
+	if _, err := NewLoadCommand(note); nil == err{
+		t.Error("built a load command with a 17 byte LC_NOTE data owner")
+	}
+
+	m := loadFixture(t)
+	text := m.Segment("__TEXT")
+	renamed := *text
+	renamed.SegmentName = "__TEXT_EXECUTABLE"
+	if err := text.Update(&renamed); nil == err || !strings.Contains(err.Error(), "segment name"){
+		t.Errorf("renaming a segment to a 17 byte name: %v", err)
+	}
+
+	renamed.SegmentName = "__TEXT"
+	renamed.Sections = append([]SectionHeader(nil), text.Sections...)
+	renamed.Sections[0].SectionName = "__text_and_more_code"
+	m = m.clone()
+	*m.Segment("__TEXT") = renamed
+	if _, err := m.Bytes(); nil == err || !strings.Contains(err.Error(), "section name"){
+		t.Errorf("writing a 20 byte section name: %v", err)
+	}
+}
+
+//An image written back without edits is the file it was read from.
+func TestBytesUnchanged(t *testing.T){
+	original, err := os.ReadFile(FIXTURE)
+	if nil != err{
+		t.Fatal(err)
+	}
+	written, err := loadFixture(t).Bytes()
+	if nil != err{
+		t.Fatal(err)
+	}
+	if !bytes.Equal(original, written){
+		t.Error("the fixture changed on the way through Bytes")
+	}
+}
@@ -0,0 +1,59 @@
+package machoHeader
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"golang.org/x/arch/x86/x86asm"
+)
+
+/*
+	//////////////////////////////////////// PRIVATE METHODS ////////////////////////////////////////
+*/
+
+//Disassembles x86_64 code loaded at start in AT&T syntax, like otool. Branch targets are printed as absolute
+//addresses and they and RIP relative operands are annotated with what they point to, so a call into __stubs
+//shows the imported function.
+func (d *disassembly) x86_64(code []byte, start uint64)[]Instruction{
+	var instructions []Instruction
+	for i := 0; i < len(code); {
+		pc := start + uint64(i)
+		if entry := d.dataAt(pc); nil != entry{
+			data := d.data(entry, code[i:], pc)
+			instructions = append(instructions, data...)
+			for _, directive := range data{
+				i += len(directive.Bytes) / 2
+			}
+			continue
+		}
+
+		instruction := Instruction{Address: pc}
+		d.label(&instruction)
+		inst, err := x86asm.Decode(code[i:], 64)
+		if nil != err{
+			instruction.Bytes = hex.EncodeToString(code[i : i+1])
+			instruction.Text = fmt.Sprintf(".byte 0x%02x", code[i])
+			instructions = append(instructions, instruction)
+			i++
+			continue
+		}
+		instruction.Bytes = hex.EncodeToString(code[i : i+inst.Len])
+		instruction.Text = x86asm.GNUSyntax(inst, pc, nil)
+		i += inst.Len
+
+		//relative operands count from the end of the instruction
+		next := pc + uint64(inst.Len)
+		for _, arg := range inst.Args{
+			switch operand := arg.(type){
+			case x86asm.Rel:
+				d.refer(&instruction, next + uint64(operand))
+			case x86asm.Mem:
+				if x86asm.RIP == operand.Base{
+					d.refer(&instruction, next + uint64(operand.Disp))
+				}
+			}
+		}
+		instructions = append(instructions, instruction)
+	}
+	return instructions
+}
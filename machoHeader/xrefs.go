@@ -0,0 +1,111 @@
+package machoHeader
+
+import (
+	"cycle1/demangle"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//One reference from code: the instruction at From, inside Function, branches to or loads To.
+type Xref struct{
+	From uint64				`json:"from"`
+	Function string			`json:"function"`
+	To Reference			`json:"to"`
+}
+
+//Every reference the image's code makes to imports, functions, strings, selectors and classes, in address order.
+type XrefIndex struct{
+	Xrefs []Xref			`json:"xrefs"`
+}
+
+/*
+	//////////////////////////////////////// PUBLIC METHODS ////////////////////////////////////////
+*/
+
+func (x Xref) String()string{
+	return fmt.Sprintf("0x%x in %s: %s", x.From, x.Function, x.To)
+}
+
+//The cross reference with the function it is in and the symbol it references demangled.
+func (x Xref) Demangled()Xref{
+	x.Function = demangle.Demangle(x.Function)
+	x.To = x.To.Demangled()
+	return x
+}
+
+//The references to a symbol, import, selector or class named query (with or without its leading underscore,
+//or demangled), to strings containing query, or to the address query if it is a number such as "0x100008000".
+func (index *XrefIndex) To(query string)[]Xref{
+	address, err := strconv.ParseUint(query, 0, 64)
+	isAddress := nil == err
+
+	var xrefs []Xref
+	for _, xref := range index.Xrefs{
+		to := xref.To
+		switch{
+		case isAddress && address == to.Address:
+		case nameMatches(query, to.Name):
+		case (REFERENCE_STRING == to.Kind || REFERENCE_CFSTRING == to.Kind) && strings.Contains(to.Name, query):
+		default:
+			continue
+		}
+		xrefs = append(xrefs, xref)
+	}
+	return xrefs
+}
+
+//The references made by the function named function, with or without its leading underscore or demangled.
+func (index *XrefIndex) From(function string)[]Xref{
+	var xrefs []Xref
+	for _, xref := range index.Xrefs{
+		if nameMatches(function, xref.Function){
+			xrefs = append(xrefs, xref)
+		}
+	}
+	return xrefs
+}
+
+/*
+	//////////////////////////////////////// PUBLIC CLASS METHODS ////////////////////////////////////////
+*/
+
+//Disassembles every section with instructions, except the stubs themselves, and indexes what each
+//instruction references. Branches within a function and addresses which only resolve to a section offset
+//are left out, so what remains is which functions call which imports and functions and which use which
+//strings, selectors and classes.
+func (m FileHeader) Xrefs()(*XrefIndex, error){
+	d, err := m.newDisassembly()
+	if nil != err{
+		return nil, err
+	}
+
+	index := &XrefIndex{}
+	for i := range m.LoadCommands{
+		for _, section := range m.LoadCommands[i].Sections{
+			if !section.Attributes().Has(S_ATTR_SOME_INSTRUCTIONS) || S_SYMBOL_STUBS == section.Type() || "__stub_helper" == section.SectionName || 0 == section.Size{
+				continue
+			}
+			instructions, err := d.disassemble(section.Address, section.Address + section.Size)
+			if nil != err{
+				return nil, err
+			}
+
+			function := sectionLabel(section)
+			for _, instruction := range instructions{
+				if "" != instruction.Label{
+					function = instruction.Label
+				}
+				to := instruction.Reference
+				if nil == to || REFERENCE_SECTION == to.Kind{
+					continue
+				}
+				if REFERENCE_SYMBOL == to.Kind && (function == to.Name || strings.HasPrefix(to.Name, function + "+")){
+					continue
+				}
+				index.Xrefs = append(index.Xrefs, Xref{From: instruction.Address, Function: function, To: *to})
+			}
+		}
+	}
+	return index, nil
+}
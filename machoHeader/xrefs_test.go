@@ -0,0 +1,50 @@
+package machoHeader
+
+import (
+	"testing"
+)
+
+func TestXrefs(t *testing.T){
+	index, err := loadFixture(t).Xrefs()
+	if nil != err{
+		t.Fatal(err)
+	}
+	calls := index.To("printf")
+	if 1 != len(calls) || "_main" != calls[0].Function || REFERENCE_STUB != calls[0].To.Kind{
+		t.Errorf("xrefs to printf %+v", calls)
+	}
+	strings := index.To("hello")
+	if 1 != len(strings) || 0x100000fa2 != strings[0].To.Address{
+		t.Errorf("xrefs to \"hello\" %+v", strings)
+	}
+	if from := index.From("main"); 2 != len(from){
+		t.Errorf("xrefs from main %+v", from)
+	}
+}
+
+//Mangled names can be looked up by their demangled form, and Demangled keeps symbol offsets.
+func TestXrefsDemangled(t *testing.T){
+	index := &XrefIndex{Xrefs: []Xref{
+		{From: 0x1000, Function: "__ZN3foo3barEi", To: Reference{Kind: REFERENCE_STUB, Name: "_$s4main3fooyyF"}},
+		{From: 0x1010, Function: "__ZN3foo3barEi", To: Reference{Kind: REFERENCE_SYMBOL, Name: "__ZN3foo3bazEv+0x10"}},
+		{From: 0x1020, Function: "_main", To: Reference{Kind: REFERENCE_STRING, Name: "__ZN3foo3barEi"}},
+	}}
+	if to := index.To("main.foo() -> ()"); 1 != len(to) || 0x1000 != to[0].From{
+		t.Errorf("To(main.foo() -> ()) = %+v", to)
+	}
+	if from := index.From("foo::bar(int)"); 2 != len(from){
+		t.Errorf("From(foo::bar(int)) = %+v", from)
+	}
+
+	want := []Xref{
+		{From: 0x1000, Function: "foo::bar(int)", To: Reference{Kind: REFERENCE_STUB, Name: "main.foo() -> ()"}},
+		{From: 0x1010, Function: "foo::bar(int)", To: Reference{Kind: REFERENCE_SYMBOL, Name: "foo::baz()+0x10"}},
+		//strings are not names, even when they look mangled
+		{From: 0x1020, Function: "_main", To: Reference{Kind: REFERENCE_STRING, Name: "__ZN3foo3barEi"}},
+	}
+	for i, xref := range index.Xrefs{
+		if got := xref.Demangled(); want[i] != got{
+			t.Errorf("Demangled = %+v, want %+v", got, want[i])
+		}
+	}
+}
@@ -11,6 +11,11 @@ import (
 
 func main(){
 
+	if len(os.Args) > 1{
+		runCommand(os.Args[1], os.Args[2:])
+		return
+	}
+
 	reader := bufio.NewReader(os.Stdin)
 	fmt.Println("Please enter the file you want to analyze: ")
 	fileName, err := reader.ReadString('\n')
@@ -23,3 +28,103 @@ func main(){
 	myMachoFile.PrintStruct()
 
 }
+
+func runCommand(command string, args []string){
+	switch command{
+	case "lookup":
+		lookup(args)
+	case "modify":
+		modify(args)
+	case "sign":
+		sign(args)
+	case "lipo":
+		lipo(args)
+	case "checksec":
+		checksec(args)
+	case "policy":
+		policy(args)
+	case "match":
+		match(args)
+	case "diff":
+		diff(args)
+	case "strings":
+		extractStrings(args)
+	case "objc":
+		objc(args)
+	case "swift":
+		swift(args)
+	case "symbols":
+		symbols(args)
+	case "disassemble":
+		disassemble(args)
+	case "xrefs":
+		xrefs(args)
+	case "entropy":
+		entropy(args)
+	default:
+		usage()
+	}
+}
+
+//One thin image named on the command line. A universal binary gives one per slice, labelled "file (arch)".
+type inputImage struct{
+	label string
+	header machoHeader.FileHeader
+}
+
+//Loads every thin image in fileName for the commands which take a list of files. Files and slices which
+//cannot be parsed are reported under the command's name and skipped, so one bad file does not end the run;
+//ok is false if that happened.
+func loadImages(command string, fileName string)(images []inputImage, ok bool){
+	data, err := os.ReadFile(fileName)
+	if nil != err{
+		fmt.Fprintln(os.Stderr, command+":", err)
+		return nil, false
+	}
+	if !machoHeader.IsFat(data){
+		header, err := machoHeader.ParseBytes(data)
+		if nil != err{
+			fmt.Fprintln(os.Stderr, command+":", fileName+":", err)
+			return nil, false
+		}
+		return []inputImage{{fileName, header}}, true
+	}
+
+	fat, err := machoHeader.LoadFat(data)
+	if nil != err{
+		fmt.Fprintln(os.Stderr, command+":", fileName+":", err)
+		return nil, false
+	}
+	ok = true
+	for i, arch := range fat.Arches{
+		label := fmt.Sprintf("%s (%s)", fileName, machoHeader.ArchName(arch.Cpu, arch.SubCpu))
+		header, err := fat.ParseSlice(i)
+		if nil != err{
+			fmt.Fprintln(os.Stderr, command+":", label+":", err)
+			ok = false
+			continue
+		}
+		images = append(images, inputImage{label, header})
+	}
+	return images, ok
+}
+
+func usage(){
+	fmt.Fprintln(os.Stderr, "usage: cycle1                                  (prompts for a file and prints it)")
+	fmt.Fprintln(os.Stderr, "       cycle1 lookup <file> va|offset <value>")
+	fmt.Fprintln(os.Stderr, "       cycle1 modify <input> <output> [-id name] [-change old new] [-add_rpath path] [-delete_rpath path] [-rpath old new] [-remove_signature]")
+	fmt.Fprintln(os.Stderr, "       cycle1 sign <input> <output> [-identifier id] [-entitlements plist]")
+	fmt.Fprintln(os.Stderr, "       cycle1 lipo -info <file> | -create <input>... -output <output> | <input> -thin|-remove <arch> -output <output>")
+	fmt.Fprintln(os.Stderr, "       cycle1 checksec [-json] <file>...")
+	fmt.Fprintln(os.Stderr, "       cycle1 policy [-json] <policy.json> <file>...")
+	fmt.Fprintln(os.Stderr, "       cycle1 match [-s] <rules> <file>...")
+	fmt.Fprintln(os.Stderr, "       cycle1 diff [-demangle] [-json] <old> <new>")
+	fmt.Fprintln(os.Stderr, "       cycle1 strings [-json] <file>")
+	fmt.Fprintln(os.Stderr, "       cycle1 objc [-json] <file>")
+	fmt.Fprintln(os.Stderr, "       cycle1 swift [-json] <file>")
+	fmt.Fprintln(os.Stderr, "       cycle1 symbols [-demangle] [-json] [-imports|-exports] <file>")
+	fmt.Fprintln(os.Stderr, "       cycle1 disassemble [-demangle] [-json] <file> [<segment>,<section> | <function> | <address>]")
+	fmt.Fprintln(os.Stderr, "       cycle1 xrefs [-demangle] [-json] [-from] <file> [<symbol|string|address>]")
+	fmt.Fprintln(os.Stderr, "       cycle1 entropy [-json] [-window size] <file>...")
+	os.Exit(2)
+}
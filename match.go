@@ -0,0 +1,46 @@
+package main
+
+import (
+	"cycle1/machoHeader"
+	"fmt"
+	"os"
+)
+
+//match [-s] <rules> <file>...
+//Prints "rule file" for every rule that hits, like yara. -s also prints each pattern hit. Universal binaries
+//are matched one slice at a time.
+func match(args []string){
+	showStrings := false
+	if 0 != len(args) && "-s" == args[0]{
+		showStrings = true
+		args = args[1:]
+	}
+	if len(args) < 2{
+		usage()
+	}
+
+	rules, err := machoHeader.LoadRules(args[0])
+	if nil != err{
+		fmt.Fprintln(os.Stderr, "match:", err)
+		os.Exit(2)
+	}
+
+	for _, fileName := range args[1:]{
+		images, _ := loadImages("match", fileName)
+		for _, image := range images{
+			printRuleMatches(rules.Match(image.header), image.label, showStrings)
+		}
+	}
+}
+
+func printRuleMatches(matches []machoHeader.RuleMatch, label string, showStrings bool){
+	for _, ruleMatch := range matches{
+		fmt.Println(ruleMatch.Rule, label)
+		if !showStrings{
+			continue
+		}
+		for _, hit := range ruleMatch.Matches{
+			fmt.Printf("\t0x%x (va 0x%x) $%s: %q\n", hit.Offset, hit.VA, hit.ID, hit.Data)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"cycle1/machoHeader"
+	"errors"
+	"fmt"
+	"os"
+)
+
+//modify <input> <output> [-id name] [-change old new] [-add_rpath path] [-delete_rpath path] [-rpath old new] [-remove_signature]
+//Options can be repeated and are applied in order, like install_name_tool.
+func modify(args []string){
+	if len(args) < 3{
+		usage()
+	}
+
+	myMachoFile := machoHeader.LoadStruct(args[0])
+	output := args[1]
+
+	options := args[2:]
+	for 0 != len(options){
+		var err error
+		switch{
+		case "-id" == options[0] && len(options) >= 2:
+			err = myMachoFile.SetID(options[1])
+			options = options[2:]
+		case "-change" == options[0] && len(options) >= 3:
+			err = myMachoFile.ChangeInstallName(options[1], options[2])
+			options = options[3:]
+		case "-add_rpath" == options[0] && len(options) >= 2:
+			err = myMachoFile.AddRpath(options[1])
+			options = options[2:]
+		case "-delete_rpath" == options[0] && len(options) >= 2:
+			err = myMachoFile.DeleteRpath(options[1])
+			options = options[2:]
+		case "-rpath" == options[0] && len(options) >= 3:
+			err = myMachoFile.ChangeRpath(options[1], options[2])
+			options = options[3:]
+		case "-remove_signature" == options[0]:
+			err = myMachoFile.RemoveSignature()
+			options = options[1:]
+		default:
+			fmt.Fprintln(os.Stderr, "unknown or incomplete option:", options[0])
+			usage()
+		}
+		if nil != err{
+			fmt.Fprintln(os.Stderr, "modify:", err)
+			os.Exit(1)
+		}
+	}
+
+	err := myMachoFile.WriteFile(output)
+	if errors.Is(err, machoHeader.ErrNoHeaderSpace){
+		fmt.Fprintln(os.Stderr, "modify:", err)
+		fmt.Fprintln(os.Stderr, "modify: relink with -headerpad or -headerpad_max_install_names to make room")
+		os.Exit(1)
+	} else if nil != err{
+		fmt.Fprintln(os.Stderr, "modify:", err)
+		os.Exit(1)
+	}
+
+	if myMachoFile.IsSigned(){
+		fmt.Fprintln(os.Stderr, "warning: changes invalidate the code signature of", output, "- it must be re-signed before it will run")
+	}
+}
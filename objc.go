@@ -0,0 +1,41 @@
+package main
+
+import (
+	"cycle1/machoHeader"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+//objc [-json] <file>
+//Prints the Objective-C classes, categories and protocols as a class-dump style header.
+func objc(args []string){
+	asJSON := false
+	if 0 != len(args) && "-json" == args[0]{
+		asJSON = true
+		args = args[1:]
+	}
+	if 1 != len(args){
+		usage()
+	}
+
+	metadata, err := machoHeader.LoadStruct(args[0]).ObjC()
+	if nil != err{
+		fmt.Fprintln(os.Stderr, "objc:", err)
+	}
+
+	if asJSON{
+		out, err := json.MarshalIndent(metadata, "", "  ")
+		if nil != err{
+			fmt.Fprintln(os.Stderr, "objc:", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(out))
+	} else {
+		fmt.Print(metadata.Header())
+	}
+
+	if nil != err{
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"cycle1/machoHeader"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+//policy [-json] <policy.json> <file>...
+//Prints PASS or FAIL for every rule and exits with 1 if any rule fails or a file cannot be read, so it can
+//gate a CI job. Universal binaries are checked one slice at a time.
+func policy(args []string){
+	asJSON := false
+	if 0 != len(args) && "-json" == args[0]{
+		asJSON = true
+		args = args[1:]
+	}
+	if len(args) < 2{
+		usage()
+	}
+
+	rules, err := machoHeader.LoadPolicy(args[0])
+	if nil != err{
+		fmt.Fprintln(os.Stderr, "policy:", err)
+		os.Exit(2)
+	}
+
+	type fileResults struct{
+		File string							`json:"file"`
+		Results []machoHeader.RuleResult	`json:"results"`
+	}
+	var all []fileResults
+	failed := false
+	for _, fileName := range args[1:]{
+		//a file which cannot be checked does not pass the gate
+		images, ok := loadImages("policy", fileName)
+		failed = failed || !ok
+		for _, image := range images{
+			results := rules.Evaluate(image.header)
+			for _, result := range results{
+				failed = failed || !result.Passed
+			}
+			all = append(all, fileResults{image.label, results})
+		}
+	}
+
+	if asJSON{
+		out, err := json.MarshalIndent(all, "", "  ")
+		if nil != err{
+			fmt.Fprintln(os.Stderr, "policy:", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(out))
+	} else {
+		for _, file := range all{
+			fmt.Println(file.File)
+			for _, result := range file.Results{
+				status := "PASS"
+				if !result.Passed{
+					status = "FAIL"
+				}
+				if "" != result.Detail{
+					fmt.Printf("\t%s %s (%s)\n", status, result.Rule, result.Detail)
+				} else {
+					fmt.Printf("\t%s %s\n", status, result.Rule)
+				}
+			}
+		}
+	}
+
+	if failed{
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"cycle1/machoHeader"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+//sign <input> <output> [-identifier id] [-entitlements plist]
+func sign(args []string){
+	if len(args) < 2{
+		usage()
+	}
+
+	identifier := filepath.Base(args[0])
+	var entitlements []byte
+
+	options := args[2:]
+	for 0 != len(options){
+		switch{
+		case "-identifier" == options[0] && len(options) >= 2:
+			identifier = options[1]
+		case "-entitlements" == options[0] && len(options) >= 2:
+			var err error
+			entitlements, err = os.ReadFile(options[1])
+			if nil != err{
+				fmt.Fprintln(os.Stderr, "sign:", err)
+				os.Exit(1)
+			}
+		default:
+			fmt.Fprintln(os.Stderr, "unknown or incomplete option:", options[0])
+			usage()
+		}
+		options = options[2:]
+	}
+
+	myMachoFile := machoHeader.LoadStruct(args[0])
+	err := myMachoFile.AdHocSign(identifier, entitlements)
+	if nil == err{
+		err = myMachoFile.WriteFile(args[1])
+	}
+	if nil != err{
+		fmt.Fprintln(os.Stderr, "sign:", err)
+		os.Exit(1)
+	}
+}
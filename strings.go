@@ -0,0 +1,46 @@
+package main
+
+import (
+	"cycle1/machoHeader"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+//strings [-json] <file>
+//Each string is printed with its address, section and kind, quoted so embedded newlines stay on one line.
+func extractStrings(args []string){
+	asJSON := false
+	if 0 != len(args) && "-json" == args[0]{
+		asJSON = true
+		args = args[1:]
+	}
+	if 1 != len(args){
+		usage()
+	}
+
+	literals, err := machoHeader.LoadStruct(args[0]).Strings()
+	if nil != err{
+		fmt.Fprintln(os.Stderr, "strings:", err)
+	}
+
+	if asJSON{
+		if nil == literals{
+			literals = []machoHeader.StringLiteral{}
+		}
+		out, err := json.MarshalIndent(literals, "", "  ")
+		if nil != err{
+			fmt.Fprintln(os.Stderr, "strings:", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(out))
+	} else {
+		for _, literal := range literals{
+			fmt.Printf("0x%x %s %s %q\n", literal.Address, literal.Section, literal.Kind, literal.Value)
+		}
+	}
+
+	if nil != err{
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"cycle1/machoHeader"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+//swift [-json] <file>
+//Lists the Swift types the image defines with their fields and conformances, then its protocols.
+func swift(args []string){
+	asJSON := false
+	if 0 != len(args) && "-json" == args[0]{
+		asJSON = true
+		args = args[1:]
+	}
+	if 1 != len(args){
+		usage()
+	}
+
+	metadata, err := machoHeader.LoadStruct(args[0]).Swift()
+	if nil != err{
+		fmt.Fprintln(os.Stderr, "swift:", err)
+	}
+
+	if asJSON{
+		out, err := json.MarshalIndent(metadata, "", "  ")
+		if nil != err{
+			fmt.Fprintln(os.Stderr, "swift:", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(out))
+	} else {
+		printSwiftMetadata(metadata)
+	}
+
+	if nil != err{
+		os.Exit(1)
+	}
+}
+
+func printSwiftMetadata(metadata machoHeader.SwiftMetadata){
+	for _, swiftType := range metadata.Types{
+		inherits := swiftType.Conformances
+		if "" != swiftType.SuperClass{
+			inherits = append([]string{swiftType.SuperClass}, inherits...)
+		}
+		fmt.Printf("// 0x%x\n%s %s", swiftType.Address, swiftType.Kind, swiftType.Name)
+		if swiftType.Generic{
+			fmt.Print("<...>")
+		}
+		if 0 != len(inherits){
+			fmt.Print(" : " + strings.Join(inherits, ", "))
+		}
+		fmt.Println(" {")
+		for _, field := range swiftType.Fields{
+			keyword := "let"
+			switch{
+			case "enum" == swiftType.Kind && field.Indirect:
+				keyword = "indirect case"
+			case "enum" == swiftType.Kind:
+				keyword = "case"
+			case field.Var:
+				keyword = "var"
+			}
+			if "" == field.Type{
+				fmt.Printf("    %s %s\n", keyword, field.Name)
+			} else if "enum" == swiftType.Kind{
+				fmt.Printf("    %s %s(%s)\n", keyword, field.Name, field.Type)
+			} else {
+				fmt.Printf("    %s %s: %s\n", keyword, field.Name, field.Type)
+			}
+		}
+		fmt.Println("}")
+		fmt.Println()
+	}
+
+	for _, protocol := range metadata.Protocols{
+		fmt.Printf("// 0x%x\nprotocol %s {\n", protocol.Address, protocol.Name)
+		for _, name := range protocol.AssociatedTypes{
+			fmt.Printf("    associatedtype %s\n", name)
+		}
+		fmt.Printf("    // %d requirements\n}\n\n", protocol.Requirements)
+	}
+
+	for _, associated := range metadata.AssociatedTypes{
+		fmt.Printf("extension %s : %s {\n", associated.Type, associated.Protocol)
+		for _, binding := range associated.Bindings{
+			fmt.Printf("    typealias %s = %s\n", binding.Name, binding.Type)
+		}
+		fmt.Println("}")
+		fmt.Println()
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"cycle1/demangle"
+	"cycle1/machoHeader"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+type symbolEntry struct{
+	Name string			`json:"name"`
+	Mangled string		`json:"mangled,omitempty"`		//set when -demangle changed the name
+	Kind string			`json:"kind"`					//local, external, undefined, import or export
+	Address uint64		`json:"address,omitempty"`
+	Library string		`json:"library,omitempty"`		//imports, the dylib the symbol is bound from
+	Details string		`json:"details,omitempty"`		//exports, re-export and attribute information
+}
+
+//symbols [-demangle] [-json] [-imports|-exports] <file>
+//Lists the symbol table without debugging entries, or only the imports with the dylib each one comes
+//from, or the export trie.
+func symbols(args []string){
+	asJSON, demangled, listing := false, false, "symbols"
+	for 0 != len(args) && 0 != len(args[0]) && '-' == args[0][0]{
+		switch args[0]{
+		case "-json":
+			asJSON = true
+		case "-demangle":
+			demangled = true
+		case "-imports", "-exports":
+			listing = args[0][1:]
+		default:
+			usage()
+		}
+		args = args[1:]
+	}
+	if 1 != len(args){
+		usage()
+	}
+
+	m := machoHeader.LoadStruct(args[0])
+	var entries []symbolEntry
+	var err error
+	switch listing{
+	case "imports":
+		entries, err = importEntries(m)
+	case "exports":
+		entries, err = exportEntries(m)
+	default:
+		entries, err = symbolEntries(m)
+	}
+	if nil != err{
+		fmt.Fprintln(os.Stderr, "symbols:", err)
+	}
+	if demangled{
+		for i := range entries{
+			if name := demangle.Demangle(entries[i].Name); name != entries[i].Name{
+				entries[i].Mangled, entries[i].Name = entries[i].Name, name
+			}
+		}
+	}
+
+	if asJSON{
+		if nil == entries{
+			entries = []symbolEntry{}
+		}
+		out, err := json.MarshalIndent(entries, "", "  ")
+		if nil != err{
+			fmt.Fprintln(os.Stderr, "symbols:", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(out))
+	} else {
+		for _, entry := range entries{
+			switch entry.Kind{
+			case "import":
+				fmt.Printf("%s (%s)\n", entry.Name, entry.Library)
+			case "export":
+				fmt.Printf("0x%x %s%s\n", entry.Address, entry.Name, entry.Details)
+			default:
+				fmt.Printf("0x%016x %-9s %s\n", entry.Address, entry.Kind, entry.Name)
+			}
+		}
+	}
+
+	if nil != err{
+		os.Exit(1)
+	}
+}
+
+func symbolEntries(m machoHeader.FileHeader)([]symbolEntry, error){
+	list, err := m.Symbols()
+	var entries []symbolEntry
+	for _, symbol := range list{
+		if symbol.IsDebug() || "" == symbol.Name{
+			continue
+		}
+		kind := "local"
+		switch{
+		case symbol.IsUndefined() && symbol.IsExternal():
+			kind = "undefined"
+		case symbol.IsExternal():
+			kind = "external"
+		}
+		entries = append(entries, symbolEntry{Name: symbol.Name, Kind: kind, Address: symbol.Value})
+	}
+	return entries, err
+}
+
+func importEntries(m machoHeader.FileHeader)([]symbolEntry, error){
+	imports, err := m.Imports()
+	dylibs := m.Dylibs()
+	var entries []symbolEntry
+	for _, symbol := range imports{
+		entries = append(entries, symbolEntry{Name: symbol.Name, Kind: "import", Library: symbol.Library(dylibs)})
+	}
+	return entries, err
+}
+
+func exportEntries(m machoHeader.FileHeader)([]symbolEntry, error){
+	exports, err := m.Exports()
+	var entries []symbolEntry
+	for _, export := range exports{
+		//everything String adds after the name
+		name := export.Name
+		export.Name = ""
+		details := export.String()
+		if !export.IsReexport(){
+			details = details[len(fmt.Sprintf("0x%x ", export.Address)):]
+		}
+		entries = append(entries, symbolEntry{Name: name, Kind: "export", Address: export.Address, Details: details})
+	}
+	return entries, err
+}
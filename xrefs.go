@@ -0,0 +1,80 @@
+package main
+
+import (
+	"cycle1/machoHeader"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+//xrefs [-demangle] [-json] [-from] <file> [<symbol|string|address>]
+//Prints the code which references the symbol, import, selector, class, string or address, or with -from
+//what the named function references. Without a name every cross reference is printed, grouped by function.
+//Symbols may be named by their demangled form either way.
+func xrefs(args []string){
+	asJSON, demangled, from := false, false, false
+	for 0 != len(args){
+		if "-json" == args[0]{
+			asJSON = true
+		} else if "-demangle" == args[0]{
+			demangled = true
+		} else if "-from" == args[0]{
+			from = true
+		} else {
+			break
+		}
+		args = args[1:]
+	}
+	if 1 != len(args) && 2 != len(args) || from && 2 != len(args){
+		usage()
+	}
+
+	m := machoHeader.LoadStruct(args[0])
+	index, err := m.Xrefs()
+	if nil != err{
+		fmt.Fprintln(os.Stderr, "xrefs:", err)
+		os.Exit(1)
+	}
+	found := index.Xrefs
+	switch{
+	case from:
+		found = index.From(args[1])
+	case 2 == len(args):
+		found = index.To(args[1])
+	}
+	if demangled{
+		for i := range found{
+			found[i] = found[i].Demangled()
+		}
+	}
+
+	if asJSON{
+		if nil == found{
+			found = []machoHeader.Xref{}
+		}
+		out, err := json.MarshalIndent(found, "", "  ")
+		if nil != err{
+			fmt.Fprintln(os.Stderr, "xrefs:", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(out))
+		return
+	}
+	if 1 == len(args) || from{
+		function := ""
+		for _, xref := range found{
+			if function != xref.Function{
+				function = xref.Function
+				fmt.Printf("%s:\n", function)
+			}
+			fmt.Printf("\t0x%x\t%s\n", xref.From, xref.To)
+		}
+		return
+	}
+	for _, xref := range found{
+		fmt.Println(xref)
+	}
+	if 0 == len(found){
+		os.Exit(1)
+	}
+}